@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+Alias adds a command named `name` standing for the call arguments `expansion`, e.g. to provide shortcuts presetting some options of the command,
+and possibly selecting one of its sub commands:
+
+	app.Alias("prod", "Run against the production", "--env", "prod")
+	app.Alias("deploy-prod", "", "--env", "prod", "deploy")
+
+	$ app deploy-prod --force
+	// runs as `app --env prod deploy --force`
+
+The expansion replaces the alias name in the call arguments, before they get parsed.
+It can contain other aliases, but an alias expanding to itself is reported as an incorrect usage.
+If desc is empty, the alias is described by its expansion in the help messages
+*/
+func (c *Cmd) Alias(name, desc string, expansion ...string) {
+	if len(desc) == 0 {
+		desc = fmt.Sprintf("Alias for %s", strings.Join(expansion, " "))
+	}
+	c.Command(name, desc, nil)
+	c.commands[len(c.commands)-1].alias = expansion
+}
+
+// expandAliases replaces the alias name at the position of the sub command name in args, if any, with its expansion, until there are none left
+func (c *Cmd) expandAliases(args []string) ([]string, error) {
+	expanded := map[*Cmd]bool{}
+	for {
+		nargsLen := c.getOptsAndArgs(args)
+		if nargsLen == len(args) {
+			return args, nil
+		}
+		alias := c.commandNamed(args[nargsLen])
+		if alias == nil || alias.alias == nil {
+			return args, nil
+		}
+		if expanded[alias] {
+			return args, &ParseError{Kind: ErrorKindIncorrectUsage, Token: alias.name, Message: fmt.Sprintf("alias %s expands to itself", alias.name)}
+		}
+		expanded[alias] = true
+
+		res := append([]string{}, args[:nargsLen]...)
+		res = append(res, alias.alias...)
+		args = append(res, args[nargsLen+1:]...)
+	}
+}
+
+// commandNamed returns the sub command named name, or nil if there is none
+func (c *Cmd) commandNamed(name string) *Cmd {
+	for _, sub := range c.commands {
+		if sub.name == name {
+			return sub
+		}
+	}
+	return nil
+}
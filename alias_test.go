@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlias(t *testing.T) {
+	defer suppressOutput()()
+
+	var (
+		env      *string
+		force    *bool
+		target   *string
+		deployed bool
+	)
+	init := func(app *Cli) {
+		deployed = false
+		env = app.StringOpt("env", "dev", "")
+		app.Command("deploy", "", func(cmd *Cmd) {
+			force = cmd.BoolOpt("force", false, "")
+			target = cmd.StringArg("TARGET", "", "")
+			cmd.Action = func() {
+				deployed = true
+			}
+		})
+		app.Alias("production", "", "--env", "prod")
+		app.Alias("deploy-prod", "Deploy to the production", "--env", "prod", "deploy")
+		app.Alias("ship", "", "deploy-prod", "--force")
+	}
+
+	require.Nil(t, runApp(init, "deploy-prod", "--force", "web"))
+	require.True(t, deployed)
+	require.Equal(t, "prod", *env)
+	require.True(t, *force)
+	require.Equal(t, "web", *target)
+
+	require.Nil(t, runApp(init, "production", "deploy", "db"))
+	require.True(t, deployed)
+	require.Equal(t, "prod", *env)
+	require.False(t, *force)
+	require.Equal(t, "db", *target)
+
+	require.Nil(t, runApp(init, "ship", "web"))
+	require.True(t, deployed)
+	require.Equal(t, "prod", *env)
+	require.True(t, *force)
+
+	require.Nil(t, runApp(init, "--env", "staging", "deploy", "web"))
+	require.Equal(t, "staging", *env, "the commands should not be affected by the aliases")
+
+	app := testApp(init)
+	app.Alias("loop", "", "--env", "x", "loop")
+	err := app.Run([]string{"app", "loop", "deploy", "web"})
+	require.NotNil(t, err)
+	require.Equal(t, "alias loop expands to itself", err.Error())
+	require.False(t, deployed)
+}
+
+func TestAliasHelp(t *testing.T) {
+	var out, stdErr string
+	defer captureAndRestoreOutput(&out, &stdErr)()
+	exitShouldBeCalledWith(t, 2, new(bool))
+
+	app := App("app", "")
+	app.Command("deploy", "Deploy", func(cmd *Cmd) {})
+	app.Alias("deploy-prod", "", "--env", "prod", "deploy")
+	app.Run([]string{"app", "-h"})
+
+	require.Contains(t, stdErr, "  deploy-prod   Alias for --env prod deploy\n")
+}
@@ -2,7 +2,10 @@ package cli
 
 import (
 	"fmt"
+	"os"
 	"reflect"
+	"regexp"
+	"time"
 )
 
 // BoolArg describes a boolean argument
@@ -17,8 +20,14 @@ type BoolArg struct {
 	EnvVar string
 	// The argument's inital value
 	Value bool
+	// A boolean to only accept the `true` and `false` values in the call arguments and the environment variables, instead of all the values accepted by strconv.ParseBool, e.g. `1` or `f`
+	Strict bool
 	// A boolean to display or not the current value of the argument in the help message
 	HideValue bool
+	// The label of the section the argument is listed under in the help message, e.g. to group related arguments, instead of `Arguments`
+	Group string
+	// The message reporting that the argument is missing from the call arguments, e.g. "please specify the source file", instead of the generic usage error
+	MissingMsg string
 }
 
 // StringArg describes a string argument
@@ -33,8 +42,21 @@ type StringArg struct {
 	EnvVar string
 	// The argument's inital value
 	Value string
+	// The fixed set of values the argument accepts, e.g. `debug info warn`, listed in the help message
+	Choices []string
+	// A boolean to match the values against Choices ignoring their case, the matching choice being stored as is
+	CaseInsensitive bool
+	// A regular expression the values passed in the call arguments must match, e.g. `^[a-z0-9-]+$`.
+	// An invalid expression panics when the argument is declared
+	Pattern string
 	// A boolean to display or not the current value of the argument in the help message
 	HideValue bool
+	// The label of the section the argument is listed under in the help message, e.g. to group related arguments, instead of `Arguments`
+	Group string
+	// The message reporting that the argument is missing from the call arguments, e.g. "please specify the source file", instead of the generic usage error
+	MissingMsg string
+	// If set, the function returning the completion candidates of a value of the argument starting with prefix, e.g. CompleteFiles (see Complete)
+	Complete func(prefix string) []string
 }
 
 // IntArg describes an int argument
@@ -49,8 +71,56 @@ type IntArg struct {
 	EnvVar string
 	// The argument's inital value
 	Value int
+	// If set, the minimum value the argument accepts, shown in the help message
+	Min *int
+	// If set, the maximum value the argument accepts, shown in the help message
+	Max *int
 	// A boolean to display or not the current value of the argument in the help message
 	HideValue bool
+	// The label of the section the argument is listed under in the help message, e.g. to group related arguments, instead of `Arguments`
+	Group string
+	// The message reporting that the argument is missing from the call arguments, e.g. "please specify the source file", instead of the generic usage error
+	MissingMsg string
+}
+
+// Float64Arg describes a float64 argument
+type Float64Arg struct {
+	Float64Param
+
+	// The argument name as will be shown in help messages
+	Name string
+	// The argument description as will be shown in help messages
+	Desc string
+	// A space separated list of environment variables names to be used to initialize this argument
+	EnvVar string
+	// The argument's inital value
+	Value float64
+	// A boolean to display or not the current value of the argument in the help message
+	HideValue bool
+	// The label of the section the argument is listed under in the help message, e.g. to group related arguments, instead of `Arguments`
+	Group string
+	// The message reporting that the argument is missing from the call arguments, e.g. "please specify the source file", instead of the generic usage error
+	MissingMsg string
+}
+
+// DurationArg describes a duration argument, e.g. `1h30m`, `30d` or `1w2d`
+type DurationArg struct {
+	DurationParam
+
+	// The argument name as will be shown in help messages
+	Name string
+	// The argument description as will be shown in help messages
+	Desc string
+	// A space separated list of environment variables names to be used to initialize this argument
+	EnvVar string
+	// The argument's inital value
+	Value time.Duration
+	// A boolean to display or not the current value of the argument in the help message
+	HideValue bool
+	// The label of the section the argument is listed under in the help message, e.g. to group related arguments, instead of `Arguments`
+	Group string
+	// The message reporting that the argument is missing from the call arguments, e.g. "please specify the source file", instead of the generic usage error
+	MissingMsg string
 }
 
 // StringsArg describes a string slice argument
@@ -66,8 +136,20 @@ type StringsArg struct {
 	EnvVar string
 	// The argument's inital value
 	Value []string
+	// The minimum number of values the argument accepts, 0 meaning no minimum
+	MinCount int
+	// The maximum number of values the argument accepts, 0 meaning no maximum
+	MaxCount int
+	// A function checking each value of the argument as it is added, e.g. to check that it is within a range
+	ValidateElem func(string) error
 	// A boolean to display or not the current value of the argument in the help message
 	HideValue bool
+	// The label of the section the argument is listed under in the help message, e.g. to group related arguments, instead of `Arguments`
+	Group string
+	// The message reporting that the argument is missing from the call arguments, e.g. "please specify the source file", instead of the generic usage error
+	MissingMsg string
+	// If set, the function returning the completion candidates of a value of the argument starting with prefix, e.g. CompleteFiles (see Complete)
+	Complete func(prefix string) []string
 }
 
 // IntsArg describes an int slice argument
@@ -83,8 +165,89 @@ type IntsArg struct {
 	EnvVar string
 	// The argument's inital value
 	Value []int
+	// The minimum number of values the argument accepts, 0 meaning no minimum
+	MinCount int
+	// The maximum number of values the argument accepts, 0 meaning no maximum
+	MaxCount int
+	// A function checking each value of the argument as it is added, e.g. to check that it is within a range
+	ValidateElem func(int) error
+	// A boolean to display or not the current value of the argument in the help message
+	HideValue bool
+	// The label of the section the argument is listed under in the help message, e.g. to group related arguments, instead of `Arguments`
+	Group string
+	// The message reporting that the argument is missing from the call arguments, e.g. "please specify the source file", instead of the generic usage error
+	MissingMsg string
+}
+
+// DurationsArg describes a duration slice argument, each value being a duration like `1h30m`, `30d` or `1w2d`
+type DurationsArg struct {
+	DurationsParam
+
+	// The argument name as will be shown in help messages
+	Name string
+	// The argument description as will be shown in help messages
+	Desc string
+	// A space separated list of environment variables names to be used to initialize this argument.
+	// The env variable should contain a comma separated list of values
+	EnvVar string
+	// The argument's inital value
+	Value []time.Duration
+	// The minimum number of values the argument accepts, 0 meaning no minimum
+	MinCount int
+	// The maximum number of values the argument accepts, 0 meaning no maximum
+	MaxCount int
+	// A boolean to display or not the current value of the argument in the help message
+	HideValue bool
+	// The label of the section the argument is listed under in the help message, e.g. to group related arguments, instead of `Arguments`
+	Group string
+	// The message reporting that the argument is missing from the call arguments, e.g. "please specify the source file", instead of the generic usage error
+	MissingMsg string
+}
+
+// Float64sArg describes a float64 slice argument
+type Float64sArg struct {
+	Float64sParam
+
+	// The argument name as will be shown in help messages
+	Name string
+	// The argument description as will be shown in help messages
+	Desc string
+	// A space separated list of environment variables names to be used to initialize this argument.
+	// The env variable should contain a comma separated list of values
+	EnvVar string
+	// The argument's inital value
+	Value []float64
+	// The minimum number of values the argument accepts, 0 meaning no minimum
+	MinCount int
+	// The maximum number of values the argument accepts, 0 meaning no maximum
+	MaxCount int
+	// A boolean to display or not the current value of the argument in the help message
+	HideValue bool
+	// The label of the section the argument is listed under in the help message, e.g. to group related arguments, instead of `Arguments`
+	Group string
+	// The message reporting that the argument is missing from the call arguments, e.g. "please specify the source file", instead of the generic usage error
+	MissingMsg string
+}
+
+// StringMapArg describes a string map argument, accepting `key=value` pairs
+type StringMapArg struct {
+	StringMapParam
+
+	// The argument name as will be shown in help messages
+	Name string
+	// The argument description as will be shown in help messages
+	Desc string
+	// A space separated list of environment variables names to be used to initialize this argument.
+	// The env variable should contain a comma separated list of key=value pairs
+	EnvVar string
+	// The argument's inital value
+	Value map[string]string
 	// A boolean to display or not the current value of the argument in the help message
 	HideValue bool
+	// The label of the section the argument is listed under in the help message, e.g. to group related arguments, instead of `Arguments`
+	Group string
+	// The message reporting that the argument is missing from the call arguments, e.g. "please specify the source file", instead of the generic usage error
+	MissingMsg string
 }
 
 /*
@@ -114,6 +277,26 @@ func (c *Cmd) IntArg(name string, value int, desc string) *int {
 	return c.mkArg(arg{name: name, desc: desc}, value).(*int)
 }
 
+/*
+Float64Arg defines a float64 argument on the command c named `name`, with an initial value of `value` and a description of `desc` which will be used in help messages.
+
+The result should be stored in a variable (a pointer to a float64) which will be populated when the app is run and the call arguments get parsed
+*/
+func (c *Cmd) Float64Arg(name string, value float64, desc string) *float64 {
+	return c.mkArg(arg{name: name, desc: desc}, value).(*float64)
+}
+
+/*
+DurationArg defines a duration argument on the command c named `name`, with an initial value of `value` and a description of `desc` which will be used in help messages.
+
+Besides the units accepted by time.ParseDuration, the value can use the `d` (24h) and `w` (7d) units, e.g. `30d` or `1w2d3h`.
+
+The result should be stored in a variable (a pointer to a time.Duration) which will be populated when the app is run and the call arguments get parsed
+*/
+func (c *Cmd) DurationArg(name string, value time.Duration, desc string) *time.Duration {
+	return c.mkArg(arg{name: name, desc: desc}, value).(*time.Duration)
+}
+
 /*
 StringsArg defines a string slice argument on the command c named `name`, with an initial value of `value` and a description of `desc` which will be used in help messages.
 
@@ -132,6 +315,39 @@ func (c *Cmd) IntsArg(name string, value []int, desc string) *[]int {
 	return c.mkArg(arg{name: name, desc: desc}, value).(*[]int)
 }
 
+/*
+DurationsArg defines a duration slice argument on the command c named `name`, with an initial value of `value` and a description of `desc` which will be used in help messages.
+
+Each value can use the units accepted by time.ParseDuration, as well as the `d` (24h) and `w` (7d) units.
+
+The result should be stored in a variable (a pointer to a time.Duration slice) which will be populated when the app is run and the call arguments get parsed
+*/
+func (c *Cmd) DurationsArg(name string, value []time.Duration, desc string) *[]time.Duration {
+	return c.mkArg(arg{name: name, desc: desc}, value).(*[]time.Duration)
+}
+
+/*
+Float64sArg defines a float64 slice argument on the command c named `name`, with an initial value of `value` and a description of `desc` which will be used in help messages.
+
+The result should be stored in a variable (a pointer to a float64 slice) which will be populated when the app is run and the call arguments get parsed
+*/
+func (c *Cmd) Float64sArg(name string, value []float64, desc string) *[]float64 {
+	return c.mkArg(arg{name: name, desc: desc}, value).(*[]float64)
+}
+
+/*
+StringMapArg defines a string map argument on the command c named `name`, with an initial value of `value` and a description of `desc` which will be used in help messages.
+
+Each call argument matched by this argument should be a `key=value` pair, which is split on its first `=`, the value possibly containing other `=`.
+A call argument without a `=` is reported as a usage error.
+When the command has no explicit spec, the argument is repeatable, i.e. it collects all the remaining pairs.
+
+The result should be stored in a variable (a pointer to a string map) which will be populated when the app is run and the call arguments get parsed
+*/
+func (c *Cmd) StringMapArg(name string, value map[string]string, desc string) *map[string]string {
+	return c.mkArg(arg{name: name, desc: desc}, value).(*map[string]string)
+}
+
 type arg struct {
 	name          string
 	desc          string
@@ -139,6 +355,21 @@ type arg struct {
 	helpFormatter func(interface{}) string
 	value         reflect.Value
 	hideValue     bool
+	source        Source
+	sourceEnvVar  string
+	minCount      int
+	maxCount      int
+	strict        bool
+	group         string
+	missingMsg    string
+	validateElem  func(interface{}) error
+	choices       []string
+	ignoreCase    bool
+	pattern       string
+	patternRe     *regexp.Regexp
+	bounds        *intBounds
+	complete      func(string) []string
+	defaultValue  interface{}
 }
 
 func (a *arg) String() string {
@@ -149,19 +380,175 @@ func (a *arg) get() interface{} {
 	return a.value.Elem().Interface()
 }
 
+// isMulti returns true for the slice and map arguments, which accept multiple values
+func (a *arg) isMulti() bool {
+	kind := a.value.Elem().Kind()
+	return kind == reflect.Slice || kind == reflect.Map
+}
+
+// isMap returns true for the map arguments, which collect key=value pairs
+func (a *arg) isMap() bool {
+	return a.value.Elem().Kind() == reflect.Map
+}
+
+// reset initializes the argument value from its env vars, or else with its initial value
+func (a *arg) reset() {
+	a.sourceEnvVar = vinit(a.value, a.envVar, ",", a.defaultValue)
+	a.source = sourceFor(a.sourceEnvVar)
+}
+
+// countBounds returns the bounds set on the number of values of the argument, e.g. `1-3` or `2+`, or an empty string if there are none
+func (a *arg) countBounds() string {
+	switch {
+	case a.maxCount > 0:
+		return fmt.Sprintf("%d-%d", a.minCount, a.maxCount)
+	case a.minCount > 0:
+		return fmt.Sprintf("%d+", a.minCount)
+	default:
+		return ""
+	}
+}
+
+// checkCount checks that the number of values of the argument is within its bounds
+func (a *arg) checkCount() error {
+	if a.minCount == 0 && a.maxCount == 0 {
+		return nil
+	}
+	n := a.value.Elem().Len()
+	if a.minCount > 0 && n < a.minCount {
+		return fmt.Errorf("argument %s requires at least %d value(s), got %d", a.name, a.minCount, n)
+	}
+	if a.maxCount > 0 && n > a.maxCount {
+		return fmt.Errorf("argument %s accepts at most %d value(s), got %d", a.name, a.maxCount, n)
+	}
+	return nil
+}
+
 func (a *arg) set(s string) error {
+	if a.strict && s != "true" && s != "false" {
+		return fmt.Errorf("invalid value %s for argument %s: expected true or false", s, a.name)
+	}
+	if len(a.choices) > 0 {
+		choice, found := matchChoice(s, a.choices, a.ignoreCase)
+		if !found {
+			return fmt.Errorf("invalid value %s for argument %s: expected one of %v", s, a.name, a.choices)
+		}
+		s = choice
+	}
+	if a.patternRe != nil && !a.patternRe.MatchString(s) {
+		return fmt.Errorf("invalid value %s for argument %s: expected a value matching %s", s, a.name, a.pattern)
+	}
+	if a.bounds != nil {
+		if err := a.bounds.checkString(s); err != nil {
+			return fmt.Errorf("invalid value for argument %s: %v", a.name, err)
+		}
+	}
+	if a.validateElem != nil {
+		v, err := vconv(s, a.value.Elem().Type().Elem())
+		if err != nil {
+			return err
+		}
+		if err := a.checkElem(a.value.Elem().Len(), v.Interface()); err != nil {
+			return err
+		}
+	}
 	return vset(a.value, s)
 }
 
+// checkEnvStrict checks that the value of the strict bool argument read from an environment variable is either true or false
+func (a *arg) checkEnvStrict() error {
+	if !a.strict || a.source != SourceEnv {
+		return nil
+	}
+	if s := os.Getenv(a.sourceEnvVar); s != "true" && s != "false" {
+		return fmt.Errorf("invalid value %s for argument %s: expected true or false (from the environment variable %s)", s, a.name, a.sourceEnvVar)
+	}
+	return nil
+}
+
+// checkEnvChoice checks that the value of the argument read from an environment variable is one of its choices, if any
+func (a *arg) checkEnvChoice() error {
+	if len(a.choices) == 0 || a.source != SourceEnv {
+		return nil
+	}
+	s := a.value.Elem().String()
+	choice, found := matchChoice(s, a.choices, a.ignoreCase)
+	if !found {
+		return fmt.Errorf("invalid value %s for argument %s: expected one of %v (from the environment variable %s)", s, a.name, a.choices, a.sourceEnvVar)
+	}
+	a.value.Elem().SetString(choice)
+	return nil
+}
+
+// checkEnvPattern checks that the value of the argument read from an environment variable matches its pattern, if any
+func (a *arg) checkEnvPattern() error {
+	if a.patternRe == nil || a.source != SourceEnv {
+		return nil
+	}
+	if s := fmt.Sprint(a.value.Elem().Interface()); !a.patternRe.MatchString(s) {
+		return fmt.Errorf("invalid value %s for argument %s: expected a value matching %s (from the environment variable %s)", s, a.name, a.pattern, a.sourceEnvVar)
+	}
+	return nil
+}
+
+// checkElem checks the value v at index idx of the argument with its element validation function
+func (a *arg) checkElem(idx int, v interface{}) error {
+	if err := a.validateElem(v); err != nil {
+		return fmt.Errorf("invalid value %v at index %d of argument %s: %v", v, idx, a.name, err)
+	}
+	return nil
+}
+
+// checkEnvElems checks the values of the argument read from an environment variable with its element validation function, if any
+func (a *arg) checkEnvElems() error {
+	if a.validateElem == nil || a.source != SourceEnv {
+		return nil
+	}
+	values := a.value.Elem()
+	for i := 0; i < values.Len(); i++ {
+		if err := a.checkElem(i, values.Index(i).Interface()); err != nil {
+			return fmt.Errorf("%v (from the environment variable %s)", err, a.sourceEnvVar)
+		}
+	}
+	return nil
+}
+
+// mustCompilePattern compiles the Pattern of an option or an argument, panicking if it is invalid
+func mustCompilePattern(owner, pattern string) *regexp.Regexp {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		panic(fmt.Sprintf("%s has an invalid Pattern %q: %v", owner, pattern, err))
+	}
+	return re
+}
+
+func stringValidator(f func(string) error) func(interface{}) error {
+	if f == nil {
+		return nil
+	}
+	return func(v interface{}) error { return f(v.(string)) }
+}
+
+func intValidator(f func(int) error) func(interface{}) error {
+	if f == nil {
+		return nil
+	}
+	return func(v interface{}) error { return f(v.(int)) }
+}
+
 func (c *Cmd) mkArg(arg arg, defaultvalue interface{}) interface{} {
 	value := reflect.ValueOf(defaultvalue)
 	res := reflect.New(value.Type())
 
 	arg.helpFormatter = formatterFor(value.Type())
 
-	vinit(res, arg.envVar, defaultvalue)
+	if len(arg.pattern) > 0 {
+		arg.patternRe = mustCompilePattern(fmt.Sprintf("Argument %s", arg.name), arg.pattern)
+	}
 
 	arg.value = res
+	arg.defaultValue = defaultvalue
+	arg.reset()
 
 	c.args = append(c.args, &arg)
 	c.argsIdx[arg.name] = &arg
@@ -1,9 +1,11 @@
 package cli
 
 import (
+	"fmt"
 	"os"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -58,6 +60,62 @@ func TestBoolArg(t *testing.T) {
 	require.False(t, *b)
 }
 
+func TestStrictBoolArg(t *testing.T) {
+	var lax, strict *bool
+	init := func(c *Cmd) {
+		lax = c.Bool(BoolArg{Name: "LAX", Value: false})
+		strict = c.Bool(BoolArg{Name: "STRICT", Value: false, Strict: true})
+	}
+
+	okCmd(t, "LAX STRICT", init, []string{"1", "true"})
+	require.True(t, *lax)
+	require.True(t, *strict)
+
+	okCmd(t, "LAX STRICT", init, []string{"true", "false"})
+	require.True(t, *lax)
+	require.False(t, *strict)
+
+	failCmd(t, "LAX STRICT", init, []string{"true", "1"})
+	failCmd(t, "LAX STRICT", init, []string{"true", "TRUE"})
+	failCmd(t, "LAX STRICT", init, []string{"true", "f"})
+
+	cmd := &Cmd{name: "test", optionsIdx: map[string]*opt{}, argsIdx: map[string]*arg{}}
+	cmd.Spec = "LAX STRICT"
+	init(cmd)
+	require.Nil(t, cmd.doInit())
+	err := cmd.fsm.parse([]string{"0", "0"})
+	require.NotNil(t, err)
+	require.Equal(t, "invalid value 0 for argument STRICT: expected true or false", err.Error())
+
+	os.Setenv("STRICT_BOOL_ARG", "1")
+	defer os.Setenv("STRICT_BOOL_ARG", "")
+	okCmd(t, "[LAX]", func(c *Cmd) {
+		lax = c.Bool(BoolArg{Name: "LAX", Value: false, EnvVar: "STRICT_BOOL_ARG"})
+	}, []string{})
+	require.True(t, *lax)
+
+	envInit := func(c *Cmd) {
+		strict = c.Bool(BoolArg{Name: "STRICT", Value: false, EnvVar: "STRICT_BOOL_ARG", Strict: true})
+	}
+	failCmd(t, "[STRICT]", envInit, []string{})
+	okCmd(t, "[STRICT]", envInit, []string{"true"})
+	require.True(t, *strict)
+
+	os.Setenv("STRICT_BOOL_ARG", "true")
+	okCmd(t, "[STRICT]", envInit, []string{})
+	require.True(t, *strict)
+
+	os.Setenv("STRICT_BOOL_ARG", "t")
+	cmd = &Cmd{name: "test", optionsIdx: map[string]*opt{}, argsIdx: map[string]*arg{}}
+	cmd.Spec = "[STRICT]"
+	envInit(cmd)
+	require.Nil(t, cmd.doInit())
+	require.Nil(t, cmd.fsm.parse([]string{}))
+	err = cmd.resolve()
+	require.NotNil(t, err)
+	require.Equal(t, "invalid value t for argument STRICT: expected true or false (from the environment variable STRICT_BOOL_ARG)", err.Error())
+}
+
 func TestIntArg(t *testing.T) {
 	cmd := &Cmd{argsIdx: map[string]*arg{}}
 	a := cmd.Int(IntArg{Name: "a", Value: -1, Desc: ""})
@@ -88,6 +146,54 @@ func TestIntArg(t *testing.T) {
 	require.Equal(t, 42, *b)
 }
 
+func TestFloat64Arg(t *testing.T) {
+	cmd := &Cmd{argsIdx: map[string]*arg{}}
+	a := cmd.Float64(Float64Arg{Name: "a", Value: -1.5, Desc: ""})
+	require.Equal(t, -1.5, *a)
+
+	os.Setenv("B", "")
+	b := cmd.Float64(Float64Arg{Name: "b", Value: -1.5, EnvVar: "B", Desc: ""})
+	require.Equal(t, -1.5, *b)
+
+	goodValues := map[string]float64{"1": 1, "0": 0, "0.95": 0.95, "-2.5": -2.5, "1e3": 1000}
+	for env, tv := range goodValues {
+		os.Setenv("B", env)
+		b := cmd.Float64(Float64Arg{Name: "b", Value: -1.5, EnvVar: "B", Desc: ""})
+		require.Equal(t, tv, *b, "env=%s", env)
+	}
+
+	badValues := []string{"", "b", "q1", "0.9.5", "1,5"}
+	for _, tv := range badValues {
+		os.Setenv("B", tv)
+		b := cmd.Float64(Float64Arg{Name: "b", Value: -1.5, EnvVar: "B", Desc: ""})
+		require.Equal(t, -1.5, *b, "env=%s", tv)
+	}
+
+	os.Setenv("B", "")
+	os.Setenv("C", "0.42")
+	os.Setenv("D", "666")
+	b = cmd.Float64(Float64Arg{Name: "b", Value: -1.5, EnvVar: "B C D", Desc: ""})
+	require.Equal(t, 0.42, *b)
+}
+
+func TestDurationArg(t *testing.T) {
+	cmd := &Cmd{argsIdx: map[string]*arg{}}
+	a := cmd.Duration(DurationArg{Name: "a", Value: time.Minute, Desc: ""})
+	require.Equal(t, time.Minute, *a)
+
+	os.Setenv("B", "")
+	b := cmd.Duration(DurationArg{Name: "b", Value: time.Minute, EnvVar: "B", Desc: ""})
+	require.Equal(t, time.Minute, *b)
+
+	os.Setenv("B", "2w")
+	b = cmd.Duration(DurationArg{Name: "b", Value: time.Minute, EnvVar: "B", Desc: ""})
+	require.Equal(t, 14*24*time.Hour, *b)
+
+	os.Setenv("B", "forever")
+	b = cmd.Duration(DurationArg{Name: "b", Value: time.Minute, EnvVar: "B", Desc: ""})
+	require.Equal(t, time.Minute, *b)
+}
+
 func TestStringsArg(t *testing.T) {
 	cmd := &Cmd{argsIdx: map[string]*arg{}}
 	v := []string{"test"}
@@ -113,6 +219,50 @@ func TestStringsArg(t *testing.T) {
 	require.Equal(t, v, *b)
 }
 
+func TestDurationsArg(t *testing.T) {
+	cmd := &Cmd{argsIdx: map[string]*arg{}}
+	vd := []time.Duration{time.Second}
+	a := cmd.Durations(DurationsArg{Name: "a", Value: vd, Desc: ""})
+	require.Equal(t, vd, *a)
+
+	os.Setenv("B", "1s,2w")
+	b := cmd.Durations(DurationsArg{Name: "b", Value: nil, EnvVar: "B", Desc: ""})
+	require.Equal(t, []time.Duration{time.Second, 14 * 24 * time.Hour}, *b)
+
+	os.Setenv("B", "1s,later")
+	b = cmd.Durations(DurationsArg{Name: "b", Value: vd, EnvVar: "B", Desc: ""})
+	require.Equal(t, vd, *b)
+
+	var delays *[]time.Duration
+	init := func(c *Cmd) {
+		delays = c.DurationsArg("DELAY", nil, "")
+	}
+	okCmd(t, "DELAY...", init, []string{"1s", "1m30s", "2d"})
+	require.Equal(t, []time.Duration{time.Second, 90 * time.Second, 48 * time.Hour}, *delays)
+
+	failCmd(t, "DELAY...", init, []string{"1s", "never"})
+}
+
+func TestFloat64sArg(t *testing.T) {
+	cmd := &Cmd{argsIdx: map[string]*arg{}}
+	vf := []float64{0.5}
+	a := cmd.Float64s(Float64sArg{Name: "a", Value: vf, Desc: ""})
+	require.Equal(t, vf, *a)
+
+	os.Setenv("B", "0.1,2")
+	b := cmd.Float64s(Float64sArg{Name: "b", Value: nil, EnvVar: "B", Desc: ""})
+	require.Equal(t, []float64{0.1, 2}, *b)
+
+	var weights *[]float64
+	init := func(c *Cmd) {
+		weights = c.Float64sArg("WEIGHT", nil, "")
+	}
+	okCmd(t, "WEIGHT...", init, []string{"0.25", "0.75"})
+	require.Equal(t, []float64{0.25, 0.75}, *weights)
+
+	failCmd(t, "WEIGHT...", init, []string{"0.25", "x"})
+}
+
 func TestIntsArg(t *testing.T) {
 	cmd := &Cmd{argsIdx: map[string]*arg{}}
 	vi := []int{42}
@@ -139,3 +289,212 @@ func TestIntsArg(t *testing.T) {
 	b = cmd.Ints(IntsArg{Name: "b", Value: nil, EnvVar: "B C D E F", Desc: ""})
 	require.Equal(t, vi, *b)
 }
+
+func TestStringMapArg(t *testing.T) {
+	cmd := &Cmd{argsIdx: map[string]*arg{}}
+	v := map[string]string{"k": "v"}
+	a := cmd.StringMap(StringMapArg{Name: "a", Value: v, Desc: ""})
+	require.Equal(t, v, *a)
+
+	os.Setenv("B", "a=1, b=x=y")
+	b := cmd.StringMap(StringMapArg{Name: "b", Value: nil, EnvVar: "B", Desc: ""})
+	require.Equal(t, map[string]string{"a": "1", "b": "x=y"}, *b)
+
+	os.Setenv("B", "a=1,b")
+	b = cmd.StringMap(StringMapArg{Name: "b", Value: v, EnvVar: "B", Desc: ""})
+	require.Equal(t, v, *b)
+	os.Setenv("B", "")
+
+	var kv *map[string]string
+	init := func(c *Cmd) {
+		kv = c.StringMap(StringMapArg{Name: "KV", Value: v})
+	}
+
+	okCmd(t, "KV...", init, []string{"a=1", "b=2", "url=http://x?q=1"})
+	require.Equal(t, map[string]string{"k": "v", "a": "1", "b": "2", "url": "http://x?q=1"}, *kv)
+	require.Equal(t, map[string]string{"k": "v"}, v, "the initial value should not be modified")
+
+	okCmd(t, "KV...", init, []string{"a=", "a=2"})
+	require.Equal(t, map[string]string{"k": "v", "a": "2"}, *kv)
+
+	failCmd(t, "KV...", init, []string{"a=1", "b"})
+
+	cmd = &Cmd{name: "test", optionsIdx: map[string]*opt{}, argsIdx: map[string]*arg{}}
+	cmd.Spec = "KV..."
+	init(cmd)
+	require.Nil(t, cmd.doInit())
+	err := cmd.fsm.parse([]string{"a=1", "b"})
+	require.NotNil(t, err)
+	require.Equal(t, `expected a key=value pair, got "b"`, err.Error())
+}
+
+func TestStringMapArgDefaultSpec(t *testing.T) {
+	defer exitShouldNotCalled(t)()
+	defer suppressOutput()()
+
+	app := App("myapp", "")
+	app.Command("set", "", func(cmd *Cmd) {
+		name := cmd.StringArg("NAME", "", "")
+		values := cmd.StringMapArg("VALUES", nil, "")
+		cmd.Action = func() {
+			require.Equal(t, "cfg", *name)
+			require.Equal(t, map[string]string{"key": "value", "key2": "a=b"}, *values)
+		}
+	})
+
+	require.Nil(t, app.Run([]string{"myapp", "set", "cfg", "key=value", "key2=a=b"}))
+	require.Equal(t, "NAME VALUES... ", app.commands[0].Spec)
+}
+
+func TestArgCountBounds(t *testing.T) {
+	defer suppressOutput()()
+
+	cases := []struct {
+		spec     string
+		min, max int
+		usage    string
+	}{
+		{"SRC... DST", 0, 0, "Usage: cp SRC... DST"},
+		{"SRC... DST", 1, 0, "Usage: cp SRC... (1+) DST"},
+		{"SRC... DST", 1, 3, "Usage: cp SRC... (1-3) DST"},
+		{"[-f] [SRC...] DST", 0, 3, "Usage: cp [-f] [SRC... (0-3)] DST"},
+		{"(SRC DST)...", 1, 3, "Usage: cp (SRC DST)..."},
+	}
+
+	for _, cas := range cases {
+		app := App("cp", "")
+		app.Spec = cas.spec
+		app.BoolOpt("f", false, "")
+		app.Strings(StringsArg{Name: "SRC", Value: nil, MinCount: cas.min, MaxCount: cas.max})
+		app.Strings(StringsArg{Name: "DST", Value: nil})
+		require.Nil(t, app.doInit())
+		require.Equal(t, cas.usage, app.UsageLine())
+	}
+
+	var called bool
+	init := func(app *Cli) {
+		called = false
+		app.Spec = "[SRC...] DST"
+		app.Strings(StringsArg{Name: "SRC", Value: nil, MinCount: 1, MaxCount: 2})
+		app.String(StringArg{Name: "DST", Value: ""})
+		app.Action = func() {
+			called = true
+		}
+	}
+
+	require.Nil(t, runApp(init, "a", "dst"))
+	require.True(t, called)
+	require.Nil(t, runApp(init, "a", "b", "dst"))
+	require.True(t, called)
+
+	err := runApp(init, "dst")
+	require.NotNil(t, err)
+	require.Equal(t, "argument SRC requires at least 1 value(s), got 0", err.Error())
+	require.False(t, called)
+
+	err = runApp(init, "a", "b", "c", "dst")
+	require.NotNil(t, err)
+	require.Equal(t, "argument SRC accepts at most 2 value(s), got 3", err.Error())
+	require.False(t, called)
+}
+
+func TestArgValidateElem(t *testing.T) {
+	defer suppressOutput()()
+	os.Setenv("APP_PORTS", "80,70000,443")
+	defer os.Unsetenv("APP_PORTS")
+
+	var (
+		ports  *[]int
+		envVar string
+		called bool
+	)
+	init := func(app *Cli) {
+		called = false
+		app.Spec = "[PORT...]"
+		ports = app.Ints(IntsArg{Name: "PORT", EnvVar: envVar, ValidateElem: func(p int) error {
+			if p < 1 || p > 65535 {
+				return fmt.Errorf("should be between 1 and 65535")
+			}
+			return nil
+		}})
+		app.Action = func() {
+			called = true
+		}
+	}
+
+	require.Nil(t, runApp(init, "80", "443", "8080"))
+	require.True(t, called)
+	require.Equal(t, []int{80, 443, 8080}, *ports)
+
+	err := runApp(init, "80", "0", "8080")
+	require.NotNil(t, err)
+	require.Equal(t, "invalid value 0 at index 1 of argument PORT: should be between 1 and 65535", err.Error())
+	require.False(t, called)
+
+	err = runApp(init, "80", "x")
+	require.NotNil(t, err)
+	require.False(t, called)
+
+	envVar = "APP_PORTS"
+	err = runApp(init)
+	require.NotNil(t, err)
+	require.Equal(t, "invalid value 70000 at index 1 of argument PORT: should be between 1 and 65535 (from the environment variable APP_PORTS)", err.Error())
+	require.False(t, called)
+
+	cmd := &Cmd{argsIdx: map[string]*arg{}}
+	hosts := cmd.Strings(StringsArg{Name: "HOST", ValidateElem: func(h string) error {
+		if h == "localhost" {
+			return fmt.Errorf("should be a remote host")
+		}
+		return nil
+	}})
+	require.Nil(t, cmd.argsIdx["HOST"].set("example.com"))
+	err = cmd.argsIdx["HOST"].set("localhost")
+	require.NotNil(t, err)
+	require.Equal(t, "invalid value localhost at index 1 of argument HOST: should be a remote host", err.Error())
+	require.Equal(t, []string{"example.com"}, *hosts)
+}
+
+func TestChoicesArg(t *testing.T) {
+	defer suppressOutput()()
+
+	var (
+		level           *string
+		caseInsensitive bool
+	)
+	init := func(app *Cli) {
+		level = app.String(StringArg{Name: "LEVEL", Choices: []string{"debug", "info", "warn"}, CaseInsensitive: caseInsensitive})
+	}
+
+	require.Nil(t, runApp(init, "info"))
+	require.Equal(t, "info", *level)
+
+	err := runApp(init, "Info")
+	require.NotNil(t, err)
+	require.Equal(t, "invalid value Info for argument LEVEL: expected one of [debug info warn]", err.Error())
+
+	caseInsensitive = true
+	require.Nil(t, runApp(init, "Info"))
+	require.Equal(t, "info", *level, "the matching choice should be stored")
+}
+
+func TestPatternArg(t *testing.T) {
+	defer suppressOutput()()
+
+	var id *string
+	init := func(app *Cli) {
+		id = app.String(StringArg{Name: "ID", Pattern: "^[a-z0-9-]+$"})
+	}
+
+	require.Nil(t, runApp(init, "web-01"))
+	require.Equal(t, "web-01", *id)
+
+	err := runApp(init, "web.01")
+	require.NotNil(t, err)
+	require.Equal(t, "invalid value web.01 for argument ID: expected a value matching ^[a-z0-9-]+$", err.Error())
+
+	require.Panics(t, func() {
+		app := App("app", "")
+		app.String(StringArg{Name: "ID", Pattern: "(a"})
+	})
+}
@@ -0,0 +1,46 @@
+package cli
+
+import "unicode"
+
+/*
+AutoShort turns on or off the automatic assignment of a short name to the command's options declared with long names only,
+e.g. `-o` for `--output`. The commands declared afterwards inherit this setting.
+
+Each option gets the first letter of its longest name which is not already used by another option, in their declaration order,
+e.g. `-o` for `--output` and then `-u` for `--out-dir`.
+An option whose letters are all used gets no short name. The letter `h` is never assigned, being used to request the help message.
+*/
+func (c *Cmd) AutoShort(enabled bool) {
+	c.autoShort = enabled
+}
+
+// assignShortNames adds a short name to the options which have none, see AutoShort
+func (c *Cmd) assignShortNames() {
+	for _, opt := range c.options {
+		if opt.hasShortName() {
+			continue
+		}
+		for _, r := range opt.displayName() {
+			if !unicode.IsLetter(r) || r > unicode.MaxASCII || r == 'h' {
+				continue
+			}
+			name := "-" + string(r)
+			if _, used := c.optionsIdx[name]; used {
+				continue
+			}
+			opt.names = append([]string{name}, opt.names...)
+			c.optionsIdx[name] = opt
+			break
+		}
+	}
+}
+
+// hasShortName returns true if one of the option names is a single letter one, e.g. `-v`
+func (o *opt) hasShortName() bool {
+	for _, name := range o.names {
+		if len(name) == 2 {
+			return true
+		}
+	}
+	return false
+}
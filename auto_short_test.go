@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoShort(t *testing.T) {
+	var stdErr string
+	defer captureAndRestoreOutput(nil, &stdErr)()
+
+	var (
+		output, outDir, host, to *string
+		verbose, force           *bool
+	)
+	init := func(app *Cli) {
+		app.AutoShort(true)
+		output = app.StringOpt("output", "", "Output file")
+		outDir = app.StringOpt("out-dir", "", "Output dir")
+		verbose = app.BoolOpt("V verbose", false, "Verbose")
+		host = app.StringOpt("host", "", "Host")
+		to = app.StringOpt("to", "", "Destination")
+		force = app.BoolOpt("f", false, "Force")
+		app.BoolOpt("ouo", false, "No letter left")
+	}
+
+	require.Nil(t, runApp(init, "-o", "a", "-u", "b", "-s", "c", "-t", "d", "-fV"))
+	require.Equal(t, "a", *output)
+	require.Equal(t, "b", *outDir)
+	require.Equal(t, "c", *host, "the letter h should be kept for the help")
+	require.Equal(t, "d", *to)
+	require.True(t, *verbose)
+	require.True(t, *force)
+
+	require.Nil(t, runApp(init, "--output", "a", "--out-dir", "b"), "the long names should still be accepted")
+	require.Equal(t, "a", *output)
+	require.Equal(t, "b", *outDir)
+
+	func() {
+		exitCalled := false
+		defer exitShouldBeCalledWith(t, 2, &exitCalled)()
+		runApp(init, "-h")
+	}()
+	require.Equal(t, `
+Usage: app [OPTIONS]
+
+
+Options:
+  -o, --output=""            Output file
+  -u, --out-dir=""           Output dir
+  -V, --[no-]verbose=false   Verbose
+  -s, --host=""              Host
+  -t, --to=""                Destination
+  -f=false                   Force
+  --[no-]ouo=false           No letter left
+`, stdErr)
+
+	app := App("app", "")
+	app.StringOpt("output", "", "")
+	app.Command("sub", "", func(cmd *Cmd) {
+		cmd.StringOpt("output", "", "")
+	})
+	app.AutoShort(true)
+	app.Command("auto", "", func(cmd *Cmd) {
+		cmd.StringOpt("output", "", "")
+	})
+	require.Nil(t, app.doInitAll())
+	require.Equal(t, []string{"-o", "--output"}, app.optionsIdx["--output"].names)
+	require.Equal(t, []string{"--output"}, app.commands[0].optionsIdx["--output"].names, "no short names should be assigned unless enabled")
+	require.Equal(t, []string{"-o", "--output"}, app.commands[1].optionsIdx["--output"].names, "the commands should inherit the setting")
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// intBounds holds the optional minimum and maximum values of an int option or argument
+type intBounds struct {
+	min, max *int
+}
+
+// newIntBounds returns the bounds of an int option or argument, or nil if it has none. It panics if min is greater than max
+func newIntBounds(owner string, min, max *int) *intBounds {
+	if min == nil && max == nil {
+		return nil
+	}
+	if min != nil && max != nil && *min > *max {
+		panic(fmt.Sprintf("%s has a Min %d greater than its Max %d", owner, *min, *max))
+	}
+	return &intBounds{min: min, max: max}
+}
+
+// check checks that v is within the bounds
+func (b *intBounds) check(v int) error {
+	if b.min != nil && v < *b.min {
+		return fmt.Errorf("value %d is below minimum %d", v, *b.min)
+	}
+	if b.max != nil && v > *b.max {
+		return fmt.Errorf("value %d exceeds maximum %d", v, *b.max)
+	}
+	return nil
+}
+
+// checkString checks that s is within the bounds, leaving the values which are not ints to be reported by their conversion
+func (b *intBounds) checkString(s string) error {
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return nil
+	}
+	return b.check(v)
+}
+
+// describe appends the valid range to the description of an option or an argument
+func (b *intBounds) describe(desc string) string {
+	if b == nil {
+		return desc
+	}
+	switch {
+	case b.max == nil:
+		desc = fmt.Sprintf("%s (min %d)", desc, *b.min)
+	case b.min == nil:
+		desc = fmt.Sprintf("%s (max %d)", desc, *b.max)
+	default:
+		desc = fmt.Sprintf("%s (%d..%d)", desc, *b.min, *b.max)
+	}
+	return strings.TrimSpace(desc)
+}
+
+// checkEnvBounds checks that the value of the option read from an environment variable is within its bounds, if any
+func (o *opt) checkEnvBounds() error {
+	if o.bounds == nil || o.source != SourceEnv {
+		return nil
+	}
+	if err := o.bounds.check(int(o.value.Elem().Int())); err != nil {
+		return fmt.Errorf("invalid value for option %s: %v (from the environment variable %s)", o.displayName(), err, o.sourceEnvVar)
+	}
+	return nil
+}
+
+// checkEnvBounds checks that the value of the argument read from an environment variable is within its bounds, if any
+func (a *arg) checkEnvBounds() error {
+	if a.bounds == nil || a.source != SourceEnv {
+		return nil
+	}
+	if err := a.bounds.check(int(a.value.Elem().Int())); err != nil {
+		return fmt.Errorf("invalid value for argument %s: %v (from the environment variable %s)", a.name, err, a.sourceEnvVar)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntBoundsOpt(t *testing.T) {
+	defer suppressOutput()()
+	defer os.Setenv("APP_WORKERS", "")
+
+	min, max := 1, 64
+	var workers *int
+	init := func(app *Cli) {
+		workers = app.Int(IntOpt{Name: "w workers", Value: 4, EnvVar: "APP_WORKERS", Min: &min, Max: &max})
+	}
+
+	require.Nil(t, runApp(init, "-w", "64"))
+	require.Equal(t, 64, *workers)
+
+	err := runApp(init, "--workers", "128")
+	require.NotNil(t, err)
+	require.Equal(t, "invalid value for option --workers: value 128 exceeds maximum 64", err.Error())
+
+	err = runApp(init, "--workers", "0")
+	require.NotNil(t, err)
+	require.Equal(t, "invalid value for option --workers: value 0 is below minimum 1", err.Error())
+
+	os.Setenv("APP_WORKERS", "100")
+	err = runApp(init)
+	require.NotNil(t, err)
+	require.Equal(t, "invalid value for option --workers: value 100 exceeds maximum 64 (from the environment variable APP_WORKERS)", err.Error())
+
+	require.Nil(t, runApp(init, "-w", "8"), "the call arguments should override the environment variable")
+	require.Equal(t, 8, *workers)
+
+	require.Panics(t, func() {
+		app := App("app", "")
+		app.Int(IntOpt{Name: "w", Min: &max, Max: &min})
+	})
+}
+
+func TestIntBoundsArg(t *testing.T) {
+	defer suppressOutput()()
+
+	min := 1
+	var count *int
+	init := func(app *Cli) {
+		count = app.Int(IntArg{Name: "COUNT", Value: 1, Min: &min})
+	}
+
+	require.Nil(t, runApp(init, "3"))
+	require.Equal(t, 3, *count)
+
+	err := runApp(init, "0")
+	require.NotNil(t, err)
+	require.Equal(t, "invalid value for argument COUNT: value 0 is below minimum 1", err.Error())
+}
+
+func TestIntBoundsHelp(t *testing.T) {
+	min, max := 1, 64
+	b := newIntBounds("", &min, &max)
+	require.Equal(t, "Workers (1..64)", b.describe("Workers"))
+	require.Equal(t, "Workers (min 1)", newIntBounds("", &min, nil).describe("Workers"))
+	require.Equal(t, "Workers (max 64)", newIntBounds("", nil, &max).describe("Workers"))
+	require.Equal(t, "(max 64)", newIntBounds("", nil, &max).describe(""))
+
+	var none *intBounds
+	require.Equal(t, "Workers", none.describe("Workers"))
+}
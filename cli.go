@@ -1,10 +1,14 @@
 package cli
 
 import (
+	"bufio"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"strings"
 )
 
 /*
@@ -12,7 +16,13 @@ Cli represents the structure of a CLI app. It should be constructed using the Ap
 */
 type Cli struct {
 	*Cmd
-	version *cliVersion
+	// If true, the `help` and `version` commands are not added to the apps having commands
+	NoBuiltinCommands bool
+
+	version       *cliVersion
+	exitCode      func(error) int
+	builtinsAdded bool
+	quiet         bool
 }
 
 type cliVersion struct {
@@ -59,6 +69,7 @@ func (cli *Cli) Version(name, version string) {
 	})
 	names := mkOptStrs(name)
 	option := cli.optionsIdx[names[0]]
+	cli.removeNegatedNames(option)
 	cli.version = &cliVersion{version, option}
 }
 
@@ -70,11 +81,18 @@ func (cli *Cli) parse(args []string, entry, inFlow, outFlow *step) error {
 		exiter(0)
 		return nil
 	}
+	if cli.helpAllRequested(args) {
+		if err := cli.PrintHelpAll(); err != nil {
+			return err
+		}
+		cli.onError(nil)
+		return nil
+	}
 	return cli.Cmd.parse(args, entry, inFlow, outFlow)
 }
 
 func (cli *Cli) versionSetAndRequested(args []string) bool {
-	return cli.version != nil && cli.isArgSet(args, cli.version.option.names)
+	return cli.version != nil && cli.isArgSet(args, cli.callNames(cli.version.option.names))
 }
 
 /*
@@ -97,11 +115,222 @@ func (cli *Cli) Run(args []string) error {
 	if err := cli.doInit(); err != nil {
 		panic(err)
 	}
+	cli.addBuiltinCommands()
+	if cli.quiet {
+		defer discardOutput()()
+	}
 	inFlow := &step{desc: "RootIn"}
-	outFlow := &step{desc: "RootOut"}
+	outFlow := &step{desc: "RootOut", exitCode: cli.exitCode}
 	return cli.parse(args[1:], inFlow, inFlow, outFlow)
 }
 
+/*
+Resolve returns the path of the command which would be selected if the app was run with the passed arguments, e.g. `[]string{"remote", "add"}`,
+without parsing the options and arguments or running any action.
+The first argument should be the app name, e.g. os.Args.
+An empty path is returned when the app itself would be selected.
+
+An error is returned if an argument looks like a command name but does not match any of the available commands
+*/
+func (cli *Cli) Resolve(args []string) ([]string, error) {
+	if err := cli.doInit(); err != nil {
+		return nil, err
+	}
+	cli.addBuiltinCommands()
+
+	res := []string{}
+	c := cli.Cmd
+	args = args[1:]
+	for {
+		if err := c.doInit(); err != nil {
+			return nil, err
+		}
+
+		nargsLen := c.getOptsAndArgs(args)
+		if nargsLen == len(args) {
+			if len(c.commands) > 0 && len(c.args) == 0 {
+				if arg, found := c.firstNonOption(args); found {
+					return nil, fmt.Errorf("unknown command %s", arg)
+				}
+			}
+			return res, nil
+		}
+
+		name := args[nargsLen]
+		for _, sub := range c.commands {
+			if sub.name == name {
+				c = sub
+				break
+			}
+		}
+		res = append(res, name)
+		args = args[nargsLen+1:]
+	}
+}
+
+/*
+addBuiltinCommands adds the `help [COMMAND...]`, `__complete [WORDS...]` and `version` commands to the apps having commands, unless NoBuiltinCommands is set.
+They are not listed in the help message, and a command declared with the same name takes precedence
+*/
+func (cli *Cli) addBuiltinCommands() {
+	if cli.NoBuiltinCommands || cli.builtinsAdded || len(cli.commands) == 0 {
+		return
+	}
+	cli.builtinsAdded = true
+
+	if !cli.hasCommand("help") {
+		cli.Command("help", "Show the help of a command", func(cmd *Cmd) {
+			cmd.Spec = "[COMMAND...]"
+			path := cmd.StringsArg("COMMAND", nil, "The command path, e.g. `remote add`")
+			cmd.Action = func() {
+				cli.printCommandHelp(*path)
+			}
+		})
+		cli.commands[len(cli.commands)-1].hidden = true
+	}
+
+	if !cli.hasCommand("__complete") {
+		cli.addCompleteCommand()
+	}
+
+	if cli.version != nil && !cli.hasCommand("version") {
+		cli.Command("version", "Show the version", ActionCommand(cli.PrintVersion))
+		cli.commands[len(cli.commands)-1].hidden = true
+	}
+}
+
+func (cli *Cli) hasCommand(name string) bool {
+	for _, c := range cli.commands {
+		if c.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// printCommandHelp prints the long help message of the command with the given path
+func (cli *Cli) printCommandHelp(path []string) {
+	c := cli.Cmd
+	for _, name := range path {
+		var sub *Cmd
+		for _, s := range c.commands {
+			if s.name == name {
+				sub = s
+				break
+			}
+		}
+		if sub == nil {
+			fmt.Fprintf(stdErr, "Error: unknown command %s\n", name)
+			c.PrintHelp()
+			Exit(2)
+		}
+		if err := sub.doInit(); err != nil {
+			panic(err)
+		}
+		c = sub
+	}
+	c.PrintLongHelp()
+}
+
+/*
+ParseKnown parses the app options and arguments from the longest prefix of args it accepts, and returns the remaining arguments unparsed,
+e.g. to delegate them to another tool:
+
+	app := cli.App("wrapper", "")
+	verbose := app.BoolOpt("v verbose", false, "")
+
+	rest, err := app.ParseKnown([]string{"wrapper", "-v", "--color", "auto", "file"})
+	// *verbose is true and rest is []string{"--color", "auto", "file"}
+
+The first argument should be the app name, e.g. os.Args.
+Unlike Run, no action is run and the commands are not considered: a command name is simply returned with the remaining arguments.
+Invalid values are reported by returning an error, regardless of the ErrorHandling policy
+*/
+func (cli *Cli) ParseKnown(args []string) ([]string, error) {
+	if err := cli.doInit(); err != nil {
+		return nil, err
+	}
+	rest, err := cli.fsm.parseKnown(args[1:])
+	if err != nil {
+		return nil, err
+	}
+	if err := cli.resolve(); err != nil {
+		return nil, err
+	}
+	return rest, nil
+}
+
+/*
+RunString is similar to Run, except that it parses a single command line, e.g. read from a script or typed in an interactive session.
+
+The line should not start with the app name. It is split into arguments following the shell rules: words are separated by blanks,
+and single quotes, double quotes or backslashes can be used to include blanks in an argument:
+
+	app.RunString(`greet --name "John Doe"`)
+
+Incorrect usages are always reported by returning an error, as if the ErrorHandling policy of the app and all of its commands was set to flag.ContinueOnError.
+The options and arguments are restored to their initial values before the line is parsed, so the values set by a line do not leak into the next one
+*/
+func (cli *Cli) RunString(line string) error {
+	args, err := shellTokenize(line)
+	if err != nil {
+		fmt.Fprintf(stdErr, "Error: %s\n", err.Error())
+		return err
+	}
+	// the builtin commands are added before overriding the error handling, for it to be restored on them too
+	cli.addBuiltinCommands()
+	if err := cli.doInitAll(); err != nil {
+		panic(err)
+	}
+	defer cli.overrideErrorHandling(flag.ContinueOnError)()
+
+	cli.reset()
+	return cli.Run(append([]string{cli.name}, args...))
+}
+
+/*
+Reset restores the options and arguments of the app and of its commands to their initial values, re-reading the environment variables,
+as they were before any call arguments were parsed.
+
+It is meant to be called between the runs of an app which is run several times, e.g. in a test loop or a long-lived service,
+so that the values set by a run do not leak into the next one:
+
+	app.Run([]string{"app", "--verbose", "build"})
+	app.Reset()
+	app.Run([]string{"app", "build"})
+*/
+func (cli *Cli) Reset() {
+	cli.reset()
+}
+
+/*
+Repl runs the app in interactive mode: it repeatedly prompts for a command line on the standard input
+and runs it against the app commands (using RunString), until the input ends (e.g. Ctrl-D) or the user types quit or exit.
+
+Typing help prints the app help message.
+Incorrect usages are reported without ending the interactive session.
+*/
+func (cli *Cli) Repl() error {
+	scanner := bufio.NewScanner(stdIn)
+	for {
+		fmt.Fprintf(stdOut, "%s> ", cli.name)
+		if !scanner.Scan() {
+			fmt.Fprintln(stdOut)
+			return scanner.Err()
+		}
+
+		switch line := strings.TrimSpace(scanner.Text()); line {
+		case "":
+		case "quit", "exit":
+			return nil
+		case "help":
+			cli.PrintHelp()
+		default:
+			cli.RunString(line)
+		}
+	}
+}
+
 /*
 ActionCommand(func() { myFun() }) is syntactic sugar for
 func(cmd *cli.Cmd) { cmd.Action = func() { myFun() }
@@ -125,11 +354,86 @@ func Exit(code int) {
 
 type exit int
 
+/*
+OnWarning sets the function to be called with the warnings emitted while running the app, e.g. when a deprecated command is run,
+instead of printing them to stderr. This can be used to route the warnings to a logger:
+
+	app.OnWarning(func(msg string) {
+		log.Printf("warning: %s", msg)
+	})
+
+*/
+func (cli *Cli) OnWarning(f func(msg string)) {
+	cli.onWarning = f
+}
+
+/*
+Fail causes the app to print the error and exit while giving the After interceptors a chance to run.
+The exit code is 1, unless a different one was configured for this error using ExitCodeFor.
+This should be used in actions which need to report an error.
+A nil err is reported as a generic `failed` error.
+*/
+func Fail(err error) {
+	if err == nil {
+		err = errFailed
+	}
+	panic(failure{err})
+}
+
+var errFailed = errors.New("failed")
+
+type failure struct {
+	err error
+}
+
+/*
+ExitCodeFor configures the exit code used when an action reports an error using Fail.
+The function f gets called with the reported error, and the app exits with the returned code.
+If f returns 0, e.g. for an unknown error, the app exits with 1:
+
+	app.ExitCodeFor(func(err error) int {
+		switch err.(type) {
+		case NotFoundError:
+			return 4
+		case AuthError:
+			return 77
+		}
+		return 0
+	})
+
+*/
+func (cli *Cli) ExitCodeFor(f func(error) int) {
+	cli.exitCode = f
+}
+
+/*
+Quiet turns on or off the quiet mode, in which the app runs without printing any of the library's messages,
+i.e. the help messages, the usage errors and the warnings (unless handled with OnWarning), e.g. when run from a script:
+
+	app.Quiet(true)
+
+The incorrect usages are still reported by the exit code, or by the error returned by Run, depending on the ErrorHandling policy.
+The output of the commands actions is left untouched
+*/
+func (cli *Cli) Quiet(quiet bool) {
+	cli.quiet = quiet
+}
+
+// discardOutput discards the library's messages, and returns a function which restores their previous destination
+func discardOutput() func() {
+	old := stdErr
+	stdErr = ioutil.Discard
+	return func() {
+		stdErr = old
+	}
+}
+
 var exiter = func(code int) {
 	os.Exit(code)
 }
 
 var (
+	stdIn  io.Reader = os.Stdin
 	stdOut io.Writer = os.Stdout
 	stdErr io.Writer = os.Stderr
 )
@@ -1,11 +1,18 @@
 package cli
 
 import (
+	"errors"
 	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/stretchr/testify/require"
 
 	"testing"
+	"time"
 )
 
 func TestTheCpCase(t *testing.T) {
@@ -89,7 +96,7 @@ Usage: app [-o] ARG
 App Desc
 
 Arguments:
-  ARG=""       Argument
+  ARG="" (string)   Argument
 
 Options:
   -o, --opt=""   Option
@@ -98,6 +105,43 @@ Options:
 	require.Equal(t, help, err)
 }
 
+func TestHelpMessageArgsTypes(t *testing.T) {
+	var out, err string
+	defer captureAndRestoreOutput(&out, &err)()
+
+	exitCalled := false
+	defer exitShouldBeCalledWith(t, 2, &exitCalled)()
+
+	app := App("app", "App Desc")
+	app.Spec = "NAME COUNT FORCE TTL IDS FILES..."
+
+	app.String(StringArg{Name: "NAME", Value: "x", Desc: "Name"})
+	app.Int(IntArg{Name: "COUNT", Value: 3, Desc: "Count"})
+	app.Bool(BoolArg{Name: "FORCE", Value: false, Desc: "Force"})
+	app.Duration(DurationArg{Name: "TTL", Value: time.Minute, Desc: "TTL"})
+	app.Ints(IntsArg{Name: "IDS", Value: nil, Desc: "Ids"})
+	app.Strings(StringsArg{Name: "FILES", Value: nil, Desc: "Files", HideValue: true})
+
+	app.Action = func() {}
+	app.Run([]string{"app", "-h"})
+
+	help := `
+Usage: app NAME COUNT FORCE TTL IDS FILES...
+
+App Desc
+
+Arguments:
+  NAME="x" (string)     Name
+  COUNT=3 (int)         Count
+  FORCE=false (bool)    Force
+  TTL=1m0s (duration)   TTL
+  IDS=[] (int...)       Ids
+  FILES (string...)     Files
+`
+
+	require.Equal(t, help, err)
+}
+
 func TestLongHelpMessage(t *testing.T) {
 	var out, err string
 	defer captureAndRestoreOutput(&out, &err)()
@@ -121,7 +165,7 @@ Usage: app [-o] ARG
 Longer App Desc
 
 Arguments:
-  ARG=""       Argument
+  ARG="" (string)   Argument
 
 Options:
   -o, --opt=""   Option
@@ -225,3 +269,1113 @@ func TestPanicOnError(t *testing.T) {
 	app.Run([]string{"say"})
 	t.Fatalf("wanted panic")
 }
+
+func TestRunString(t *testing.T) {
+	defer exitShouldNotCalled(t)()
+	defer suppressOutput()()
+
+	app := App("greet", "")
+	var (
+		name  *string
+		times *int
+	)
+	app.Command("hi", "", func(cmd *Cmd) {
+		name = cmd.StringOpt("n name", "", "")
+		times = cmd.IntArg("TIMES", 0, "")
+		cmd.Spec = "-n TIMES"
+		cmd.Action = func() {}
+	})
+
+	err := app.RunString(`hi --name "John Doe" 2`)
+	require.Nil(t, err)
+	require.Equal(t, "John Doe", *name)
+	require.Equal(t, 2, *times)
+
+	err = app.RunString(`hi -n 'Jane O"Doe' 3`)
+	require.Nil(t, err)
+	require.Equal(t, `Jane O"Doe`, *name)
+	require.Equal(t, 3, *times)
+
+	err = app.RunString(`hi -n Jane\ Doe 4`)
+	require.Nil(t, err)
+	require.Equal(t, "Jane Doe", *name)
+	require.Equal(t, 4, *times)
+
+	require.NotNil(t, app.RunString(`hi "John Doe" 2`), "missing option should be reported")
+	require.NotNil(t, app.RunString(`hi -n x y`), "invalid int should be reported")
+	require.NotNil(t, app.RunString(`bye`), "unknown command should be reported")
+	require.NotNil(t, app.RunString(`hi -n "John 2`), "unclosed quote should be reported")
+
+	require.Equal(t, flag.ExitOnError, app.ErrorHandling, "the error handling policy should be restored")
+	require.Equal(t, flag.ExitOnError, app.commands[0].ErrorHandling, "the error handling policy should be restored")
+}
+
+func TestRunStringRestoresBuiltinsErrorHandling(t *testing.T) {
+	defer suppressOutput()()
+
+	app := App("greet", "")
+	app.Command("hi", "", ActionCommand(func() {}))
+
+	require.Nil(t, app.RunString("hi"))
+	require.True(t, len(app.commands) > 1, "the builtin commands should have been added")
+	for _, c := range app.commands {
+		require.Equal(t, flag.ExitOnError, c.ErrorHandling, "command %s", c.name)
+	}
+}
+
+func TestRepl(t *testing.T) {
+	defer exitShouldNotCalled(t)()
+	var out, errOut string
+	defer captureAndRestoreOutput(&out, &errOut)()
+	defer provideInput(strings.NewReader("hi\n\nhi -x\nbye John\nhelp\nbye 'Jane Doe'\nquit\nhi\n"))()
+
+	app := App("say", "")
+	hi := 0
+	byes := []string{}
+	app.Command("hi", "", func(cmd *Cmd) {
+		cmd.Action = func() {
+			hi++
+		}
+	})
+	app.Command("bye", "", func(cmd *Cmd) {
+		name := cmd.StringArg("NAME", "", "")
+		cmd.Action = func() {
+			byes = append(byes, *name)
+		}
+	})
+
+	err := app.Repl()
+	require.Nil(t, err)
+	require.Equal(t, 1, hi, "hi should have been called once, before quit")
+	require.Equal(t, []string{"John", "Jane Doe"}, byes)
+	require.True(t, strings.Contains(errOut, "Error: incorrect usage"), "the error should have been reported")
+	require.True(t, strings.Contains(errOut, "Usage: say COMMAND [arg...]"), "the help should have been printed")
+}
+
+func TestReplResetsValues(t *testing.T) {
+	defer exitShouldNotCalled(t)()
+	defer suppressOutput()()
+	defer provideInput(strings.NewReader("hi --loud -t a\nhi -t b\nhi\n"))()
+
+	app := App("say", "")
+	calls := []string{}
+	app.Command("hi", "", func(cmd *Cmd) {
+		loud := cmd.BoolOpt("loud", false, "")
+		tags := cmd.StringsOpt("t tag", nil, "")
+		cmd.Action = func() {
+			calls = append(calls, fmt.Sprintf("%v %v", *loud, *tags))
+		}
+	})
+
+	require.Nil(t, app.Repl())
+	require.Equal(t, []string{"true [a]", "false [b]", "false []"}, calls, "the values of a line should not carry over to the next one")
+}
+
+func TestReplEndOfInput(t *testing.T) {
+	defer exitShouldNotCalled(t)()
+	defer suppressOutput()()
+	defer provideInput(strings.NewReader("hi\nhi"))()
+
+	app := App("say", "")
+	hi := 0
+	app.Command("hi", "", func(cmd *Cmd) {
+		cmd.Action = func() {
+			hi++
+		}
+	})
+
+	err := app.Repl()
+	require.Nil(t, err)
+	require.Equal(t, 2, hi)
+}
+
+func TestSingleDashLongOpts(t *testing.T) {
+	defer exitShouldNotCalled(t)()
+	defer suppressOutput()()
+
+	var (
+		verbose, e, r *bool
+		config        *string
+		called        bool
+		singleDash    = true
+	)
+	init := func(app *Cli) {
+		called = false
+		app.SingleDashLongOpts = singleDash
+		verbose = app.BoolOpt("v verbose", false, "")
+		e = app.BoolOpt("e", false, "")
+		r = app.BoolOpt("r", false, "")
+		app.Command("run", "", func(cmd *Cmd) {
+			config = cmd.StringOpt("config", "", "")
+			cmd.Action = func() {
+				called = true
+			}
+		})
+	}
+
+	require.Nil(t, runApp(init, "-verbose", "run", "-config", "x.json"))
+	require.True(t, called)
+	require.True(t, *verbose)
+	require.False(t, *e)
+	require.Equal(t, "x.json", *config)
+
+	require.Nil(t, runApp(init, "--verbose", "run", "-config=y.json"))
+	require.True(t, *verbose)
+	require.Equal(t, "y.json", *config)
+
+	require.Nil(t, runApp(init, "-ver", "run"))
+	require.True(t, *verbose)
+	require.True(t, *e)
+	require.True(t, *r)
+
+	singleDash = false
+	require.NotNil(t, runApp(init, "-verbose", "run"))
+	require.False(t, called)
+
+	require.NotNil(t, runApp(init, "run", "-config", "x.json"))
+	require.False(t, called)
+}
+
+func TestSingleDashLongOptsHelp(t *testing.T) {
+	defer suppressOutput()()
+
+	exitCalled := false
+	defer exitShouldBeCalledWith(t, 2, &exitCalled)()
+
+	app := App("app", "")
+	app.SingleDashLongOpts = true
+	app.Action = func() {
+		t.Errorf("action should not have been called")
+	}
+	app.Run([]string{"app", "-help"})
+	require.True(t, exitCalled, "exit should have been called")
+}
+
+func TestCmdDir(t *testing.T) {
+	defer suppressOutput()()
+
+	tmp, err := ioutil.TempDir("", "mowcli")
+	require.Nil(t, err)
+	defer os.RemoveAll(tmp)
+	tmp, err = filepath.EvalSymlinks(tmp)
+	require.Nil(t, err)
+
+	wd, err := os.Getwd()
+	require.Nil(t, err)
+
+	var (
+		actionWd, afterWd string
+		action            func()
+	)
+	init := func(app *Cli) {
+		app.Command("build", "", func(cmd *Cmd) {
+			dir := cmd.StringOpt("C", "", "")
+			cmd.Dir(func() string { return *dir })
+			cmd.Action = func() {
+				actionWd, _ = os.Getwd()
+				action()
+			}
+			cmd.After = func() {
+				afterWd, _ = os.Getwd()
+			}
+		})
+	}
+
+	action = func() {}
+	runApp(init, "build", "-C", tmp)
+	require.Equal(t, tmp, actionWd, "the action should run in the configured dir")
+	require.Equal(t, wd, afterWd, "the working dir should be restored after the action")
+
+	runApp(init, "build")
+	require.Equal(t, wd, actionWd, "the working dir should be untouched for an empty dir")
+
+	exitCalled := false
+	defer exitShouldBeCalledWith(t, 3, &exitCalled)()
+	action = func() { Exit(3) }
+	runApp(init, "build", "-C", tmp)
+	require.True(t, exitCalled, "exit should have been called")
+	require.Equal(t, tmp, actionWd)
+	require.Equal(t, wd, afterWd, "the working dir should be restored when the action exits")
+
+	action = func() { panic("boom") }
+	func() {
+		defer func() {
+			require.Equal(t, "boom", recover())
+		}()
+		runApp(init, "build", "-C", tmp)
+	}()
+	cwd, _ := os.Getwd()
+	require.Equal(t, wd, cwd, "the working dir should be restored when the action panics")
+}
+
+func TestCmdDirError(t *testing.T) {
+	defer suppressOutput()()
+
+	exitCalled := false
+	defer exitShouldBeCalledWith(t, 1, &exitCalled)()
+
+	app := App("app", "")
+	app.Dir(func() string { return "/this/dir/does/not/exist" })
+	app.Action = func() {}
+	app.Run([]string{"app"})
+	require.True(t, exitCalled, "exit should have been called")
+}
+
+func TestFailExitCode(t *testing.T) {
+	var stdErr string
+	defer captureAndRestoreOutput(nil, &stdErr)()
+
+	var (
+		errNotFound = errors.New("not found")
+		errAuth     = errors.New("unauthorized")
+		errOther    = errors.New("other")
+	)
+
+	var (
+		failWith    error
+		afterCalled bool
+	)
+	init := func(app *Cli) {
+		app.ExitCodeFor(func(err error) int {
+			switch err {
+			case errNotFound:
+				return 4
+			case errAuth:
+				return 77
+			}
+			return 0
+		})
+		app.Action = func() {
+			Fail(failWith)
+		}
+		app.After = func() {
+			afterCalled = true
+		}
+	}
+
+	cases := []struct {
+		err  error
+		code int
+	}{
+		{errNotFound, 4},
+		{errAuth, 77},
+		{errOther, 1},
+	}
+
+	for _, cas := range cases {
+		exitCalled := false
+		failWith, afterCalled = cas.err, false
+		restore := exitShouldBeCalledWith(t, cas.code, &exitCalled)
+		runApp(init)
+		restore()
+
+		require.True(t, exitCalled, "exit should have been called for %v", cas.err)
+		require.True(t, afterCalled, "after should have been called for %v", cas.err)
+		require.True(t, strings.HasSuffix(stdErr, fmt.Sprintf("Error: %s\n", cas.err)), "unexpected output %q", stdErr)
+	}
+
+	exitCalled := false
+	defer exitShouldBeCalledWith(t, 1, &exitCalled)()
+	app := App("app", "")
+	app.Action = func() {
+		Fail(errNotFound)
+	}
+	app.Run([]string{"app"})
+	require.True(t, exitCalled, "exit should have been called")
+}
+
+func TestFailNil(t *testing.T) {
+	var stdErr string
+	defer captureAndRestoreOutput(nil, &stdErr)()
+
+	exitCalled, afterCalled := false, false
+	defer exitShouldBeCalledWith(t, 1, &exitCalled)()
+
+	var reported error
+	app := App("app", "")
+	app.ExitCodeFor(func(err error) int {
+		reported = err
+		return 0
+	})
+	app.Action = func() {
+		Fail(nil)
+	}
+	app.After = func() {
+		afterCalled = true
+	}
+	app.Run([]string{"app"})
+
+	require.True(t, exitCalled, "exit should have been called")
+	require.True(t, afterCalled, "after should have been called")
+	require.NotNil(t, reported)
+	require.Equal(t, "Error: failed\n", stdErr)
+}
+
+func TestHelpMessageWithExamples(t *testing.T) {
+	var out, err string
+	defer captureAndRestoreOutput(&out, &err)()
+
+	exitCalled := false
+	defer exitShouldBeCalledWith(t, 2, &exitCalled)()
+
+	app := App("app", "")
+	app.String(StringOpt{Name: "u url", Value: "", Desc: "API endpoint", Example: "https://api.example.com", EnvVar: "API_URL"})
+	app.String(StringOpt{Name: "t token", Value: "", Desc: "API token", Example: "s3cr3t", HideValue: true})
+	app.Ints(IntsOpt{Name: "p port", Value: nil, Desc: "Ports", Example: "8080"})
+
+	app.Action = func() {}
+	app.Run([]string{"app", "-h"})
+
+	help := `
+Usage: app [OPTIONS]
+
+
+Options:
+  -u, --url       API endpoint (e.g. https://api.example.com) (default from $API_URL)
+  -t, --token     API token
+  -p, --port=[]   Ports (e.g. 8080)
+`
+
+	require.Equal(t, help, err)
+}
+
+func TestHelpMessageHideDefaults(t *testing.T) {
+	exitCalled := false
+	defer exitShouldBeCalledWith(t, 2, &exitCalled)()
+
+	hideDefaults := false
+	init := func(app *Cli) {
+		app.HideDefaults = hideDefaults
+		app.Spec = "[OPTIONS] SRC"
+		app.String(StringOpt{Name: "u user", Value: "root", Desc: "User", HideValue: false})
+		app.Int(IntOpt{Name: "p port", Value: 22, Desc: "Port"})
+		app.String(StringArg{Name: "SRC", Value: "/tmp", Desc: "Source"})
+	}
+
+	var err string
+	restore := captureAndRestoreOutput(nil, &err)
+	runApp(init, "-h")
+	restore()
+	require.Equal(t, `
+Usage: app [OPTIONS] SRC
+
+
+Arguments:
+  SRC="/tmp" (string)   Source
+
+Options:
+  -u, --user="root"   User
+  -p, --port=22       Port
+`, err)
+
+	err = ""
+	hideDefaults = true
+	restore = captureAndRestoreOutput(nil, &err)
+	runApp(init, "-h")
+	restore()
+	require.Equal(t, `
+Usage: app [OPTIONS] SRC
+
+
+Arguments:
+  SRC (string)   Source
+
+Options:
+  -u, --user    User
+  -p, --port    Port
+`, err)
+}
+
+func TestHelpMessageVisibleWhen(t *testing.T) {
+	exitCalled := false
+	defer exitShouldBeCalledWith(t, 2, &exitCalled)()
+	defer os.Setenv("APP_EXPERIMENTAL", "")
+
+	experimental := func() bool {
+		return os.Getenv("APP_EXPERIMENTAL") == "1"
+	}
+	init := func(app *Cli) {
+		app.Bool(BoolOpt{Name: "f force", Value: false, Desc: "Force"})
+		app.String(StringOpt{Name: "engine", Value: "v1", Desc: "Engine", VisibleWhen: experimental})
+	}
+	help := func() string {
+		var err string
+		defer captureAndRestoreOutput(nil, &err)()
+		runApp(init, "-h")
+		return err
+	}
+
+	os.Setenv("APP_EXPERIMENTAL", "")
+	require.Equal(t, `
+Usage: app [OPTIONS]
+
+
+Options:
+  -f, --[no-]force=false   Force
+`, help())
+
+	os.Setenv("APP_EXPERIMENTAL", "1")
+	require.Equal(t, `
+Usage: app [OPTIONS]
+
+
+Options:
+  -f, --[no-]force=false   Force
+  --engine="v1"            Engine
+`, help())
+
+	os.Setenv("APP_EXPERIMENTAL", "")
+	defer exitShouldNotCalled(t)()
+	defer suppressOutput()()
+	app := testApp(init)
+	engine := app.optionsIdx["--engine"].value.Interface().(*string)
+	app.Run([]string{"app", "--engine", "v2"})
+	require.Equal(t, "v2", *engine, "hidden options should still be accepted")
+}
+
+func TestHelpMessageArgGroups(t *testing.T) {
+	exitCalled := false
+	defer exitShouldBeCalledWith(t, 2, &exitCalled)()
+
+	var stdErr string
+	defer captureAndRestoreOutput(nil, &stdErr)()
+
+	app := App("cp", "")
+	app.Spec = "[-r] SRC... DST [MODE]"
+	app.BoolOpt("r", false, "Recursive")
+	app.Strings(StringsArg{Name: "SRC", Desc: "The files to copy", Group: "Files"})
+	app.String(StringArg{Name: "DST", Desc: "The destination directory", Group: "Files"})
+	app.String(StringArg{Name: "MODE", Value: "0644", Desc: "The copied files mode"})
+	app.Action = func() {}
+
+	app.Run([]string{"cp", "-h"})
+
+	require.Equal(t, `
+Usage: cp [-r] SRC... DST [MODE]
+
+
+Arguments:
+  MODE="0644" (string)   The copied files mode
+
+Files:
+  SRC=[] (string...)   The files to copy
+  DST="" (string)      The destination directory
+
+Options:
+  -r=false     Recursive
+`, stdErr)
+}
+
+func TestHelpMessageBoolStrings(t *testing.T) {
+	var stdErr string
+	defer captureAndRestoreOutput(nil, &stdErr)()
+
+	var color, verbose *bool
+	init := func(app *Cli) {
+		color = app.Bool(BoolOpt{Name: "c color", Value: true, TrueStr: "on", FalseStr: "off", Desc: "Colors"})
+		verbose = app.Bool(BoolOpt{Name: "v verbose", TrueStr: "yes", FalseStr: "no", Desc: "Verbose"})
+	}
+
+	func() {
+		exitCalled := false
+		defer exitShouldBeCalledWith(t, 2, &exitCalled)()
+		runApp(init, "-h")
+	}()
+	require.Equal(t, `
+Usage: app [OPTIONS]
+
+
+Options:
+  -c, --[no-]color=on     Colors
+  -v, --[no-]verbose=no   Verbose
+`, stdErr)
+
+	for _, cas := range []struct {
+		args           []string
+		color, verbose bool
+	}{
+		{[]string{"app", "-v"}, true, true},
+		{[]string{"app", "--color=false", "--verbose=1"}, false, true},
+		{[]string{"app", "-c=0", "-v=F"}, false, false},
+	} {
+		require.Nil(t, testApp(init).Run(cas.args))
+		require.Equal(t, cas.color, *color, "args %v", cas.args)
+		require.Equal(t, cas.verbose, *verbose, "args %v", cas.args)
+	}
+	require.NotNil(t, runApp(init, "--color=off"), "the help representations should not be accepted as values")
+}
+
+func TestHelpMessageSliceDefaults(t *testing.T) {
+	var stdErr string
+	defer captureAndRestoreOutput(nil, &stdErr)()
+
+	exitCalled := false
+	defer exitShouldBeCalledWith(t, 2, &exitCalled)()
+
+	app := App("app", "")
+	app.StringsOpt("t tag", []string{"a", "b"}, "Tags")
+	app.IntsOpt("p port", []int{80, 443}, "Ports")
+	app.StringsOpt("x", nil, "Excludes")
+	app.StringsArg("SRC", []string{"in.txt"}, "Sources")
+	app.Run([]string{"app", "-h"})
+
+	require.Equal(t, `
+Usage: app [OPTIONS] SRC
+
+
+Arguments:
+  SRC=in.txt (string...)   Sources
+
+Options:
+  -t, --tag=a, b       Tags
+  -p, --port=80, 443   Ports
+  -x=[]                Excludes
+`, stdErr)
+}
+
+func TestHelpMessageDuplicateEnvVars(t *testing.T) {
+	cmd := &Cmd{}
+	require.Equal(t, "Count ($FOO $BAR)", cmd.formatDescription("Count", " FOO  FOO BAR "))
+	require.Equal(t, "Count", cmd.formatDescription("Count", "  "))
+}
+
+func TestHelpMessageEnvDefault(t *testing.T) {
+	defer os.Setenv("APP_OUTPUT", "")
+	defer os.Setenv("APP_TAGS", "")
+
+	help := func() string {
+		exitCalled := false
+		defer exitShouldBeCalledWith(t, 2, &exitCalled)()
+		var stdErr string
+		defer captureAndRestoreOutput(nil, &stdErr)()
+
+		app := App("app", "")
+		app.String(StringOpt{Name: "o output", Value: "", EnvVar: "APP_OUTPUT OUTPUT", Desc: "Output"})
+		app.Strings(StringsOpt{Name: "t tag", EnvVar: "APP_TAGS", Desc: "Tags"})
+		app.Int(IntOpt{Name: "n", Value: 0, EnvVar: "APP_N", Desc: "Count"})
+		app.String(StringOpt{Name: "format", Value: "text", EnvVar: "APP_FORMAT", Desc: "Format"})
+		app.Action = func() {}
+		app.Run([]string{"app", "-h"})
+		return stdErr
+	}
+
+	os.Setenv("APP_OUTPUT", "")
+	os.Setenv("APP_TAGS", "")
+	require.Equal(t, `
+Usage: app [OPTIONS]
+
+
+Options:
+  -o, --output      Output (default from $APP_OUTPUT or $OUTPUT)
+  -t, --tag         Tags (default from $APP_TAGS)
+  -n=0              Count ($APP_N)
+  --format="text"   Format ($APP_FORMAT)
+`, help())
+
+	os.Setenv("APP_OUTPUT", "out.txt")
+	os.Setenv("APP_TAGS", "a,b")
+	require.Equal(t, `
+Usage: app [OPTIONS]
+
+
+Options:
+  -o, --output="out.txt"   Output ($APP_OUTPUT $OUTPUT)
+  -t, --tag=a, b           Tags ($APP_TAGS)
+  -n=0                     Count ($APP_N)
+  --format="text"          Format ($APP_FORMAT)
+`, help())
+}
+
+func TestUsageLine(t *testing.T) {
+	defer exitShouldNotCalled(t)()
+
+	var runUsage, sayUsage string
+	app := App("app", "App Desc")
+	app.BoolOpt("v verbose", false, "")
+	app.Command("run", "", func(cmd *Cmd) {
+		cmd.Spec = "[-d] IMAGE [ARG...]"
+		cmd.BoolOpt("d", false, "")
+		cmd.StringArg("IMAGE", "", "")
+		cmd.StringsArg("ARG", nil, "")
+		cmd.Action = func() {
+			runUsage = cmd.UsageLine()
+		}
+	})
+	app.Command("say", "", func(cmd *Cmd) {
+		cmd.Command("hi", "", ActionCommand(func() {}))
+		cmd.Before = func() {
+			sayUsage = cmd.UsageLine()
+		}
+	})
+
+	app.Run([]string{"app", "run", "ubuntu"})
+	require.Equal(t, "Usage: app run [-d] IMAGE [ARG...]", runUsage)
+	require.Equal(t, "Usage: app [OPTIONS] COMMAND [arg...]", app.UsageLine())
+
+	app.Run([]string{"app", "say", "hi"})
+	require.Equal(t, "Usage: app say COMMAND [arg...]", sayUsage)
+}
+
+func TestUsageLineFollowsSpecOrder(t *testing.T) {
+	specs := []string{
+		"SRC [--force] DST",
+		"[--force] SRC DST",
+		"SRC DST [--force]",
+		"SRC... [-r | --force] DST",
+		"[-f=<mode>] (SRC | -i) [DST]",
+	}
+
+	for _, spec := range specs {
+		app := App("cp", "")
+		app.Spec = spec
+		app.BoolOpt("force", false, "")
+		app.BoolOpt("r", false, "")
+		app.StringOpt("f", "", "")
+		app.BoolOpt("i", false, "")
+		app.StringsArg("SRC", nil, "")
+		app.StringArg("DST", "", "")
+		require.Nil(t, app.doInit(), "spec %s", spec)
+
+		require.Equal(t, "Usage: cp "+spec, app.UsageLine())
+	}
+}
+
+func TestResolve(t *testing.T) {
+	defer exitShouldNotCalled(t)()
+	defer suppressOutput()()
+
+	actionCalled := false
+	app := App("git", "")
+	app.StringOpt("C", "", "")
+	app.BoolOpt("v", false, "")
+	app.Command("remote", "", func(cmd *Cmd) {
+		cmd.Command("add", "", func(cmd *Cmd) {
+			cmd.StringOpt("t track", "", "")
+			cmd.StringArg("NAME", "", "")
+			cmd.StringArg("URL", "", "")
+			cmd.Spec = "[-t] NAME URL"
+			cmd.Action = func() {
+				actionCalled = true
+			}
+		})
+		cmd.Command("remove", "", ActionCommand(func() {
+			actionCalled = true
+		}))
+	})
+	app.Command("status", "", ActionCommand(func() {
+		actionCalled = true
+	}))
+
+	cases := []struct {
+		args     []string
+		expected []string
+	}{
+		{[]string{"git"}, []string{}},
+		{[]string{"git", "-v"}, []string{}},
+		{[]string{"git", "status"}, []string{"status"}},
+		{[]string{"git", "-C", "/tmp", "status"}, []string{"status"}},
+		{[]string{"git", "remote"}, []string{"remote"}},
+		{[]string{"git", "-v", "remote", "add", "-t", "master", "origin", "http://x"}, []string{"remote", "add"}},
+		{[]string{"git", "remote", "add", "status"}, []string{"remote", "add"}},
+		{[]string{"git", "remote", "remove", "origin"}, []string{"remote", "remove"}},
+	}
+
+	for _, cas := range cases {
+		path, err := app.Resolve(cas.args)
+		require.Nil(t, err, "resolving %v", cas.args)
+		require.Equal(t, cas.expected, path, "resolving %v", cas.args)
+	}
+
+	badCases := []struct {
+		args []string
+		msg  string
+	}{
+		{[]string{"git", "stauts"}, "unknown command stauts"},
+		{[]string{"git", "-C", "/tmp", "pull"}, "unknown command pull"},
+		{[]string{"git", "remote", "rename", "a", "b"}, "unknown command rename"},
+	}
+
+	for _, cas := range badCases {
+		_, err := app.Resolve(cas.args)
+		require.NotNil(t, err, "resolving %v should have failed", cas.args)
+		require.Equal(t, cas.msg, err.Error())
+	}
+
+	require.False(t, actionCalled, "no action should have been called")
+}
+
+func TestParseKnown(t *testing.T) {
+	defer exitShouldNotCalled(t)()
+	defer suppressOutput()()
+
+	var (
+		verbose *bool
+		name    *string
+		count   *int
+		called  bool
+	)
+	init := func(app *Cli) {
+		called = false
+		verbose = app.BoolOpt("v verbose", false, "")
+		name = app.StringOpt("n name", "", "")
+		count = app.IntOpt("c count", 1, "")
+		app.Action = func() {
+			called = true
+		}
+	}
+
+	cases := []struct {
+		args    []string
+		rest    []string
+		verbose bool
+		name    string
+	}{
+		{[]string{"wrapper"}, []string{}, false, ""},
+		{[]string{"wrapper", "-v", "--name", "x"}, []string{}, true, "x"},
+		{[]string{"wrapper", "-v", "--color", "auto", "file"}, []string{"--color", "auto", "file"}, true, ""},
+		{[]string{"wrapper", "--name=x", "build", "-v", "--name", "y"}, []string{"build", "-v", "--name", "y"}, false, "x"},
+		{[]string{"wrapper", "-x", "-v"}, []string{"-x", "-v"}, false, ""},
+		{[]string{"wrapper", "-v", "--", "-n", "x"}, []string{"--", "-n", "x"}, true, ""},
+	}
+
+	for _, cas := range cases {
+		app := testApp(init)
+		rest, err := app.ParseKnown(cas.args)
+		require.Nil(t, err, "parsing %v", cas.args)
+		require.Equal(t, cas.rest, rest, "parsing %v", cas.args)
+		require.Equal(t, cas.verbose, *verbose, "parsing %v", cas.args)
+		require.Equal(t, cas.name, *name, "parsing %v", cas.args)
+		require.False(t, called, "the action should not have been called")
+	}
+
+	app := testApp(init)
+	_, err := app.ParseKnown([]string{"wrapper", "-c", "x", "file"})
+	require.NotNil(t, err)
+	require.Equal(t, 1, *count)
+}
+
+func TestSharedOptionSet(t *testing.T) {
+	defer exitShouldNotCalled(t)()
+	defer suppressOutput()()
+
+	var buildLogging, testLogging loggingOpts
+	app := App("app", "")
+	app.Command("build", "", func(cmd *Cmd) {
+		buildLogging.Apply(cmd)
+		cmd.Action = func() {}
+	})
+	app.Command("test", "", func(cmd *Cmd) {
+		testLogging.Apply(cmd)
+		cmd.Action = func() {}
+	})
+	require.Nil(t, app.doInitAll())
+
+	app.Run([]string{"app", "build", "--log-level", "debug", "--log-json"})
+	require.Equal(t, "debug", *buildLogging.level)
+	require.True(t, *buildLogging.json)
+	require.Equal(t, "info", *testLogging.level, "the commands should not share the option values")
+	require.False(t, *testLogging.json, "the commands should not share the option values")
+
+	app.Run([]string{"app", "test", "--log-level", "warn"})
+	require.Equal(t, "warn", *testLogging.level)
+	require.Equal(t, "debug", *buildLogging.level, "the commands should not share the option values")
+}
+
+func TestCmdPath(t *testing.T) {
+	defer exitShouldNotCalled(t)()
+	defer suppressOutput()()
+
+	var (
+		appPath, dbPath, migratePath []string
+		migrateCmd                   *Cmd
+	)
+	app := App("app", "")
+	app.Command("db", "", func(db *Cmd) {
+		db.Before = func() {
+			dbPath = db.Path()
+		}
+		db.Command("migrate", "", func(migrate *Cmd) {
+			migrateCmd = migrate
+			migrate.Action = func() {
+				migratePath = migrate.Path()
+			}
+		})
+	})
+	app.Before = func() {
+		appPath = app.Path()
+	}
+
+	app.Run([]string{"app", "db", "migrate"})
+	require.Equal(t, []string{"app"}, appPath)
+	require.Equal(t, []string{"app", "db"}, dbPath)
+	require.Equal(t, []string{"app", "db", "migrate"}, migratePath)
+
+	migratePath[1] = "changed"
+	require.Equal(t, []string{"app", "db", "migrate"}, migrateCmd.Path(), "the path should be a copy")
+}
+
+func TestDeprecatedCommand(t *testing.T) {
+	defer exitShouldNotCalled(t)()
+
+	called := false
+	init := func(app *Cli) {
+		called = false
+		app.Command("migrate", "Migrate the db", func(cmd *Cmd) {
+			cmd.Deprecated("use 'app db migrate' instead")
+			cmd.Action = func() {
+				called = true
+			}
+		})
+		app.Command("db", "Manage the db", func(cmd *Cmd) {
+			cmd.Command("migrate", "Migrate the db", ActionCommand(func() {}))
+		})
+	}
+
+	var stdErr string
+	restore := captureAndRestoreOutput(nil, &stdErr)
+	runApp(init, "migrate")
+	restore()
+	require.True(t, called, "the deprecated command should still run")
+	require.Equal(t, "Warning: command app migrate is deprecated: use 'app db migrate' instead\n", stdErr)
+
+	stdErr = ""
+	restore = captureAndRestoreOutput(nil, &stdErr)
+	runApp(init, "db", "migrate")
+	restore()
+	require.Equal(t, "", stdErr)
+
+	exitCalled := false
+	defer exitShouldBeCalledWith(t, 2, &exitCalled)()
+	stdErr = ""
+	restore = captureAndRestoreOutput(nil, &stdErr)
+	runApp(init, "-h")
+	restore()
+	require.Equal(t, `
+Usage: app COMMAND [arg...]
+
+
+Commands:
+  db           Manage the db
+
+Run 'app COMMAND --help' for more information on a command.
+`, stdErr)
+}
+
+func TestOnWarning(t *testing.T) {
+	defer exitShouldNotCalled(t)()
+
+	var stdErr string
+	defer captureAndRestoreOutput(nil, &stdErr)()
+
+	warnings := []string{}
+	app := App("app", "")
+	app.OnWarning(func(msg string) {
+		warnings = append(warnings, msg)
+	})
+	app.Command("db", "", func(db *Cmd) {
+		db.Command("upgrade", "", func(cmd *Cmd) {
+			cmd.Deprecated("use 'app db migrate' instead")
+			cmd.Action = func() {}
+		})
+	})
+
+	app.Run([]string{"app", "db", "upgrade"})
+	require.Equal(t, []string{"command app db upgrade is deprecated: use 'app db migrate' instead"}, warnings)
+	require.Equal(t, "", stdErr, "the warnings should not be printed")
+}
+
+func TestBuiltinCommands(t *testing.T) {
+	defer exitShouldNotCalled(t)()
+
+	init := func(app *Cli) {
+		app.ErrorHandling = flag.ExitOnError
+		app.Version("v version", "app 1.0.0")
+		app.Command("remote", "Manage remotes", func(cmd *Cmd) {
+			cmd.Command("add", "Add a remote", func(cmd *Cmd) {
+				cmd.LongDesc = "Add a remote named NAME"
+				cmd.StringArg("NAME", "", "The remote name")
+				cmd.Action = func() {}
+			})
+		})
+	}
+
+	run := func(app *Cli, args ...string) string {
+		var stdErr string
+		defer captureAndRestoreOutput(nil, &stdErr)()
+		app.Run(append([]string{"app"}, args...))
+		return stdErr
+	}
+
+	require.Equal(t, `
+Usage: app [OPTIONS] COMMAND [arg...]
+
+
+Options:
+  -v, --version    Show the version and exit
+
+Commands:
+  remote       Manage remotes
+
+Run 'app COMMAND --help' for more information on a command.
+`, run(testApp(init), "help"))
+
+	require.Equal(t, `
+Usage: app remote add NAME
+
+Add a remote named NAME
+
+Arguments:
+  NAME="" (string)   The remote name
+`, run(testApp(init), "help", "remote", "add"))
+
+	require.Equal(t, "app 1.0.0\n", run(testApp(init), "version"))
+
+	exitCalled := false
+	defer exitShouldBeCalledWith(t, 2, &exitCalled)()
+	out := run(testApp(init), "help", "remote", "rm")
+	require.True(t, exitCalled, "exit should have been called")
+	require.True(t, strings.HasPrefix(out, "Error: unknown command rm\n\nUsage: app remote COMMAND [arg...]"), "unexpected output %q", out)
+
+	app := testApp(init)
+	app.NoBuiltinCommands = true
+	exitCalled = false
+	out = run(app, "version")
+	require.True(t, exitCalled, "exit should have been called")
+	require.True(t, strings.HasPrefix(out, "Error: incorrect usage"), "unexpected output %q", out)
+
+	helped := false
+	app = testApp(init)
+	app.Command("help", "", ActionCommand(func() {
+		helped = true
+	}))
+	run(app, "help")
+	require.True(t, helped, "a help command declared by the app should take precedence")
+}
+
+func TestReset(t *testing.T) {
+	defer exitShouldNotCalled(t)()
+	defer suppressOutput()()
+
+	os.Setenv("APP_OUTPUT", "env-out")
+	defer os.Unsetenv("APP_OUTPUT")
+
+	app := App("app", "")
+	output := app.String(StringOpt{Name: "o output", Value: "out", EnvVar: "APP_OUTPUT"})
+	tags := app.StringsOpt("t tag", []string{"default"}, "")
+	var (
+		force *bool
+		src   *string
+	)
+	app.Command("cp", "", func(cmd *Cmd) {
+		cmd.Spec = "[-f] [SRC]"
+		force = cmd.BoolOpt("f", false, "")
+		src = cmd.StringArg("SRC", "src", "")
+		cmd.Action = func() {}
+	})
+
+	require.Nil(t, app.Run([]string{"app", "-o", "x", "-t", "a", "-t", "b", "cp", "-f", "file"}))
+	require.Equal(t, "x", *output)
+	require.Equal(t, []string{"default", "a", "b"}, *tags)
+	require.True(t, *force)
+	require.Equal(t, "file", *src)
+
+	app.Reset()
+	require.Equal(t, "env-out", *output)
+	require.Equal(t, SourceEnv, app.optionsIdx["-o"].source)
+	require.Equal(t, []string{"default"}, *tags)
+	require.Equal(t, SourceDefault, app.optionsIdx["-t"].source)
+
+	require.Nil(t, app.Run([]string{"app", "cp"}))
+	require.Equal(t, "env-out", *output)
+	require.Equal(t, []string{"default"}, *tags)
+	require.False(t, *force, "the values of a run should not leak into the next one")
+	require.Equal(t, "src", *src, "the values of a run should not leak into the next one")
+}
+
+func TestQuiet(t *testing.T) {
+	var out, errOut string
+	defer captureAndRestoreOutput(&out, &errOut)()
+
+	init := func(app *Cli) {
+		app.ErrorHandling = flag.ExitOnError
+		app.Quiet(true)
+		app.Command("db", "", func(db *Cmd) {
+			db.Deprecated("use 'app database' instead")
+			db.StringArg("NAME", "", "")
+			db.Action = func() {
+				fmt.Fprint(stdOut, "action output")
+			}
+		})
+	}
+
+	exitCalled := false
+	func() {
+		defer exitShouldBeCalledWith(t, 2, &exitCalled)()
+		runApp(init, "db", "--unknown")
+	}()
+	require.True(t, exitCalled, "exit should have been called")
+	require.Equal(t, "", errOut, "no usage error should be printed in quiet mode")
+
+	app := testApp(init)
+	app.ErrorHandling = flag.ContinueOnError
+	err := app.Run([]string{"app", "unknown"})
+	require.NotNil(t, err, "the usage error should still be returned")
+	require.Equal(t, "", errOut, "no usage error should be printed in quiet mode")
+
+	func() {
+		defer exitShouldNotCalled(t)()
+		runApp(init, "db", "x")
+	}()
+	require.Equal(t, "", errOut, "no warning should be printed in quiet mode")
+	require.Equal(t, "action output", out, "the action output should be left untouched")
+
+	app = testApp(init)
+	app.Quiet(false)
+	app.ErrorHandling = flag.ContinueOnError
+	app.Run([]string{"app", "unknown"})
+	require.Contains(t, errOut, "Usage: app", "the usage error should be printed when not in quiet mode")
+}
+
+func TestHelpMessageChoices(t *testing.T) {
+	var stdErr string
+	defer captureAndRestoreOutput(nil, &stdErr)()
+
+	exitCalled := false
+	defer exitShouldBeCalledWith(t, 2, &exitCalled)()
+
+	app := App("app", "")
+	app.String(StringOpt{Name: "l level", Value: "info", Desc: "Log level", Choices: []string{"debug", "info", "warn"}})
+	app.String(StringArg{Name: "FORMAT", Value: "json", Desc: "Output format", Choices: []string{"json", "text"}})
+	app.Run([]string{"app", "-h"})
+
+	require.Equal(t, `
+Usage: app [OPTIONS] FORMAT
+
+
+Arguments:
+  FORMAT="json" (string)   Output format (one of json, text)
+
+Options:
+  -l, --level="info"   Log level (one of debug, info, warn)
+`, stdErr)
+}
+
+func TestHelpMessageIntChoices(t *testing.T) {
+	var stdErr string
+	defer captureAndRestoreOutput(nil, &stdErr)()
+
+	exitCalled := false
+	defer exitShouldBeCalledWith(t, 2, &exitCalled)()
+
+	app := App("app", "")
+	app.IntChoiceOpt("tls-version", []int{12, 13}, 13, "TLS version")
+	app.Run([]string{"app", "-h"})
+
+	require.Equal(t, `
+Usage: app [OPTIONS]
+
+
+Options:
+  --tls-version=13   TLS version (one of 12, 13)
+`, stdErr)
+}
@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"flag"
 	"fmt"
+	"os"
+	"reflect"
 	"strings"
 	"text/tabwriter"
+	"time"
 )
 
 /*
@@ -26,6 +29,16 @@ type Cmd struct {
 	LongDesc string
 	// The command error handling strategy
 	ErrorHandling flag.ErrorHandling
+	// If true, long options can also be called with a single dash, e.g. `-force` for `--force`, like with the standard flag package.
+	// A single dash argument matching a long option name is then never treated as a group of short options
+	SingleDashLongOpts bool
+	// If true, the help message does not show the current value of any of the command's options and arguments, regardless of their HideValue setting
+	HideDefaults bool
+	// If true, the help message lists the options sorted by name and grouped under the first letter of their longest name, e.g. for the commands with many options
+	IndexedHelp bool
+	// Example call arguments of the command, following its name, e.g. `-r src dst`, listed in the help message and checked by ValidateExamples.
+	// An example can select a sub command, e.g. `remote add origin` for the app
+	Examples []string
 
 	init CmdInitializer
 	name string
@@ -39,7 +52,37 @@ type Cmd struct {
 
 	parents []string
 
-	fsm *state
+	fsm         *state
+	declared    bool
+	initialized bool
+	dir         func() string
+	jsonFlags   *opt
+	configFile  *opt
+	configFiles []configFileRef
+	showConfig  *opt
+	deprecated  string
+	onWarning   func(string)
+	jsonErrors  bool
+	pager       bool
+	history     bool
+	profile     Profile
+	autoShort   bool
+	alias       []string
+	streams     []*ArgStream
+	ancestors   []*Cmd
+	summary     bool
+	ignored     []string
+	passthrough *[]string
+	hidden      bool
+
+	valueSources  []ValueSource
+	implications  []implication
+	orderings     [][]*opt
+	exactlyOnes   [][]*opt
+	enumFlags     []enumFlags
+	interpolation bool
+
+	defaultOverrides map[string][]string
 }
 
 /*
@@ -57,16 +100,51 @@ IntParam represents an Int option or argument
 */
 type IntParam interface{}
 
+/*
+Float64Param represents a Float64 option or argument
+*/
+type Float64Param interface{}
+
+/*
+DurationParam represents a Duration option or argument
+*/
+type DurationParam interface{}
+
+/*
+IntCounterParam represents an Int counter option
+*/
+type IntCounterParam interface{}
+
+/*
+UnitParam represents a unit option
+*/
+type UnitParam interface{}
+
 /*
 StringsParam represents a string slice option or argument
 */
 type StringsParam interface{}
 
+/*
+StringMapParam represents a string map argument
+*/
+type StringMapParam interface{}
+
 /*
 IntsParam represents an int slice option or argument
 */
 type IntsParam interface{}
 
+/*
+DurationsParam represents a duration slice option or argument
+*/
+type DurationsParam interface{}
+
+/*
+Float64sParam represents a float64 slice option or argument
+*/
+type Float64sParam interface{}
+
 /*
 CmdInitializer is a function that configures a command by adding options, arguments, a spec, sub commands and the code
 to execute when the command is called
@@ -87,15 +165,19 @@ the last argument, init, is a function that will be called by mow.cli to further
 */
 func (c *Cmd) Command(name, desc string, init CmdInitializer) {
 	c.commands = append(c.commands, &Cmd{
-		ErrorHandling: c.ErrorHandling,
-		name:          name,
-		desc:          desc,
-		init:          init,
-		commands:      []*Cmd{},
-		options:       []*opt{},
-		optionsIdx:    map[string]*opt{},
-		args:          []*arg{},
-		argsIdx:       map[string]*arg{},
+		ErrorHandling:      c.ErrorHandling,
+		SingleDashLongOpts: c.SingleDashLongOpts,
+		HideDefaults:       c.HideDefaults,
+		IndexedHelp:        c.IndexedHelp,
+		autoShort:          c.autoShort,
+		name:               name,
+		desc:               desc,
+		init:               init,
+		commands:           []*Cmd{},
+		options:            []*opt{},
+		optionsIdx:         map[string]*opt{},
+		args:               []*arg{},
+		argsIdx:            map[string]*arg{},
 	})
 }
 
@@ -108,9 +190,9 @@ The result should be stored in a variable (a pointer to a bool) which will be po
 func (c *Cmd) Bool(p BoolParam) *bool {
 	switch x := p.(type) {
 	case BoolOpt:
-		return c.mkOpt(opt{name: x.Name, desc: x.Desc, envVar: x.EnvVar, hideValue: x.HideValue}, x.Value).(*bool)
+		return c.mkOpt(opt{name: x.Name, desc: x.Desc, envVar: x.EnvVar, envRequired: x.EnvRequired, immutable: x.Immutable, noComplete: x.NoComplete, hideValue: x.HideValue, envInvert: x.EnvInvert, once: x.Once, visibleWhen: x.VisibleWhen, helpFormatter: customBoolFormatter(x.TrueStr, x.FalseStr), onSet: boolCallback(x.OnSet)}, x.Value).(*bool)
 	case BoolArg:
-		return c.mkArg(arg{name: x.Name, desc: x.Desc, envVar: x.EnvVar, hideValue: x.HideValue}, x.Value).(*bool)
+		return c.mkArg(arg{name: x.Name, desc: x.Desc, envVar: x.EnvVar, hideValue: x.HideValue, group: x.Group, missingMsg: x.MissingMsg, strict: x.Strict}, x.Value).(*bool)
 	default:
 		panic(fmt.Sprintf("Unhandled param %v", p))
 	}
@@ -125,9 +207,9 @@ The result should be stored in a variable (a pointer to a string) which will be
 func (c *Cmd) String(p StringParam) *string {
 	switch x := p.(type) {
 	case StringOpt:
-		return c.mkOpt(opt{name: x.Name, desc: x.Desc, envVar: x.EnvVar, hideValue: x.HideValue}, x.Value).(*string)
+		return c.mkOpt(opt{name: x.Name, desc: x.Desc, envVar: x.EnvVar, envRequired: x.EnvRequired, immutable: x.Immutable, noComplete: x.NoComplete, hideValue: x.HideValue, optionalValue: x.OptionalValue, nonEmpty: x.NonEmpty, once: x.Once, example: x.Example, transforms: x.Transforms, allowExec: x.AllowExec, secret: x.Secret, visibleWhen: x.VisibleWhen, choiceFunc: x.ChoiceFunc, allowed: x.Choices, ignoreCase: x.CaseInsensitive, pattern: x.Pattern, onSet: stringCallback(x.OnSet)}, x.Value).(*string)
 	case StringArg:
-		return c.mkArg(arg{name: x.Name, desc: x.Desc, envVar: x.EnvVar, hideValue: x.HideValue}, x.Value).(*string)
+		return c.mkArg(arg{name: x.Name, desc: x.Desc, envVar: x.EnvVar, choices: x.Choices, ignoreCase: x.CaseInsensitive, pattern: x.Pattern, hideValue: x.HideValue, group: x.Group, missingMsg: x.MissingMsg, complete: x.Complete}, x.Value).(*string)
 	default:
 		panic(fmt.Sprintf("Unhandled param %v", p))
 	}
@@ -142,9 +224,88 @@ The result should be stored in a variable (a pointer to an int) which will be po
 func (c *Cmd) Int(p IntParam) *int {
 	switch x := p.(type) {
 	case IntOpt:
-		return c.mkOpt(opt{name: x.Name, desc: x.Desc, envVar: x.EnvVar, hideValue: x.HideValue}, x.Value).(*int)
+		conversions, allowed := withIntChoices(nil, x.Choices)
+		return c.mkOpt(opt{name: x.Name, desc: x.Desc, envVar: x.EnvVar, envRequired: x.EnvRequired, immutable: x.Immutable, noComplete: x.NoComplete, hideValue: x.HideValue, once: x.Once, example: x.Example, transforms: x.Transforms, underscores: x.AllowUnderscore, conversions: conversions, allowed: allowed, bounds: newIntBounds(fmt.Sprintf("Option %s", x.Name), x.Min, x.Max), visibleWhen: x.VisibleWhen, onSet: intCallback(x.OnSet)}, x.Value).(*int)
 	case IntArg:
-		return c.mkArg(arg{name: x.Name, desc: x.Desc, envVar: x.EnvVar, hideValue: x.HideValue}, x.Value).(*int)
+		return c.mkArg(arg{name: x.Name, desc: x.Desc, envVar: x.EnvVar, bounds: newIntBounds(fmt.Sprintf("Argument %s", x.Name), x.Min, x.Max), hideValue: x.HideValue, group: x.Group, missingMsg: x.MissingMsg}, x.Value).(*int)
+	default:
+		panic(fmt.Sprintf("Unhandled param %v", p))
+	}
+}
+
+/*
+Float64 can be used to add a float64 option or argument to a command.
+It accepts either a Float64Opt or a Float64Arg struct.
+
+The result should be stored in a variable (a pointer to a float64) which will be populated when the app is run and the call arguments get parsed
+*/
+func (c *Cmd) Float64(p Float64Param) *float64 {
+	switch x := p.(type) {
+	case Float64Opt:
+		return c.mkOpt(opt{name: x.Name, desc: x.Desc, envVar: x.EnvVar, envRequired: x.EnvRequired, immutable: x.Immutable, noComplete: x.NoComplete, hideValue: x.HideValue, once: x.Once, example: x.Example, transforms: x.Transforms, visibleWhen: x.VisibleWhen}, x.Value).(*float64)
+	case Float64Arg:
+		return c.mkArg(arg{name: x.Name, desc: x.Desc, envVar: x.EnvVar, hideValue: x.HideValue, group: x.Group, missingMsg: x.MissingMsg}, x.Value).(*float64)
+	default:
+		panic(fmt.Sprintf("Unhandled param %v", p))
+	}
+}
+
+/*
+Duration can be used to add a duration option or argument to a command.
+It accepts either a DurationOpt or a DurationArg struct.
+
+The result should be stored in a variable (a pointer to a time.Duration) which will be populated when the app is run and the call arguments get parsed
+*/
+func (c *Cmd) Duration(p DurationParam) *time.Duration {
+	switch x := p.(type) {
+	case DurationOpt:
+		return c.mkOpt(opt{name: x.Name, desc: x.Desc, envVar: x.EnvVar, envRequired: x.EnvRequired, immutable: x.Immutable, noComplete: x.NoComplete, hideValue: x.HideValue, visibleWhen: x.VisibleWhen}, x.Value).(*time.Duration)
+	case DurationArg:
+		return c.mkArg(arg{name: x.Name, desc: x.Desc, envVar: x.EnvVar, hideValue: x.HideValue, group: x.Group, missingMsg: x.MissingMsg}, x.Value).(*time.Duration)
+	default:
+		panic(fmt.Sprintf("Unhandled param %v", p))
+	}
+}
+
+/*
+IntCounter can be used to add an int counter option to a command.
+It accepts an IntCounterOpt struct.
+
+The result should be stored in a variable (a pointer to an int) which will be populated when the app is run and the call arguments get parsed
+*/
+func (c *Cmd) IntCounter(p IntCounterParam) *int {
+	switch x := p.(type) {
+	case IntCounterOpt:
+		step := x.Step
+		if step == 0 {
+			step = 1
+		}
+		return c.mkOpt(opt{name: x.Name, desc: x.Desc, envVar: x.EnvVar, envRequired: x.EnvRequired, immutable: x.Immutable, noComplete: x.NoComplete, hideValue: x.HideValue, step: step, visibleWhen: x.VisibleWhen, onSet: intCallback(x.OnSet)}, x.Value).(*int)
+	default:
+		panic(fmt.Sprintf("Unhandled param %v", p))
+	}
+}
+
+/*
+Unit can be used to add an option accepting a quantity with a unit to a command, e.g. a distance or a temperature.
+It accepts a UnitOpt struct:
+
+	distance := cmd.Unit(cli.UnitOpt{
+		Name:  "distance",
+		Base:  "m",
+		Units: map[string]cli.Unit{"km": {Factor: 1000}, "cm": {Factor: 0.01}},
+	})
+
+	$ app --distance 5km
+	// *distance is 5000
+
+The result should be stored in a variable (a pointer to a float64) which will be populated, in the base unit, when the app is run and the call arguments get parsed
+*/
+func (c *Cmd) Unit(p UnitParam) *float64 {
+	switch x := p.(type) {
+	case UnitOpt:
+		units := newUnitConverter(x.Base, x.Units)
+		return c.mkOpt(opt{name: x.Name, desc: x.Desc, envVar: x.EnvVar, envRequired: x.EnvRequired, immutable: x.Immutable, noComplete: x.NoComplete, hideValue: x.HideValue, conversions: []func(string) (string, error){units.convert}, units: units, helpFormatter: units.format, visibleWhen: x.VisibleWhen}, x.Value).(*float64)
 	default:
 		panic(fmt.Sprintf("Unhandled param %v", p))
 	}
@@ -159,9 +320,9 @@ The result should be stored in a variable (a pointer to a string slice) which wi
 func (c *Cmd) Strings(p StringsParam) *[]string {
 	switch x := p.(type) {
 	case StringsOpt:
-		return c.mkOpt(opt{name: x.Name, desc: x.Desc, envVar: x.EnvVar, hideValue: x.HideValue}, x.Value).(*[]string)
+		return c.mkOpt(opt{name: x.Name, desc: x.Desc, envVar: x.EnvVar, envRequired: x.EnvRequired, immutable: x.Immutable, noComplete: x.NoComplete, hideValue: x.HideValue, example: x.Example, transforms: x.Transforms, sep: x.Sep, visibleWhen: x.VisibleWhen}, x.Value).(*[]string)
 	case StringsArg:
-		return c.mkArg(arg{name: x.Name, desc: x.Desc, envVar: x.EnvVar, hideValue: x.HideValue}, x.Value).(*[]string)
+		return c.mkArg(arg{name: x.Name, desc: x.Desc, envVar: x.EnvVar, hideValue: x.HideValue, group: x.Group, missingMsg: x.MissingMsg, minCount: x.MinCount, maxCount: x.MaxCount, validateElem: stringValidator(x.ValidateElem), complete: x.Complete}, x.Value).(*[]string)
 	default:
 		panic(fmt.Sprintf("Unhandled param %v", p))
 	}
@@ -176,18 +337,75 @@ The result should be stored in a variable (a pointer to an int slice) which will
 func (c *Cmd) Ints(p IntsParam) *[]int {
 	switch x := p.(type) {
 	case IntsOpt:
-		return c.mkOpt(opt{name: x.Name, desc: x.Desc, envVar: x.EnvVar, hideValue: x.HideValue}, x.Value).(*[]int)
+		return c.mkOpt(opt{name: x.Name, desc: x.Desc, envVar: x.EnvVar, envRequired: x.EnvRequired, immutable: x.Immutable, noComplete: x.NoComplete, hideValue: x.HideValue, example: x.Example, transforms: x.Transforms, underscores: x.AllowUnderscore, sep: x.Sep, visibleWhen: x.VisibleWhen}, x.Value).(*[]int)
 	case IntsArg:
-		return c.mkArg(arg{name: x.Name, desc: x.Desc, envVar: x.EnvVar, hideValue: x.HideValue}, x.Value).(*[]int)
+		return c.mkArg(arg{name: x.Name, desc: x.Desc, envVar: x.EnvVar, hideValue: x.HideValue, group: x.Group, missingMsg: x.MissingMsg, minCount: x.MinCount, maxCount: x.MaxCount, validateElem: intValidator(x.ValidateElem)}, x.Value).(*[]int)
+	default:
+		panic(fmt.Sprintf("Unhandled param %v", p))
+	}
+}
+
+/*
+Durations can be used to add a duration slice option or argument to a command, e.g. for a retry schedule.
+It accepts either a DurationsOpt or a DurationsArg struct.
+
+The result should be stored in a variable (a pointer to a time.Duration slice) which will be populated when the app is run and the call arguments get parsed
+*/
+func (c *Cmd) Durations(p DurationsParam) *[]time.Duration {
+	switch x := p.(type) {
+	case DurationsOpt:
+		return c.mkOpt(opt{name: x.Name, desc: x.Desc, envVar: x.EnvVar, envRequired: x.EnvRequired, immutable: x.Immutable, noComplete: x.NoComplete, hideValue: x.HideValue, example: x.Example, transforms: x.Transforms, sep: x.Sep, visibleWhen: x.VisibleWhen}, x.Value).(*[]time.Duration)
+	case DurationsArg:
+		return c.mkArg(arg{name: x.Name, desc: x.Desc, envVar: x.EnvVar, hideValue: x.HideValue, group: x.Group, missingMsg: x.MissingMsg, minCount: x.MinCount, maxCount: x.MaxCount}, x.Value).(*[]time.Duration)
+	default:
+		panic(fmt.Sprintf("Unhandled param %v", p))
+	}
+}
+
+/*
+Float64s can be used to add a float64 slice option or argument to a command, e.g. for weights.
+It accepts either a Float64sOpt or a Float64sArg struct.
+
+The result should be stored in a variable (a pointer to a float64 slice) which will be populated when the app is run and the call arguments get parsed
+*/
+func (c *Cmd) Float64s(p Float64sParam) *[]float64 {
+	switch x := p.(type) {
+	case Float64sOpt:
+		return c.mkOpt(opt{name: x.Name, desc: x.Desc, envVar: x.EnvVar, envRequired: x.EnvRequired, immutable: x.Immutable, noComplete: x.NoComplete, hideValue: x.HideValue, example: x.Example, transforms: x.Transforms, sep: x.Sep, visibleWhen: x.VisibleWhen}, x.Value).(*[]float64)
+	case Float64sArg:
+		return c.mkArg(arg{name: x.Name, desc: x.Desc, envVar: x.EnvVar, hideValue: x.HideValue, group: x.Group, missingMsg: x.MissingMsg, minCount: x.MinCount, maxCount: x.MaxCount}, x.Value).(*[]float64)
+	default:
+		panic(fmt.Sprintf("Unhandled param %v", p))
+	}
+}
+
+/*
+StringMap can be used to add a string map argument to a command.
+It accepts a StringMapArg struct.
+
+The result should be stored in a variable (a pointer to a string map) which will be populated when the app is run and the call arguments get parsed
+*/
+func (c *Cmd) StringMap(p StringMapParam) *map[string]string {
+	switch x := p.(type) {
+	case StringMapArg:
+		return c.mkArg(arg{name: x.Name, desc: x.Desc, envVar: x.EnvVar, hideValue: x.HideValue, group: x.Group, missingMsg: x.MissingMsg}, x.Value).(*map[string]string)
 	default:
 		panic(fmt.Sprintf("Unhandled param %v", p))
 	}
 }
 
 func (c *Cmd) doInit() error {
-	if c.init != nil {
+	if c.initialized {
+		return nil
+	}
+	// the initializer is not run again if the spec parsing failed, so as not to declare the options twice
+	if c.init != nil && !c.declared {
 		c.init(c)
 	}
+	if c.autoShort && !c.declared {
+		c.assignShortNames()
+	}
+	c.declared = true
 
 	parents := append(c.parents, c.name)
 
@@ -201,6 +419,9 @@ func (c *Cmd) doInit() error {
 		}
 		for _, arg := range c.args {
 			c.Spec += arg.name + " "
+			if arg.isMap() {
+				c.Spec = strings.TrimSpace(c.Spec) + "... "
+			}
 		}
 	}
 	fsm, err := uParse(c)
@@ -208,9 +429,42 @@ func (c *Cmd) doInit() error {
 		return err
 	}
 	c.fsm = fsm
+	c.initialized = true
+	return nil
+}
+
+// doInitAll initializes c and all of its sub commands, recursively
+func (c *Cmd) doInitAll() error {
+	if err := c.doInit(); err != nil {
+		return err
+	}
+	for _, sub := range c.commands {
+		if err := sub.doInitAll(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// overrideErrorHandling sets the error handling policy of c and all of its (already initialized) sub commands,
+// and returns a function which restores their previous policies
+func (c *Cmd) overrideErrorHandling(errorHandling flag.ErrorHandling) func() {
+	old := c.ErrorHandling
+	c.ErrorHandling = errorHandling
+
+	restores := []func(){}
+	for _, sub := range c.commands {
+		restores = append(restores, sub.overrideErrorHandling(errorHandling))
+	}
+
+	return func() {
+		c.ErrorHandling = old
+		for _, restore := range restores {
+			restore()
+		}
+	}
+}
+
 func (c *Cmd) onError(err error) {
 	if err != nil {
 		switch c.ErrorHandling {
@@ -226,6 +480,20 @@ func (c *Cmd) onError(err error) {
 	}
 }
 
+/*
+Dir sets the function returning the directory to change the working directory to before running the command action.
+It is called after the call arguments got parsed, so it can return the value of an option, e.g.:
+
+	dir := cmd.StringOpt("C", "", "run in this directory")
+	cmd.Dir(func() string { return *dir })
+
+If the function returns an empty string, the working directory is left untouched.
+Otherwise, the previous working directory is restored after the action is run, even if it panics or calls Exit
+*/
+func (c *Cmd) Dir(dir func() string) {
+	c.dir = dir
+}
+
 /*
 PrintHelp prints the command's help message.
 In most cases the library users won't need to call this method, unless
@@ -244,20 +512,173 @@ func (c *Cmd) PrintLongHelp() {
 	c.printHelp(true)
 }
 
-func (c *Cmd) printHelp(longDesc bool) {
+/*
+UsageLine returns the command's usage line, as shown at the top of its help message, e.g.:
+
+	Usage: docker run [OPTIONS] IMAGE [COMMAND [ARG...]]
+
+It can be used when the full help message would be too long, e.g. in error messages.
+It is meant to be called once the app is run, e.g. in an Action, as the default spec is only computed at that time
+*/
+func (c *Cmd) UsageLine() string {
+	return "Usage: " + c.synopsis()
+}
+
+/*
+Deprecated marks the command as deprecated: it is no longer listed in its parent's help message,
+and running it prints a warning with the passed message, e.g. pointing to its replacement, before running it as usual:
+
+	cmd.Deprecated("use 'app db migrate' instead")
+*/
+func (c *Cmd) Deprecated(message string) {
+	c.deprecated = message
+}
+
+// reset initializes the options and arguments of the command and its sub commands like when they were declared
+func (c *Cmd) reset() {
+	for _, opt := range c.options {
+		opt.reset()
+	}
+	for _, arg := range c.args {
+		arg.reset()
+	}
+	if c.passthrough != nil {
+		*c.passthrough = []string{}
+	}
+	for _, source := range c.valueSources {
+		if cached, ok := source.(cachedValueSource); ok {
+			cached.reset()
+		}
+	}
+	for _, sub := range c.commands {
+		sub.reset()
+	}
+}
+
+// valueOf returns the current value of the option or argument named name, the options being looked up with or without their dashes.
+// It reads the parsed value in place using the options and arguments indexes, so it takes constant time whatever the number of options
+func (c *Cmd) valueOf(name string) (interface{}, bool) {
+	if arg, found := c.argsIdx[name]; found {
+		return arg.get(), true
+	}
+	if opt, found := c.optionsIdx[name]; found {
+		return opt.get(), true
+	}
+	prefix := "--"
+	if len(name) == 1 {
+		prefix = "-"
+	}
+	if opt, found := c.optionsIdx[prefix+name]; found {
+		return opt.get(), true
+	}
+	return nil, false
+}
+
+// warn reports a warning to the warning handler set using OnWarning, or else prints it to stderr
+func (c *Cmd) warn(msg string) {
+	if c.onWarning != nil {
+		c.onWarning(msg)
+		return
+	}
+	fmt.Fprintf(stdErr, "Warning: %s\n", msg)
+}
+
+/*
+Path returns the names of the command and its parents, starting with the app name, e.g.:
+
+	[]string{"app", "db", "migrate"}
+
+It is meant to be called once the app is run, e.g. in an Action or a Before interceptor to log the command being run
+*/
+func (c *Cmd) Path() []string {
+	return append(append([]string{}, c.parents...), c.name)
+}
+
+// synopsis returns the command path followed by its spec, its ExactlyOne groups as alternations, e.g. `(--file | --url)`,
+// and a placeholder for the sub commands, if any
+func (c *Cmd) synopsis() string {
 	full := append(c.parents, c.name)
-	path := strings.Join(full, " ")
-	fmt.Fprintf(stdErr, "\nUsage: %s", path)
+	res := strings.Join(full, " ")
 
 	spec := strings.TrimSpace(c.Spec)
 	if len(spec) > 0 {
-		fmt.Fprintf(stdErr, " %s", spec)
+		res += " " + c.annotateCounts(spec)
+	}
+
+	for _, opts := range c.exactlyOnes {
+		res += " (" + optNames(opts) + ")"
 	}
 
 	if len(c.commands) > 0 {
-		fmt.Fprint(stdErr, " COMMAND [arg...]")
+		res += " COMMAND [arg...]"
+	}
+	return res
+}
+
+// annotateCounts adds the count bounds of the repeated arguments in spec, e.g. `FILES... (1-3)`
+func (c *Cmd) annotateCounts(spec string) string {
+	tokens, err := uTokenize(spec)
+	if err != nil {
+		return spec
+	}
+	for i := len(tokens) - 1; i > 0; i-- {
+		if tokens[i].typ != utRep || tokens[i-1].typ != utPos {
+			continue
+		}
+		arg, found := c.argsIdx[tokens[i-1].val]
+		if !found || len(arg.countBounds()) == 0 {
+			continue
+		}
+		end := tokens[i].pos + len(tokens[i].val)
+		spec = fmt.Sprintf("%s (%s)%s", spec[:end], arg.countBounds(), spec[end:])
+	}
+	return spec
+}
+
+// requiredArgs returns the names of the arguments the spec makes mandatory, i.e. neither optional nor part of a choice
+func (c *Cmd) requiredArgs() []string {
+	tokens, err := uTokenize(c.Spec)
+	if err != nil {
+		return nil
+	}
+	type group struct {
+		args     []string
+		choice   bool
+		optional bool
+	}
+	stack := []*group{{}}
+	for _, tk := range tokens {
+		top := stack[len(stack)-1]
+		switch tk.typ {
+		case utOpenPar:
+			stack = append(stack, &group{})
+		case utOpenSq:
+			stack = append(stack, &group{optional: true})
+		case utClosePar, utCloseSq:
+			if len(stack) == 1 {
+				return nil
+			}
+			stack = stack[:len(stack)-1]
+			if !top.optional && !top.choice {
+				parent := stack[len(stack)-1]
+				parent.args = append(parent.args, top.args...)
+			}
+		case utChoice:
+			top.choice = true
+		case utPos:
+			top.args = append(top.args, tk.val)
+		}
+	}
+	if stack[0].choice {
+		return nil
 	}
-	fmt.Fprint(stdErr, "\n\n")
+	return stack[0].args
+}
+
+func (c *Cmd) writeHelp(longDesc bool) {
+	full := append(c.parents, c.name)
+	path := strings.Join(full, " ")
+	fmt.Fprintf(stdErr, "\n%s\n\n", c.UsageLine())
 
 	desc := c.desc
 	if longDesc && len(c.LongDesc) > 0 {
@@ -269,11 +690,11 @@ func (c *Cmd) printHelp(longDesc bool) {
 
 	w := tabwriter.NewWriter(stdErr, 15, 1, 3, ' ', 0)
 
-	if len(c.args) > 0 {
-		fmt.Fprintf(stdErr, "\nArguments:\n")
+	for _, group := range argGroups(c.args) {
+		fmt.Fprintf(stdErr, "\n%s:\n", group.label)
 
-		for _, arg := range c.args {
-			desc := c.formatDescription(arg.desc, arg.envVar)
+		for _, arg := range group.args {
+			desc := c.formatDescription(arg.bounds.describe(describeChoices(arg.desc, arg.choices)), arg.envVar)
 			value := c.formatArgValue(arg)
 
 			fmt.Fprintf(w, "  %s%s\t%s\n", arg.name, value, desc)
@@ -281,52 +702,142 @@ func (c *Cmd) printHelp(longDesc bool) {
 		w.Flush()
 	}
 
-	if len(c.options) > 0 {
+	options := []*opt{}
+	for _, opt := range c.options {
+		if opt.isVisible() {
+			options = append(options, opt)
+		}
+	}
+	if len(options) > 0 {
 		fmt.Fprintf(stdErr, "\nOptions:\n")
 
-		for _, opt := range c.options {
-			desc := c.formatDescription(opt.desc, opt.envVar)
-			value := c.formatOptValue(opt)
-			fmt.Fprintf(w, "  %s%s\t%s\n", strings.Join(opt.names, ", "), value, desc)
+		if c.IndexedHelp {
+			c.writeOptionsIndex(options)
+		} else {
+			for _, opt := range options {
+				desc := c.formatOptDescription(opt)
+				value := c.formatOptValue(opt)
+				fmt.Fprintf(w, "  %s%s\t%s\n", opt.helpNames(), value, desc)
+			}
+			w.Flush()
 		}
-		w.Flush()
 	}
 
 	if len(c.commands) > 0 {
 		fmt.Fprintf(stdErr, "\nCommands:\n")
 
 		for _, c := range c.commands {
+			if c.hidden {
+				continue
+			}
+			// the sub command needs to be initialized to know if it is deprecated
+			if err := c.doInit(); err == nil && len(c.deprecated) > 0 {
+				continue
+			}
 			fmt.Fprintf(w, "  %s\t%s\n", c.name, c.desc)
 		}
 		w.Flush()
 	}
 
+	if len(c.Examples) > 0 {
+		fmt.Fprintf(stdErr, "\nExamples:\n")
+		for _, example := range c.Examples {
+			fmt.Fprintf(stdErr, "  %s %s\n", path, example)
+		}
+	}
+
 	if len(c.commands) > 0 {
 		fmt.Fprintf(stdErr, "\nRun '%s COMMAND --help' for more information on a command.\n", path)
 	}
 }
 
+type argGroup struct {
+	label string
+	args  []*arg
+}
+
+// argGroups splits the arguments by group label, the ungrouped ones first and then the groups in the order they were first used
+func argGroups(args []*arg) []argGroup {
+	res := []argGroup{{label: "Arguments"}}
+	idx := map[string]int{"": 0}
+	for _, arg := range args {
+		i, found := idx[arg.group]
+		if !found {
+			i = len(res)
+			idx[arg.group] = i
+			res = append(res, argGroup{label: arg.group})
+		}
+		res[i].args = append(res[i].args, arg)
+	}
+	if len(res[0].args) == 0 {
+		return res[1:]
+	}
+	return res
+}
+
 func (c *Cmd) formatArgValue(arg *arg) string {
-	if arg.hideValue {
-		return " "
+	kind := fmt.Sprintf(" (%s)", typeName(arg.value.Elem().Type()))
+	if arg.hideValue || c.HideDefaults {
+		return kind
 	}
-	return "=" + arg.helpFormatter(arg.get())
+	return "=" + arg.helpFormatter(arg.get()) + kind
 }
 
 func (c *Cmd) formatOptValue(opt *opt) string {
-	if opt.hideValue {
+	if opt.hideValue || c.HideDefaults || c.hasEnvDefault(opt) {
 		return " "
 	}
-	return "=" + opt.helpFormatter(opt.get())
+	return "=" + opt.helpFormatter(opt.shownValue())
+}
+
+func (c *Cmd) formatOptDescription(opt *opt) string {
+	desc := opt.bounds.describe(describeChoices(opt.desc, opt.allowed))
+	if len(opt.example) > 0 && !opt.hideValue {
+		desc = fmt.Sprintf("%s (e.g. %s)", desc, opt.example)
+	}
+	if hint := c.exactlyOneHint(opt); len(hint) > 0 {
+		desc = fmt.Sprintf("%s %s", desc, hint)
+	}
+	if c.hasEnvDefault(opt) {
+		envVars := []string{}
+		for _, envVar := range envVarNames(opt.envVar) {
+			envVars = append(envVars, "$"+envVar)
+		}
+		return strings.TrimSpace(fmt.Sprintf("%s (default from %s)", desc, strings.Join(envVars, " or ")))
+	}
+	return c.formatDescription(desc, opt.envVar)
+}
+
+// hasEnvDefault returns true if the help message should show that the option's default value comes from its env vars,
+// instead of its empty value, i.e. for an empty string or slice option with env vars
+func (c *Cmd) hasEnvDefault(opt *opt) bool {
+	if opt.hideValue || c.HideDefaults || len(strings.TrimSpace(opt.envVar)) == 0 {
+		return false
+	}
+	v := opt.value.Elem()
+	switch v.Kind() {
+	case reflect.String, reflect.Slice:
+		return v.Len() == 0
+	default:
+		return false
+	}
+}
+
+// describeChoices appends the accepted values, if any, to the description of an option or an argument
+func describeChoices(desc string, choices []string) string {
+	if len(choices) == 0 {
+		return desc
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s (one of %s)", desc, strings.Join(choices, ", ")))
 }
 
 func (c *Cmd) formatDescription(desc, envVar string) string {
 	var b bytes.Buffer
 	b.WriteString(desc)
-	if len(envVar) > 0 {
+	if names := envVarNames(envVar); len(names) > 0 {
 		b.WriteString(" (")
 		sep := ""
-		for _, envVal := range strings.Split(envVar, " ") {
+		for _, envVal := range names {
 			b.WriteString(fmt.Sprintf("%s$%s", sep, envVal))
 			sep = " "
 		}
@@ -336,6 +847,20 @@ func (c *Cmd) formatDescription(desc, envVar string) string {
 }
 
 func (c *Cmd) parse(args []string, entry, inFlow, outFlow *step) error {
+	args, aliasErr := c.expandAliases(args)
+	if aliasErr != nil {
+		c.reportError(aliasErr)
+		c.onError(aliasErr)
+		return aliasErr
+	}
+
+	if err := c.checkDefaultOverrides(); err != nil {
+		err = asParseError(err)
+		c.reportError(err)
+		c.onError(err)
+		return err
+	}
+
 	if c.helpRequested(args) {
 		c.PrintLongHelp()
 		c.onError(nil)
@@ -344,13 +869,45 @@ func (c *Cmd) parse(args []string, entry, inFlow, outFlow *step) error {
 
 	nargsLen := c.getOptsAndArgs(args)
 
-	if err := c.fsm.parse(args[:nargsLen]); err != nil {
-		fmt.Fprintf(stdErr, "Error: %s\n", err.Error())
-		c.PrintHelp()
+	for _, opt := range c.options {
+		opt.choices = nil
+	}
+
+	if err := c.fsm.parse(c.extractPassthrough(args[:nargsLen])); err != nil {
+		c.reportError(err)
+		c.onError(err)
+		return err
+	}
+	c.collectOperations(args[:nargsLen])
+
+	if err := c.applyDefaultOverrides(args[nargsLen:]); err != nil {
+		err = asParseError(err)
+		c.reportError(err)
+		c.onError(err)
+		return err
+	}
+
+	if err := c.resolve(); err != nil {
+		err = asParseError(err)
+		c.reportError(err)
 		c.onError(err)
 		return err
 	}
 
+	c.recordHistory()
+	c.reportIgnored()
+	for _, s := range c.streams {
+		s.start()
+	}
+
+	if shown, err := c.printConfig(); shown {
+		return err
+	}
+
+	if len(c.deprecated) > 0 {
+		c.warn(fmt.Sprintf("command %s is deprecated: %s", strings.Join(c.Path(), " "), c.deprecated))
+	}
+
 	newInFlow := &step{
 		do:    c.Before,
 		error: outFlow,
@@ -368,12 +925,7 @@ func (c *Cmd) parse(args []string, entry, inFlow, outFlow *step) error {
 	args = args[nargsLen:]
 	if len(args) == 0 {
 		if c.Action != nil {
-			newInFlow.success = &step{
-				do:      c.Action,
-				success: newOutFlow,
-				error:   newOutFlow,
-				desc:    fmt.Sprintf("%s.Action", c.name),
-			}
+			newInFlow.success = c.actionFlow(newOutFlow)
 
 			entry.run(nil)
 			return nil
@@ -389,25 +941,159 @@ func (c *Cmd) parse(args []string, entry, inFlow, outFlow *step) error {
 			if err := sub.doInit(); err != nil {
 				panic(err)
 			}
+			sub.onWarning = c.onWarning
+			sub.jsonErrors = c.jsonErrors
+			sub.pager = c.pager
+			sub.history = c.history
+			sub.profile = c.profile
+			sub.summary = c.summary
+			sub.ancestors = append(append([]*Cmd{}, c.ancestors...), c)
 			return sub.parse(args[1:], entry, newInFlow, newOutFlow)
 		}
 	}
 
-	var err error
+	var err *ParseError
 	switch {
 	case strings.HasPrefix(arg, "-"):
-		err = fmt.Errorf("Error: illegal option %s", arg)
-		fmt.Fprintln(stdErr, err.Error())
+		err = &ParseError{Kind: ErrorKindUnknownOption, Token: arg, Message: fmt.Sprintf("illegal option %s", arg)}
+	case len(c.commands) > 0:
+		err = &ParseError{Kind: ErrorKindUnknownCommand, Token: arg, Message: fmt.Sprintf("illegal input %s", arg)}
 	default:
-		err = fmt.Errorf("Error: illegal input %s", arg)
-		fmt.Fprintln(stdErr, err.Error())
+		err = &ParseError{Kind: ErrorKindIncorrectUsage, Token: arg, Message: fmt.Sprintf("illegal input %s", arg)}
 	}
-	c.PrintHelp()
+	c.reportError(err)
 	c.onError(err)
 	return err
 
 }
 
+// resolve completes and checks the options and arguments values once the call arguments are parsed
+func (c *Cmd) resolve() error {
+	if err := c.applyConfigFile(); err != nil {
+		return err
+	}
+	if err := c.applyJSONFlags(); err != nil {
+		return err
+	}
+	if err := c.applyValueSources(); err != nil {
+		return err
+	}
+	if err := c.applyImplications(); err != nil {
+		return err
+	}
+	if err := c.applyEnumFlags(); err != nil {
+		return err
+	}
+	for _, opt := range c.options {
+		if err := opt.execEnvValue(); err != nil {
+			return err
+		}
+	}
+	if err := c.applyInterpolation(); err != nil {
+		return err
+	}
+	if err := c.checkOrderings(); err != nil {
+		return err
+	}
+	if err := c.checkExactlyOnes(); err != nil {
+		return err
+	}
+	for _, opt := range c.options {
+		if err := opt.checkEnvRequired(); err != nil {
+			return err
+		}
+		if err := opt.checkEnvBounds(); err != nil {
+			return err
+		}
+		if err := opt.checkEnvChoice(); err != nil {
+			return err
+		}
+		if err := opt.checkEnvPattern(); err != nil {
+			return err
+		}
+		if c.profile != Strict {
+			continue
+		}
+		if err := opt.checkEnvValue(); err != nil {
+			return err
+		}
+	}
+	for _, opt := range c.options {
+		if opt.source == SourceEnv {
+			opt.notifySet()
+		}
+	}
+	for _, arg := range c.args {
+		if err := arg.checkCount(); err != nil {
+			return err
+		}
+		if err := arg.checkEnvElems(); err != nil {
+			return err
+		}
+		if err := arg.checkEnvStrict(); err != nil {
+			return err
+		}
+		if err := arg.checkEnvBounds(); err != nil {
+			return err
+		}
+		if err := arg.checkEnvChoice(); err != nil {
+			return err
+		}
+		if err := arg.checkEnvPattern(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// actionFlow returns the steps which run the command action, preceded by a change of the working directory if one was configured using Dir
+func (c *Cmd) actionFlow(outFlow *step) *step {
+	action := &step{
+		do:      c.Action,
+		success: outFlow,
+		error:   outFlow,
+		desc:    fmt.Sprintf("%s.Action", c.name),
+	}
+	if c.dir == nil {
+		return action
+	}
+
+	var wd string
+	restore := &step{
+		do: func() {
+			if len(wd) > 0 {
+				os.Chdir(wd)
+			}
+		},
+		success: outFlow,
+		error:   outFlow,
+		desc:    fmt.Sprintf("%s.RestoreDir", c.name),
+	}
+	action.success = restore
+	action.error = restore
+
+	return &step{
+		do: func() {
+			dir := c.dir()
+			if len(dir) == 0 {
+				return
+			}
+			cwd, err := os.Getwd()
+			if err == nil {
+				err = os.Chdir(dir)
+			}
+			if err != nil {
+				fmt.Fprintf(stdErr, "Error: %s\n", err.Error())
+				Exit(1)
+			}
+			wd = cwd
+		},
+		success: action,
+		error:   outFlow,
+		desc:    fmt.Sprintf("%s.Dir", c.name),
+	}
+}
+
 func (c *Cmd) isArgSet(args []string, searchArgs []string) bool {
 	for _, arg := range args {
 		for _, sub := range c.commands {
@@ -425,7 +1111,34 @@ func (c *Cmd) isArgSet(args []string, searchArgs []string) bool {
 }
 
 func (c *Cmd) helpRequested(args []string) bool {
-	return c.isArgSet(args, []string{"-h", "--help"})
+	return c.isArgSet(args, c.callNames([]string{"-h", "--help"}))
+}
+
+// callNames returns the different forms the option names can take in the call arguments
+func (c *Cmd) callNames(names []string) []string {
+	res := append([]string{}, names...)
+	if c.SingleDashLongOpts {
+		for _, name := range names {
+			if strings.HasPrefix(name, "--") {
+				res = append(res, name[1:])
+			}
+		}
+	}
+	return res
+}
+
+// firstNonOption returns the first argument which is neither an option nor the value of the preceding option
+func (c *Cmd) firstNonOption(args []string) (string, bool) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			return arg, true
+		}
+		if o, found := c.optionsIdx[arg]; found && !o.isFlag() {
+			i++
+		}
+	}
+	return "", false
 }
 
 func (c *Cmd) getOptsAndArgs(args []string) int {
@@ -0,0 +1,229 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// completionIndex describes the options and sub commands of a command, as needed to complete the call arguments
+type completionIndex struct {
+	Name     string             `json:"name"`
+	Options  []completionOption `json:"options,omitempty"`
+	Commands []*completionIndex `json:"commands,omitempty"`
+	// the positional arguments, in their declaration order, which are only completed by Complete
+	args []*arg
+}
+
+// completionOption describes an option of a completionIndex, Value being true if it expects a value in the call arguments
+type completionOption struct {
+	Names []string `json:"names"`
+	Value bool     `json:"value,omitempty"`
+}
+
+/*
+WriteCompletionIndex writes to w a JSON index of the app's commands and options, e.g. to be read by a generic completion shim
+instead of generating a shell script for the whole command tree:
+
+	{"name": "app", "options": [{"names": ["-v", "--verbose"]}], "commands": [{"name": "build", ...}]}
+
+The hidden and deprecated commands, and the options declared with NoComplete, are not indexed.
+An option expecting a value in the call arguments has its "value" field set to true.
+*/
+func (cli *Cli) WriteCompletionIndex(w io.Writer) error {
+	if err := cli.doInitAll(); err != nil {
+		return err
+	}
+	enc, err := json.Marshal(newCompletionIndex(cli.Cmd))
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(enc, '\n'))
+	return err
+}
+
+func newCompletionIndex(c *Cmd) *completionIndex {
+	res := &completionIndex{Name: c.name, args: c.args}
+	for _, o := range c.options {
+		if o.noComplete {
+			continue
+		}
+		names := append(append([]string{}, o.names...), o.negatedNames...)
+		res.Options = append(res.Options, completionOption{Names: names, Value: !o.isFlag()})
+	}
+	for _, sub := range c.commands {
+		if sub.hidden || len(sub.deprecated) > 0 {
+			continue
+		}
+		res.Commands = append(res.Commands, newCompletionIndex(sub))
+	}
+	return res
+}
+
+/*
+Complete returns the candidates for the last of words, the call arguments following the app name up to the one being completed (possibly empty), e.g.:
+
+	app.Complete([]string{"remote", "--verb"}) // []string{"--verbose"}
+	app.Complete([]string{"remote", ""})       // []string{"add", "remove"}
+
+The options of the selected command, except the ones declared with NoComplete, are proposed for a word starting with a dash,
+and otherwise its sub commands, and the candidates of the positional argument at the word position, from its Complete function (e.g. CompleteFiles) or its choices.
+There are no candidates for the value of an option.
+
+Apps having commands also get the hidden `__complete` command calling this method and printing the candidates one per line, e.g. for a shell completion shim:
+
+	$ app __complete -- remote --verb
+	--verbose
+*/
+func (cli *Cli) Complete(words []string) []string {
+	if err := cli.doInitAll(); err != nil {
+		panic(err)
+	}
+	return newCompletionIndex(cli.Cmd).complete(words)
+}
+
+func (idx *completionIndex) complete(words []string) []string {
+	res := []string{}
+	if len(words) == 0 {
+		return res
+	}
+	current := words[len(words)-1]
+
+	// the number of positional arguments of the selected command preceding the current word, and whether the options ended with `--`
+	positional, noOpts := 0, false
+	for i := 0; i < len(words)-1; i++ {
+		word := words[i]
+		switch {
+		case noOpts:
+			positional++
+		case word == "--":
+			noOpts = true
+		case idx.command(word) != nil:
+			idx = idx.command(word)
+			positional = 0
+		case strings.HasPrefix(word, "-"):
+			if opt := idx.option(word); opt != nil && opt.Value {
+				if i == len(words)-2 {
+					return res
+				}
+				i++
+			}
+		default:
+			positional++
+		}
+	}
+
+	if strings.HasPrefix(current, "-") && !noOpts {
+		for _, opt := range idx.Options {
+			for _, name := range opt.Names {
+				if strings.HasPrefix(name, current) {
+					res = append(res, name)
+				}
+			}
+		}
+		return res
+	}
+	if !noOpts {
+		for _, sub := range idx.Commands {
+			if strings.HasPrefix(sub.Name, current) {
+				res = append(res, sub.Name)
+			}
+		}
+	}
+	return append(res, idx.completeArg(positional, current)...)
+}
+
+// completeArg returns the candidates for the value of the positional argument at the index pos starting with prefix,
+// using its Complete function, or else its choices.
+// The last argument accepting multiple values takes all the values in excess
+func (idx *completionIndex) completeArg(pos int, prefix string) []string {
+	if len(idx.args) == 0 {
+		return nil
+	}
+	if pos >= len(idx.args) {
+		if last := idx.args[len(idx.args)-1]; !last.isMulti() {
+			return nil
+		}
+		pos = len(idx.args) - 1
+	}
+	a := idx.args[pos]
+	if a.complete != nil {
+		return a.complete(prefix)
+	}
+	res := []string{}
+	for _, choice := range a.choices {
+		if strings.HasPrefix(choice, prefix) {
+			res = append(res, choice)
+		}
+	}
+	return res
+}
+
+/*
+CompleteFiles returns the paths of the files and directories starting with prefix, the directories ending with a slash,
+e.g. to be used as the Complete function of an argument accepting a file path:
+
+	cmd.String(StringArg{Name: "FILE", Complete: CompleteFiles})
+
+The hidden files are only returned when the prefix of their name starts with a dot
+*/
+func CompleteFiles(prefix string) []string {
+	res := []string{}
+	dir, base := filepath.Split(prefix)
+	readDir := dir
+	if len(readDir) == 0 {
+		readDir = "."
+	}
+	infos, err := ioutil.ReadDir(readDir)
+	if err != nil {
+		return res
+	}
+	for _, info := range infos {
+		name := info.Name()
+		if !strings.HasPrefix(name, base) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".")) {
+			continue
+		}
+		if info.IsDir() {
+			name += string(filepath.Separator)
+		}
+		res = append(res, dir+name)
+	}
+	return res
+}
+
+func (idx *completionIndex) command(name string) *completionIndex {
+	for _, sub := range idx.Commands {
+		if sub.Name == name {
+			return sub
+		}
+	}
+	return nil
+}
+
+func (idx *completionIndex) option(name string) *completionOption {
+	for i, opt := range idx.Options {
+		for _, n := range opt.Names {
+			if n == name {
+				return &idx.Options[i]
+			}
+		}
+	}
+	return nil
+}
+
+// addCompleteCommand adds the hidden `__complete` command printing the candidates returned by Complete
+func (cli *Cli) addCompleteCommand() {
+	cli.Command("__complete", "Print the completion candidates of the call arguments", func(cmd *Cmd) {
+		cmd.Spec = "[WORDS...]"
+		words := cmd.StringsArg("WORDS", nil, "The call arguments up to the one being completed")
+		cmd.Action = func() {
+			for _, candidate := range cli.Complete(*words) {
+				fmt.Fprintln(stdOut, candidate)
+			}
+		}
+	})
+	cli.commands[len(cli.commands)-1].hidden = true
+}
@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func mkCompletionApp() *Cli {
+	app := App("app", "")
+	app.BoolOpt("v verbose", false, "")
+	app.StringOpt("c config", "", "")
+	app.Bool(BoolOpt{Name: "d debug", NoComplete: true})
+	app.Command("remote", "", func(cmd *Cmd) {
+		cmd.IntOpt("timeout", 0, "")
+		cmd.BoolOpt("f force", false, "")
+		cmd.Command("add", "", func(sub *Cmd) {
+			sub.StringOpt("b branch", "", "")
+			sub.StringArg("NAME", "", "")
+		})
+		cmd.Command("remove", "", ActionCommand(func() {}))
+		cmd.Command("rename", "", ActionCommand(func() {}))
+	})
+	app.Command("release", "", ActionCommand(func() {}))
+	app.Command("old", "", func(cmd *Cmd) {
+		cmd.Deprecated("use release")
+	})
+	return app
+}
+
+func TestComplete(t *testing.T) {
+	cases := []struct {
+		words    []string
+		expected []string
+	}{
+		{[]string{}, []string{}},
+		{[]string{""}, []string{"remote", "release"}},
+		{[]string{"re"}, []string{"remote", "release"}},
+		{[]string{"rem"}, []string{"remote"}},
+		{[]string{"x"}, []string{}},
+		{[]string{"-"}, []string{"-v", "--verbose", "--no-verbose", "-c", "--config"}},
+		{[]string{"--v"}, []string{"--verbose"}},
+		{[]string{"--no"}, []string{"--no-verbose"}},
+		{[]string{"--de"}, []string{}},
+		{[]string{"--no-de"}, []string{}},
+		{[]string{"-v", "rel"}, []string{"release"}},
+		{[]string{"--config", ""}, []string{}},
+		{[]string{"--config", "remote", ""}, []string{"remote", "release"}},
+		{[]string{"--config=x", "remote", ""}, []string{"add", "remove", "rename"}},
+		{[]string{"remote", "re"}, []string{"remove", "rename"}},
+		{[]string{"remote", "--"}, []string{"--timeout", "--force", "--no-force"}},
+		{[]string{"remote", "--timeout", "3", "a"}, []string{"add"}},
+		{[]string{"remote", "-f", "add", "--b"}, []string{"--branch"}},
+		{[]string{"remote", "add", "origin", ""}, []string{}},
+	}
+
+	app := mkCompletionApp()
+	app.addBuiltinCommands()
+	for _, cas := range cases {
+		require.Equal(t, cas.expected, app.Complete(cas.words), "%q", cas.words)
+	}
+}
+
+func TestCompleteArgs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mow-cli-complete")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"app.json", "app.yaml", "other.txt", ".hidden"} {
+		require.Nil(t, ioutil.WriteFile(filepath.Join(dir, name), nil, 0600))
+	}
+	require.Nil(t, os.Mkdir(filepath.Join(dir, "apps"), 0700))
+	prefix := dir + string(filepath.Separator)
+
+	app := App("app", "")
+	app.Command("open", "", func(cmd *Cmd) {
+		cmd.Spec = "[-f] FILE MODE [TAGS...]"
+		cmd.BoolOpt("f force", false, "")
+		cmd.String(StringArg{Name: "FILE", Complete: CompleteFiles})
+		cmd.String(StringArg{Name: "MODE", Choices: []string{"read", "write", "append"}})
+		cmd.Strings(StringsArg{Name: "TAGS", Complete: func(prefix string) []string {
+			return []string{prefix + "-tag"}
+		}})
+	})
+
+	cases := []struct {
+		words    []string
+		expected []string
+	}{
+		{[]string{"open", prefix + "app"}, []string{prefix + "app.json", prefix + "app.yaml", prefix + "apps" + string(filepath.Separator)}},
+		{[]string{"open", prefix + "o"}, []string{prefix + "other.txt"}},
+		{[]string{"open", prefix + "."}, []string{prefix + ".hidden"}},
+		{[]string{"open", prefix + "x"}, []string{}},
+		{[]string{"open", "-f", prefix + "app.j"}, []string{prefix + "app.json"}},
+		{[]string{"open", "--", prefix + "app.j"}, []string{prefix + "app.json"}},
+		{[]string{"open", "a.txt", ""}, []string{"read", "write", "append"}},
+		{[]string{"open", "a.txt", "-f", "w"}, []string{"write"}},
+		{[]string{"open", "a.txt", "read", "x"}, []string{"x-tag"}},
+		{[]string{"open", "a.txt", "read", "x", "y"}, []string{"y-tag"}},
+		{[]string{"open", "a.txt", "-"}, []string{"-f", "--force", "--no-force"}},
+	}
+
+	for _, cas := range cases {
+		require.Equal(t, cas.expected, app.Complete(cas.words), "%q", cas.words)
+	}
+}
+
+func TestCompleteCommand(t *testing.T) {
+	defer exitShouldNotCalled(t)()
+
+	cases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"app", "__complete", "--", "remote", "--timeout", "3", "-"}, "--timeout\n-f\n--force\n--no-force\n"},
+		{[]string{"app", "__complete", "remote", ""}, "add\nremove\nrename\n"},
+		{[]string{"app", "__complete", "--", "--config", ""}, ""},
+		{[]string{"app", "--debug", "__complete", "--", "-d"}, ""},
+	}
+
+	for _, cas := range cases {
+		var out string
+		func() {
+			defer captureAndRestoreOutput(&out, nil)()
+			require.Nil(t, mkCompletionApp().Run(cas.args))
+		}()
+		require.Equal(t, cas.expected, out, "%q", cas.args)
+	}
+}
+
+func TestWriteCompletionIndex(t *testing.T) {
+	app := mkCompletionApp()
+	app.addBuiltinCommands()
+
+	var buf bytes.Buffer
+	require.Nil(t, app.WriteCompletionIndex(&buf))
+
+	var idx completionIndex
+	require.Nil(t, json.Unmarshal(buf.Bytes(), &idx))
+	require.Equal(t, "app", idx.Name)
+	require.Equal(t, []completionOption{{Names: []string{"-v", "--verbose", "--no-verbose"}}, {Names: []string{"-c", "--config"}, Value: true}}, idx.Options,
+		"the options declared with NoComplete should not be indexed")
+	require.Len(t, idx.Commands, 2, "the hidden and deprecated commands should not be indexed")
+	require.Equal(t, "remote", idx.Commands[0].Name)
+	require.Equal(t, []string{"add", "remove", "rename"}, []string{idx.Commands[0].Commands[0].Name, idx.Commands[0].Commands[1].Name, idx.Commands[0].Commands[2].Name})
+	require.Equal(t, []completionOption{{Names: []string{"--timeout"}, Value: true}, {Names: []string{"-f", "--force", "--no-force"}}}, idx.Commands[0].Options)
+}
@@ -0,0 +1,178 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+/*
+ConfigDecoder decodes the content of a config file into a map of the options names (without the dashes) to their values.
+The values can be strings, numbers, booleans or slices of those for the slice options
+*/
+type ConfigDecoder func(data []byte) (map[string]interface{}, error)
+
+var configDecoders = map[string]ConfigDecoder{
+	".json": decodeJSONConfig,
+	".yaml": decodeYAMLConfig,
+	".yml":  decodeYAMLConfig,
+	".toml": decodeTOMLConfig,
+}
+
+/*
+RegisterConfigFormat registers the decoder to be used for the config files with the given extensions, e.g. to support the full YAML syntax:
+
+	cli.RegisterConfigFormat(func(data []byte) (map[string]interface{}, error) {
+		var res map[string]interface{}
+		err := yaml.Unmarshal(data, &res)
+		return res, err
+	}, ".yaml", ".yml")
+
+The JSON (.json), YAML (.yaml and .yml) and TOML (.toml) formats are supported out of the box,
+the YAML and TOML decoders being limited to flat documents mapping the options names to scalars or to lists of scalars.
+*/
+func RegisterConfigFormat(decoder ConfigDecoder, exts ...string) {
+	for _, ext := range exts {
+		configDecoders[strings.ToLower(ext)] = decoder
+	}
+}
+
+func decodeJSONConfig(data []byte) (map[string]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var res map[string]interface{}
+	err := dec.Decode(&res)
+	return res, err
+}
+
+/*
+ConfigFile adds an option named `name` to the command which accepts the path of a config file used to set the values of the command's other options, e.g.:
+
+	app.ConfigFile("config")
+
+	$ app --config app.json
+
+The config file format is detected from its extension (see RegisterConfigFormat), an unknown extension being reported as a usage error.
+The config keys are the option names without the dashes.
+
+The options explicitly set in the call arguments, or by a JSON object (see FlagsFromJSON), take precedence over the config file values,
+which in turn take precedence over the environment variables and the initial values.
+*/
+func (c *Cmd) ConfigFile(name string) {
+	c.String(StringOpt{
+		Name:      name,
+		Value:     "",
+		Desc:      "Load the options values from a config file",
+		HideValue: true,
+	})
+	names := mkOptStrs(name)
+	c.configFile = c.optionsIdx[names[0]]
+}
+
+// configFileRef is a config file added by ConfigFiles or RequiredConfigFiles
+type configFileRef struct {
+	path     string
+	required bool
+}
+
+/*
+ConfigFiles adds config files to be read in order to set the values of the command's options, the values of a file overriding those of the previous ones,
+e.g. from the most general to the most specific:
+
+	app.ConfigFiles("/etc/app.json", filepath.Join(home, ".app.json"), "app.json")
+
+The missing files are skipped silently, unless added by RequiredConfigFiles. The file passed to the option added by ConfigFile, if any, is required,
+and its values override those of all these files.
+
+The values precedence is otherwise the same as with ConfigFile
+*/
+func (c *Cmd) ConfigFiles(paths ...string) {
+	for _, path := range paths {
+		c.configFiles = append(c.configFiles, configFileRef{path: path})
+	}
+}
+
+/*
+RequiredConfigFiles adds config files like ConfigFiles, but which must exist, a missing one being reported as a usage error, e.g.:
+
+	app.RequiredConfigFiles("/etc/app.json")
+	app.ConfigFiles(filepath.Join(home, ".app.json"))
+
+The files added by ConfigFiles and RequiredConfigFiles are read in the order they were added
+*/
+func (c *Cmd) RequiredConfigFiles(paths ...string) {
+	for _, path := range paths {
+		c.configFiles = append(c.configFiles, configFileRef{path: path, required: true})
+	}
+}
+
+func (c *Cmd) applyConfigFile() error {
+	values := map[string]interface{}{}
+	origins := map[string]string{}
+	merge := func(path string, fileValues map[string]interface{}) {
+		for key, v := range fileValues {
+			values[key] = v
+			origins[key] = path
+		}
+	}
+
+	for _, ref := range c.configFiles {
+		if _, err := os.Stat(ref.path); os.IsNotExist(err) {
+			if ref.required {
+				return fmt.Errorf("missing required config file %s", ref.path)
+			}
+			continue
+		}
+		fileValues, err := decodeConfigFile(ref.path, fmt.Sprintf("config file %s", ref.path))
+		if err != nil {
+			return err
+		}
+		merge(ref.path, fileValues)
+	}
+
+	if c.configFile != nil {
+		if path, _ := c.configFile.get().(string); len(path) > 0 {
+			fileValues, err := decodeConfigFile(path, fmt.Sprintf("option %s", c.configFile.displayName()))
+			if err != nil {
+				return err
+			}
+			merge(path, fileValues)
+		}
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := c.applyValues(map[string]interface{}{key: values[key]}, SourceConfig, fmt.Sprintf("config file %s", origins[key])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeConfigFile reads and decodes the config file at path, owner describing where the path comes from in the errors, e.g. `option --config`
+func decodeConfigFile(path, owner string) (map[string]interface{}, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	decoder, found := configDecoders[ext]
+	if !found {
+		return nil, fmt.Errorf("unsupported config file format %q for %s", ext, owner)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	values, err := decoder(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+	return values, nil
+}
@@ -0,0 +1,170 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// decodeYAMLConfig decodes a flat YAML document, whose keys are the options names and whose values are scalars,
+// or lists of scalars written either as a flow sequence (`tag: [a, b]`) or as a block sequence (`- a` lines following `tag:`).
+// A key without a value nor items is null, and is left out of the result
+func decodeYAMLConfig(data []byte) (map[string]interface{}, error) {
+	res := map[string]interface{}{}
+	// the key of the block sequence being read, if any
+	list := ""
+	for n, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(stripConfigComment(line), " \t\r")
+		trimmed := strings.TrimSpace(line)
+		if len(trimmed) == 0 || trimmed == "---" {
+			continue
+		}
+
+		if trimmed == "-" || strings.HasPrefix(trimmed, "- ") {
+			if len(list) == 0 {
+				return nil, fmt.Errorf("line %d: unexpected list item %q", n+1, trimmed)
+			}
+			v, err := decodeConfigScalar(strings.TrimSpace(trimmed[1:]))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", n+1, err)
+			}
+			items, _ := res[list].([]interface{})
+			res[list] = append(items, v)
+			continue
+		}
+		if trimmed != line {
+			return nil, fmt.Errorf("line %d: nested values are not supported", n+1)
+		}
+
+		kv := strings.SplitN(line, ":", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("line %d: expected a key: value pair, got %q", n+1, line)
+		}
+		key, raw := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		list = ""
+		switch raw {
+		case "":
+			list = key
+		case "~", "null":
+		default:
+			v, err := decodeConfigValue(raw)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", n+1, err)
+			}
+			res[key] = v
+		}
+	}
+	return res, nil
+}
+
+// decodeTOMLConfig decodes a flat TOML document, whose keys are the options names and whose values are scalars,
+// or single line arrays of scalars, e.g. `tag = ["a", "b"]`. The tables are not supported
+func decodeTOMLConfig(data []byte) (map[string]interface{}, error) {
+	res := map[string]interface{}{}
+	for n, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(stripConfigComment(line))
+		if len(line) == 0 {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			return nil, fmt.Errorf("line %d: tables are not supported", n+1)
+		}
+
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("line %d: expected a key = value pair, got %q", n+1, line)
+		}
+		key, err := decodeConfigScalar(strings.TrimSpace(kv[0]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n+1, err)
+		}
+		v, err := decodeConfigValue(strings.TrimSpace(kv[1]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n+1, err)
+		}
+		res[key] = v
+	}
+	return res, nil
+}
+
+// stripConfigComment removes the comment ending line, starting with a `#` outside of the quoted strings, at the start of the line or after a space
+func stripConfigComment(line string) string {
+	quote := rune(0)
+	for i, c := range line {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// decodeConfigValue decodes raw as a list of scalars if enclosed in brackets, e.g. `[a, "b"]`, or else as a scalar
+func decodeConfigValue(raw string) (interface{}, error) {
+	if !strings.HasPrefix(raw, "[") {
+		return decodeConfigScalar(raw)
+	}
+	if !strings.HasSuffix(raw, "]") {
+		return nil, fmt.Errorf("unterminated list %s", raw)
+	}
+	res := []interface{}{}
+	inner := strings.TrimSpace(raw[1 : len(raw)-1])
+	if len(inner) == 0 {
+		return res, nil
+	}
+
+	start, quote := 0, rune(0)
+	items := []string{}
+	for i, c := range inner {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ',':
+			items = append(items, inner[start:i])
+			start = i + 1
+		}
+	}
+	// a trailing comma is allowed
+	if last := strings.TrimSpace(inner[start:]); len(last) > 0 {
+		items = append(items, last)
+	}
+
+	for _, item := range items {
+		v, err := decodeConfigScalar(strings.TrimSpace(item))
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, v)
+	}
+	return res, nil
+}
+
+// decodeConfigScalar decodes raw as a string, unquoting it if needed.
+// The numbers and booleans are kept as strings, to be parsed by the options they are set to
+func decodeConfigScalar(raw string) (string, error) {
+	switch {
+	case strings.HasPrefix(raw, `"`):
+		s, err := strconv.Unquote(raw)
+		if err != nil {
+			return "", fmt.Errorf("invalid quoted string %s", raw)
+		}
+		return s, nil
+	case strings.HasPrefix(raw, "'"):
+		if len(raw) < 2 || !strings.HasSuffix(raw, "'") {
+			return "", fmt.Errorf("invalid quoted string %s", raw)
+		}
+		return strings.Replace(raw[1:len(raw)-1], "''", "'", -1), nil
+	default:
+		return raw, nil
+	}
+}
@@ -0,0 +1,243 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigFile(t *testing.T) {
+	defer suppressOutput()()
+
+	dir, err := ioutil.TempDir("", "mow-cli-config")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	writeConfig := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.Nil(t, ioutil.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	var (
+		output *string
+		count  *int
+		tags   *[]string
+	)
+	init := func(app *Cli) {
+		output = app.StringOpt("o output", "out", "")
+		count = app.IntOpt("count", 1, "")
+		tags = app.StringsOpt("t tag", nil, "")
+		app.ConfigFile("c config")
+		app.FlagsFromJSON("flags-from-json")
+	}
+
+	cases := []struct {
+		name    string
+		content string
+	}{
+		{"app.json", `{"output": "x", "count": 3, "tag": ["a", "b"]}`},
+		{"app.yaml", "output: x\ncount: 3\ntag: [a, b]"},
+		{"app.yml", "output: x\ncount: 3\ntag:\n  - a\n  - b"},
+		{"app.toml", "output = \"x\"\ncount = 3\ntag = [\"a\", \"b\"]"},
+		{"APP.YAML", "output: 'x'\ncount: 3\ntag: [\"a\", b]"},
+	}
+
+	for _, cas := range cases {
+		app := testApp(init)
+		err := app.Run([]string{"app", "--config", writeConfig(cas.name, cas.content)})
+		require.Nil(t, err, "config %s", cas.name)
+		require.Equal(t, "x", *output, "config %s", cas.name)
+		require.Equal(t, 3, *count, "config %s", cas.name)
+		require.Equal(t, []string{"a", "b"}, *tags, "config %s", cas.name)
+		require.Equal(t, SourceConfig, app.optionsIdx["--count"].source)
+	}
+
+	path := writeConfig("app.json", `{"output": "x", "count": 3, "tag": ["a", "b"]}`)
+	app := testApp(init)
+	err = app.Run([]string{"app", "-c", path, "--count", "7", "--flags-from-json", `{"output": "y"}`})
+	require.Nil(t, err)
+	require.Equal(t, 7, *count, "the call arguments should override the config file")
+	require.Equal(t, "y", *output, "the JSON flags should override the config file")
+	require.Equal(t, []string{"a", "b"}, *tags)
+
+	badCases := []struct {
+		path string
+		msg  string
+	}{
+		{writeConfig("app.ini", "count = 3"), `unsupported config file format ".ini" for option --config`},
+		{writeConfig("app", "count = 3"), `unsupported config file format "" for option --config`},
+		{filepath.Join(dir, "missing.json"), "open " + filepath.Join(dir, "missing.json")},
+		{writeConfig("bad.json", `{"count": `), "invalid config file " + filepath.Join(dir, "bad.json")},
+		{writeConfig("unknown.json", `{"outptu": "x"}`), "unknown option outptu in config file " + filepath.Join(dir, "unknown.json")},
+		{writeConfig("self.json", `{"config": "x"}`), "unknown option config in config file " + filepath.Join(dir, "self.json")},
+	}
+
+	for _, cas := range badCases {
+		app := testApp(init)
+		err := app.Run([]string{"app", "--config", cas.path})
+		require.NotNil(t, err, "config %s should have failed", cas.path)
+		require.True(t, strings.HasPrefix(err.Error(), cas.msg), "unexpected error %s", err)
+	}
+}
+
+func TestConfigFormats(t *testing.T) {
+	cases := []struct {
+		decoder  ConfigDecoder
+		content  string
+		expected map[string]interface{}
+	}{
+		{decodeYAMLConfig, "", map[string]interface{}{}},
+		{decodeYAMLConfig, "---\n# comment\noutput: a b # comment\ncount: 3\n\nverbose: yes\n", map[string]interface{}{"output": "a b", "count": "3", "verbose": "yes"}},
+		{decodeYAMLConfig, "output: \"a # b\"\nname: 'it''s'\nurl: http://host:80/#x", map[string]interface{}{"output": "a # b", "name": "it's", "url": "http://host:80/#x"}},
+		{decodeYAMLConfig, "tag: [a, \"b, c\", ]\nempty: []", map[string]interface{}{"tag": []interface{}{"a", "b, c"}, "empty": []interface{}{}}},
+		{decodeYAMLConfig, "tag:\n  - a\n  # comment\n  - \"b\"\ncount: 3", map[string]interface{}{"tag": []interface{}{"a", "b"}, "count": "3"}},
+		{decodeYAMLConfig, "output:\ncount: ~\nname: null", map[string]interface{}{}},
+		{decodeTOMLConfig, "# comment\noutput = \"a # b\" # comment\ncount = 3\nverbose = true", map[string]interface{}{"output": "a # b", "count": "3", "verbose": "true"}},
+		{decodeTOMLConfig, "\"tag\" = ['a', \"b\"]\nname = 'c:\\dir'", map[string]interface{}{"tag": []interface{}{"a", "b"}, "name": "c:\\dir"}},
+	}
+
+	for _, cas := range cases {
+		values, err := cas.decoder([]byte(cas.content))
+		require.Nil(t, err, "content %q", cas.content)
+		require.Equal(t, cas.expected, values, "content %q", cas.content)
+	}
+
+	badCases := []struct {
+		decoder ConfigDecoder
+		content string
+		msg     string
+	}{
+		{decodeYAMLConfig, "- a", `line 1: unexpected list item "- a"`},
+		{decodeYAMLConfig, "db:\n  host: x", "line 2: nested values are not supported"},
+		{decodeYAMLConfig, "output", `line 1: expected a key: value pair, got "output"`},
+		{decodeYAMLConfig, "tag: [a, b", "line 1: unterminated list [a, b"},
+		{decodeYAMLConfig, "output: \"x", `line 1: invalid quoted string "x`},
+		{decodeTOMLConfig, "[db]\nhost = \"x\"", "line 1: tables are not supported"},
+		{decodeTOMLConfig, "count = 3\noutput", `line 2: expected a key = value pair, got "output"`},
+		{decodeTOMLConfig, "output = 'x", `line 1: invalid quoted string 'x`},
+	}
+
+	for _, cas := range badCases {
+		_, err := cas.decoder([]byte(cas.content))
+		require.NotNil(t, err, "content %q should have failed", cas.content)
+		require.Equal(t, cas.msg, err.Error())
+	}
+}
+
+func TestConfigBoolValues(t *testing.T) {
+	cases := []struct {
+		value    interface{}
+		expected bool
+	}{
+		{"yes", true},
+		{"Yes", true},
+		{"on", true},
+		{"y", true},
+		{"1", true},
+		{"true", true},
+		{true, true},
+		{json.Number("1"), true},
+		{1, true},
+		{"no", false},
+		{"OFF", false},
+		{"0", false},
+		{"false", false},
+		{false, false},
+		{json.Number("0"), false},
+	}
+
+	for _, cas := range cases {
+		cmd := &Cmd{optionsIdx: map[string]*opt{}}
+		verbose := cmd.BoolOpt("v verbose", !cas.expected, "")
+		require.Nil(t, cmd.applyValues(map[string]interface{}{"verbose": cas.value}, SourceConfig, "config file app.yaml"), "value %v", cas.value)
+		require.Equal(t, cas.expected, *verbose, "value %v", cas.value)
+	}
+
+	cmd := &Cmd{optionsIdx: map[string]*opt{}}
+	cmd.BoolOpt("v verbose", false, "")
+	err := cmd.applyValues(map[string]interface{}{"verbose": "maybe"}, SourceConfig, "config file app.yaml")
+	require.NotNil(t, err)
+	require.Equal(t, `invalid value for option verbose in config file app.yaml: strconv.ParseBool: parsing "maybe": invalid syntax`, err.Error())
+
+	name := cmd.StringOpt("name", "", "")
+	require.Nil(t, cmd.applyValues(map[string]interface{}{"name": "yes"}, SourceConfig, "config file app.yaml"))
+	require.Equal(t, "yes", *name, "only the bool values should be normalized")
+}
+
+func TestConfigFiles(t *testing.T) {
+	defer suppressOutput()()
+
+	dir, err := ioutil.TempDir("", "mow-cli-config")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	writeConfig := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.Nil(t, ioutil.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+	system := writeConfig("system.json", `{"output": "system", "count": 3, "tag": ["a"]}`)
+	user := writeConfig("user.json", `{"output": "user", "tag": ["b", "c"]}`)
+	explicit := writeConfig("explicit.json", `{"count": 5}`)
+	missing := filepath.Join(dir, "missing.json")
+
+	var (
+		output   *string
+		count    *int
+		tags     *[]string
+		paths    []string
+		required []string
+	)
+	init := func(app *Cli) {
+		output = app.StringOpt("o output", "out", "")
+		count = app.IntOpt("count", 1, "")
+		tags = app.StringsOpt("t tag", nil, "")
+		app.ConfigFile("config")
+		app.RequiredConfigFiles(required...)
+		app.ConfigFiles(paths...)
+	}
+
+	paths = []string{system, missing, user}
+	require.Nil(t, runApp(init))
+	require.Equal(t, "user", *output, "the later files should override the earlier ones")
+	require.Equal(t, 3, *count)
+	require.Equal(t, []string{"b", "c"}, *tags)
+
+	paths = []string{system, user}
+	require.Nil(t, runApp(init, "--config", explicit, "-o", "cli"))
+	require.Equal(t, "cli", *output)
+	require.Equal(t, 5, *count, "the config option file should override the config files")
+	require.Equal(t, []string{"b", "c"}, *tags)
+
+	paths = []string{missing}
+	require.Nil(t, runApp(init))
+	require.Equal(t, "out", *output)
+
+	paths = []string{system}
+	err = runApp(init, "--config", missing)
+	require.NotNil(t, err, "the config option file should be required")
+
+	paths, required = []string{user}, []string{system}
+	require.Nil(t, runApp(init))
+	require.Equal(t, "user", *output, "the files should be read in the order they were added")
+	require.Equal(t, 3, *count)
+
+	required = []string{missing}
+	err = runApp(init)
+	require.NotNil(t, err, "a missing required config file should be reported")
+	require.Equal(t, fmt.Sprintf("missing required config file %s", missing), err.Error())
+	required = nil
+
+	bad := writeConfig("bad.json", `{"count": "many"}`)
+	paths = []string{bad, user}
+	err = runApp(init)
+	require.NotNil(t, err)
+	require.Equal(t, fmt.Sprintf(`invalid value for option count in config file %s: strconv.ParseInt: parsing "many": invalid syntax`, bad), err.Error())
+}
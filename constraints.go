@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+type implication struct {
+	option  *opt
+	implied *opt
+}
+
+/*
+Implies declares that setting the option named `option` implies setting the option named `implied`, e.g.:
+
+	debug := cmd.BoolOpt("d debug", false, "debug mode")
+	verbose := cmd.BoolOpt("v verbose", false, "verbose mode")
+	cmd.Implies("debug", "verbose")
+
+After the call arguments got parsed, if the first option was set (and, for a bool option, set to true),
+the implied option is turned on, unless its value was explicitly set, e.g. with `--verbose=false` or from an env variable.
+
+The names are option names *WITHOUT* the dashes, and must refer to options already declared on the command.
+The implied option must be a bool, a counter or an optional value option, i.e. an option which can be turned on without a value.
+*/
+func (c *Cmd) Implies(option, implied string) {
+	o := c.declaredOpt(option)
+	i := c.declaredOpt(implied)
+	if !i.isFlag() {
+		panic(fmt.Sprintf("Option %s cannot be implied as it requires a value", implied))
+	}
+	c.implications = append(c.implications, implication{o, i})
+}
+
+func (c *Cmd) declaredOpt(name string) *opt {
+	res, found := c.optionsIdx[mkOptStrs(name)[0]]
+	if !found {
+		panic(fmt.Sprintf("Undeclared option %s", name))
+	}
+	return res
+}
+
+func (c *Cmd) applyImplications() error {
+	for _, imp := range c.implications {
+		if !imp.option.isOn() || imp.implied.source != SourceDefault {
+			continue
+		}
+		if err := imp.implied.set(imp.implied.flagValue()); err != nil {
+			return err
+		}
+		imp.implied.source = SourceImplied
+	}
+	return nil
+}
+
+/*
+Ordered declares that the values of the numeric options named `options` should be in ascending order, e.g.:
+
+	min := cmd.IntOpt("min", 0, "the minimum")
+	max := cmd.IntOpt("max", 100, "the maximum")
+	cmd.Ordered("min", "max")
+
+After the call arguments got parsed, each option value is checked to be lower than or equal to the value of the next one,
+a value out of order being reported as a usage error.
+
+The names are option names *WITHOUT* the dashes, and must refer to int, float64 (including UnitOpt) or duration options already declared on the command.
+*/
+func (c *Cmd) Ordered(options ...string) {
+	opts := []*opt{}
+	for _, option := range options {
+		o := c.declaredOpt(option)
+		if k := o.value.Elem().Kind(); k != reflect.Int && k != reflect.Float64 && o.value.Elem().Type() != durationType {
+			panic(fmt.Sprintf("Option %s cannot be ordered as it is not numeric", option))
+		}
+		opts = append(opts, o)
+	}
+	c.orderings = append(c.orderings, opts)
+}
+
+func (c *Cmd) checkOrderings() error {
+	for _, opts := range c.orderings {
+		for i := 1; i < len(opts); i++ {
+			lower, upper := opts[i-1], opts[i]
+			if greater(lower.value.Elem(), upper.value.Elem()) {
+				return fmt.Errorf("option %s (%v) should not be greater than option %s (%v)", lower.displayName(), lower.get(), upper.displayName(), upper.get())
+			}
+		}
+	}
+	return nil
+}
+
+// greater returns true if the numeric value a is greater than b, comparing them as floats if either of them is one
+func greater(a, b reflect.Value) bool {
+	if a.Kind() == reflect.Float64 || b.Kind() == reflect.Float64 {
+		return asFloat(a) > asFloat(b)
+	}
+	return a.Int() > b.Int()
+}
+
+func asFloat(v reflect.Value) float64 {
+	switch v.Kind() {
+	case reflect.Float64:
+		return v.Float()
+	default:
+		return float64(v.Int())
+	}
+}
+
+/*
+ExactlyOne declares that exactly one of the options named `options` should be set, e.g.:
+
+	cmd.StringOpt("file", "", "read the input from a file")
+	cmd.StringOpt("url", "", "read the input from an URL")
+	cmd.BoolOpt("stdin", false, "read the input from the standard input")
+	cmd.ExactlyOne("file", "url", "stdin")
+
+After the call arguments got parsed, setting none of the options, or more than one, is reported as a usage error.
+An option is considered set if its value comes from the call arguments, an environment variable, a config file, ... instead of being its initial value.
+The usage line shows the group as an alternation, e.g. `(--file | --url | --stdin)`, and the help message marks each of the options as part of the group.
+
+The names are option names *WITHOUT* the dashes, and must refer to options already declared on the command.
+*/
+func (c *Cmd) ExactlyOne(options ...string) {
+	opts := []*opt{}
+	for _, option := range options {
+		opts = append(opts, c.declaredOpt(option))
+	}
+	c.exactlyOnes = append(c.exactlyOnes, opts)
+}
+
+func (c *Cmd) checkExactlyOnes() error {
+	for _, opts := range c.exactlyOnes {
+		set := []string{}
+		for _, o := range opts {
+			if o.source != SourceDefault {
+				set = append(set, o.displayName())
+			}
+		}
+		switch len(set) {
+		case 1:
+		case 0:
+			return fmt.Errorf("exactly one of the options %s is required", optNames(opts))
+		default:
+			return fmt.Errorf("only one of the options %s can be set, got %s", optNames(opts), strings.Join(set, " and "))
+		}
+	}
+	return nil
+}
+
+// exactlyOneHint returns the help annotation of the option if it belongs to an ExactlyOne group, or an empty string
+func (c *Cmd) exactlyOneHint(o *opt) string {
+	for _, opts := range c.exactlyOnes {
+		for _, member := range opts {
+			if member == o {
+				return fmt.Sprintf("(required: exactly one of %s)", optNames(opts))
+			}
+		}
+	}
+	return ""
+}
+
+func optNames(opts []*opt) string {
+	names := []string{}
+	for _, o := range opts {
+		names = append(names, o.displayName())
+	}
+	return strings.Join(names, " | ")
+}
@@ -0,0 +1,201 @@
+package cli
+
+import (
+	"flag"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImplies(t *testing.T) {
+	var (
+		debug, verbose *bool
+		color          *string
+	)
+	init := func(c *Cmd) {
+		debug = c.BoolOpt("d debug", false, "")
+		verbose = c.Bool(BoolOpt{Name: "v verbose", Value: false, EnvVar: "IMPLIES_VERBOSE"})
+		color = c.String(StringOpt{Name: "color", Value: "never", OptionalValue: "always"})
+		c.Implies("debug", "verbose")
+		c.Implies("d", "color")
+	}
+
+	os.Setenv("IMPLIES_VERBOSE", "")
+
+	okCmd(t, "[OPTIONS]", init, []string{})
+	require.False(t, *debug)
+	require.False(t, *verbose)
+	require.Equal(t, "never", *color)
+
+	okCmd(t, "[OPTIONS]", init, []string{"-d"})
+	require.True(t, *debug)
+	require.True(t, *verbose, "verbose should be implied by debug")
+	require.Equal(t, "always", *color, "color should be implied by debug")
+
+	okCmd(t, "[OPTIONS]", init, []string{"--debug=false"})
+	require.False(t, *verbose, "verbose should not be implied when debug is explicitly false")
+
+	okCmd(t, "[OPTIONS]", init, []string{"-d", "--verbose=false", "--color=auto"})
+	require.True(t, *debug)
+	require.False(t, *verbose, "an explicit value should override the implication")
+	require.Equal(t, "auto", *color, "an explicit value should override the implication")
+
+	okCmd(t, "[OPTIONS]", init, []string{"-v"})
+	require.False(t, *debug, "implication should not work backwards")
+	require.True(t, *verbose)
+
+	os.Setenv("IMPLIES_VERBOSE", "false")
+	defer os.Setenv("IMPLIES_VERBOSE", "")
+	okCmd(t, "[OPTIONS]", init, []string{"-d"})
+	require.False(t, *verbose, "a value from the env should override the implication")
+}
+
+func TestImpliesBadDeclarations(t *testing.T) {
+	cmd := &Cmd{optionsIdx: map[string]*opt{}}
+	cmd.BoolOpt("debug", false, "")
+	cmd.StringOpt("output", "", "")
+
+	require.Panics(t, func() { cmd.Implies("debug", "verbose") }, "undeclared implied option")
+	require.Panics(t, func() { cmd.Implies("dbg", "debug") }, "undeclared option")
+	require.Panics(t, func() { cmd.Implies("debug", "output") }, "option requiring a value")
+}
+
+func TestOrdered(t *testing.T) {
+	var min, max *int
+	init := func(c *Cmd) {
+		min = c.IntOpt("min", 0, "")
+		max = c.IntOpt("max", 100, "")
+		c.DurationOpt("soft-timeout", time.Second, "")
+		c.DurationOpt("hard-timeout", time.Minute, "")
+		c.Ordered("min", "max")
+		c.Ordered("soft-timeout", "hard-timeout")
+	}
+
+	okCmd(t, "[OPTIONS]", init, []string{})
+	okCmd(t, "[OPTIONS]", init, []string{"--min", "5", "--max", "10"})
+	require.Equal(t, 5, *min)
+	require.Equal(t, 10, *max)
+	okCmd(t, "[OPTIONS]", init, []string{"--min", "7", "--max", "7"})
+	okCmd(t, "[OPTIONS]", init, []string{"--min=-3"})
+	okCmd(t, "[OPTIONS]", init, []string{"--hard-timeout", "1s"})
+
+	failCmd(t, "[OPTIONS]", init, []string{"--min", "5", "--max", "3"})
+	failCmd(t, "[OPTIONS]", init, []string{"--min", "101"})
+	failCmd(t, "[OPTIONS]", init, []string{"--soft-timeout", "2m"})
+
+	cmd := &Cmd{name: "test", optionsIdx: map[string]*opt{}, argsIdx: map[string]*arg{}}
+	cmd.ErrorHandling = flag.ContinueOnError
+	init(cmd)
+	require.Nil(t, cmd.doInit())
+	require.Nil(t, cmd.fsm.parse([]string{"--min", "5", "--max", "3"}))
+	err := cmd.resolve()
+	require.NotNil(t, err)
+	require.Equal(t, "option --min (5) should not be greater than option --max (3)", err.Error())
+}
+
+func TestOrderedFloats(t *testing.T) {
+	init := func(c *Cmd) {
+		c.Float64Opt("low", 0.5, "")
+		c.Float64Opt("high", 1.5, "")
+		c.IntOpt("count", 1, "")
+		c.Unit(UnitOpt{Name: "min-distance", Value: 0, Base: "m", Units: map[string]Unit{"km": {Factor: 1000}}})
+		c.Unit(UnitOpt{Name: "max-distance", Value: 1000, Base: "m", Units: map[string]Unit{"km": {Factor: 1000}}})
+		c.Ordered("low", "high")
+		c.Ordered("count", "high")
+		c.Ordered("min-distance", "max-distance")
+	}
+
+	okCmd(t, "[OPTIONS]", init, []string{})
+	okCmd(t, "[OPTIONS]", init, []string{"--low", "0.75", "--high", "1.25"})
+	okCmd(t, "[OPTIONS]", init, []string{"--min-distance", "900m", "--max-distance", "1km"})
+
+	failCmd(t, "[OPTIONS]", init, []string{"--low", "1.3", "--high", "1.25"})
+	failCmd(t, "[OPTIONS]", init, []string{"--count", "2"})
+	failCmd(t, "[OPTIONS]", init, []string{"--min-distance", "2km"})
+}
+
+func TestOrderedBadDeclarations(t *testing.T) {
+	cmd := &Cmd{optionsIdx: map[string]*opt{}}
+	cmd.IntOpt("min", 0, "")
+	cmd.StringOpt("max", "", "")
+
+	require.Panics(t, func() { cmd.Ordered("min", "maximum") }, "undeclared option")
+	require.Panics(t, func() { cmd.Ordered("min", "max") }, "non numeric option")
+}
+
+func TestExactlyOne(t *testing.T) {
+	var (
+		file, url *string
+		stdin     *bool
+	)
+	init := func(c *Cmd) {
+		file = c.StringOpt("file", "", "")
+		url = c.String(StringOpt{Name: "url", Value: "", EnvVar: "EXACTLY_ONE_URL"})
+		stdin = c.BoolOpt("stdin", false, "")
+		c.BoolOpt("v", false, "")
+		c.ExactlyOne("file", "url", "stdin")
+	}
+
+	okCmd(t, "[OPTIONS]", init, []string{"--file", "in.txt"})
+	require.Equal(t, "in.txt", *file)
+	okCmd(t, "[OPTIONS]", init, []string{"--stdin", "-v"})
+	require.True(t, *stdin)
+
+	failCmd(t, "[OPTIONS]", init, []string{})
+	failCmd(t, "[OPTIONS]", init, []string{"-v"})
+	failCmd(t, "[OPTIONS]", init, []string{"--file", "in.txt", "--stdin"})
+
+	os.Setenv("EXACTLY_ONE_URL", "http://x")
+	defer os.Setenv("EXACTLY_ONE_URL", "")
+	okCmd(t, "[OPTIONS]", init, []string{})
+	require.Equal(t, "http://x", *url)
+	failCmd(t, "[OPTIONS]", init, []string{"--file", "in.txt"})
+	os.Setenv("EXACTLY_ONE_URL", "")
+
+	errorOf := func(args ...string) string {
+		cmd := &Cmd{name: "test", optionsIdx: map[string]*opt{}, argsIdx: map[string]*arg{}}
+		cmd.ErrorHandling = flag.ContinueOnError
+		init(cmd)
+		require.Nil(t, cmd.doInit())
+		require.Nil(t, cmd.fsm.parse(args))
+		err := cmd.resolve()
+		require.NotNil(t, err)
+		return err.Error()
+	}
+	require.Equal(t, "exactly one of the options --file | --url | --stdin is required", errorOf())
+	require.Equal(t, "only one of the options --file | --url | --stdin can be set, got --file and --stdin", errorOf("--stdin", "--file", "x"))
+}
+
+func TestExactlyOneHelp(t *testing.T) {
+	exitCalled := false
+	defer exitShouldBeCalledWith(t, 2, &exitCalled)()
+	var stdErr string
+	defer captureAndRestoreOutput(nil, &stdErr)()
+
+	app := App("app", "")
+	app.StringOpt("file", "", "Input file")
+	app.BoolOpt("stdin", false, "Read stdin")
+	app.BoolOpt("v", false, "Verbose")
+	app.ExactlyOne("file", "stdin")
+	app.Action = func() {}
+	app.Run([]string{"app", "-h"})
+
+	require.Equal(t, `
+Usage: app [OPTIONS] (--file | --stdin)
+
+
+Options:
+  --file=""            Input file (required: exactly one of --file | --stdin)
+  --[no-]stdin=false   Read stdin (required: exactly one of --file | --stdin)
+  -v=false             Verbose
+`, stdErr)
+}
+
+func TestExactlyOneBadDeclarations(t *testing.T) {
+	cmd := &Cmd{optionsIdx: map[string]*opt{}}
+	cmd.StringOpt("file", "", "")
+
+	require.Panics(t, func() { cmd.ExactlyOne("file", "url") }, "undeclared option")
+}
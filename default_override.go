@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+/*
+OverrideDefault replaces the initial value of an option declared by one of the command's parents, e.g. the app, when this command is run:
+
+	env := app.StringOpt("env", "dev", "the target environment")
+	app.Command("deploy", "", func(cmd *cli.Cmd) {
+		cmd.OverrideDefault("env", "staging")
+	})
+
+	$ app deploy                # env is staging
+	$ app --env prod deploy     # env is prod
+	$ app build                 # env is dev
+
+The option is looked up by name (without the dashes) in the parents, starting with the nearest one.
+Several values can be given for the slice options.
+The new default only applies if the option was not set from another source, e.g. the call arguments or an environment variable,
+and is set before the parent resolves its options, so that e.g. its interpolations see it.
+An unknown option or an invalid value is reported as a usage error when the command is run.
+*/
+func (c *Cmd) OverrideDefault(name string, values ...string) {
+	if c.defaultOverrides == nil {
+		c.defaultOverrides = map[string][]string{}
+	}
+	c.defaultOverrides[name] = values
+}
+
+// applyDefaultOverrides sets the initial values overridden by the sub commands args select, args being the call arguments following the ones of c,
+// into the options of c before they get resolved. The outermost sub commands are applied first, so that the innermost override wins
+func (c *Cmd) applyDefaultOverrides(args []string) error {
+	subs := c.selectedCommands(args)
+	for i, sub := range subs {
+		for _, name := range sub.defaultOverrideNames() {
+			owner, opt := nearestOpt(append([]*Cmd{c}, subs[:i]...), name)
+			if owner != c || opt.source != SourceDefault {
+				continue
+			}
+			if err := opt.setValues(sub.defaultOverrides[name]); err != nil {
+				return fmt.Errorf("invalid value for option %s in the defaults of command %s: %v", opt.displayName(), strings.Join(sub.Path(), " "), err)
+			}
+		}
+	}
+	return nil
+}
+
+// checkDefaultOverrides checks that the options whose initial values the command overrides are declared by its parents
+func (c *Cmd) checkDefaultOverrides() error {
+	for _, name := range c.defaultOverrideNames() {
+		if _, opt := nearestOpt(c.ancestors, name); opt == nil {
+			return fmt.Errorf("unknown option %s in the defaults of command %s", name, strings.Join(c.Path(), " "))
+		}
+	}
+	return nil
+}
+
+func (c *Cmd) defaultOverrideNames() []string {
+	names := make([]string, 0, len(c.defaultOverrides))
+	for name := range c.defaultOverrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// selectedCommands returns the chain of sub commands args select, starting with a direct sub command of c
+func (c *Cmd) selectedCommands(args []string) []*Cmd {
+	res := []*Cmd{}
+	for cmd := c; len(args) > 0; {
+		sub := cmd.commandNamed(args[0])
+		if sub == nil || sub.doInit() != nil {
+			break
+		}
+		args = args[1:]
+		args = args[sub.getOptsAndArgs(args):]
+		res = append(res, sub)
+		cmd = sub
+	}
+	return res
+}
+
+// nearestOpt returns the option named name declared by the last command of cmds declaring one, along with that command, or nils if there is none
+func nearestOpt(cmds []*Cmd, name string) (*Cmd, *opt) {
+	key := mkOptStrs(name)[0]
+	for i := len(cmds) - 1; i >= 0; i-- {
+		if opt, found := cmds[i].optionsIdx[key]; found {
+			return cmds[i], opt
+		}
+	}
+	return nil, nil
+}
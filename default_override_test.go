@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverrideDefault(t *testing.T) {
+	defer suppressOutput()()
+
+	var (
+		env     *string
+		regions *[]string
+		ran     string
+	)
+	init := func(app *Cli) {
+		ran = ""
+		env = app.String(StringOpt{Name: "env", Value: "dev", EnvVar: "APP_TEST_ENV"})
+		regions = app.StringsOpt("region", []string{"eu"}, "")
+		app.Command("deploy", "", func(cmd *Cmd) {
+			cmd.OverrideDefault("env", "staging")
+			cmd.OverrideDefault("region", "us", "ap")
+			cmd.Action = func() { ran = "deploy" }
+			cmd.Command("canary", "", func(sub *Cmd) {
+				sub.OverrideDefault("env", "canary")
+				sub.Action = func() { ran = "canary" }
+			})
+		})
+		app.Command("build", "", func(cmd *Cmd) {
+			cmd.Action = func() { ran = "build" }
+		})
+	}
+
+	cases := []struct {
+		args    []string
+		ran     string
+		env     string
+		regions []string
+	}{
+		{[]string{"app", "deploy"}, "deploy", "staging", []string{"us", "ap"}},
+		{[]string{"app", "--env", "prod", "--region", "sa", "deploy"}, "deploy", "prod", []string{"eu", "sa"}},
+		{[]string{"app", "build"}, "build", "dev", []string{"eu"}},
+		{[]string{"app", "deploy", "canary"}, "canary", "canary", []string{"us", "ap"}},
+		{[]string{"app", "--env", "prod", "deploy", "canary"}, "canary", "prod", []string{"us", "ap"}},
+	}
+	for _, cas := range cases {
+		app := testApp(init)
+		require.Nil(t, app.Run(cas.args), "%v", cas.args)
+		require.Equal(t, cas.ran, ran, "%v", cas.args)
+		require.Equal(t, cas.env, *env, "%v", cas.args)
+		require.Equal(t, cas.regions, *regions, "%v", cas.args)
+	}
+
+	os.Setenv("APP_TEST_ENV", "qa")
+	defer os.Unsetenv("APP_TEST_ENV")
+	app := testApp(init)
+	require.Nil(t, app.Run([]string{"app", "deploy"}))
+	require.Equal(t, "qa", *env, "the environment variables should take precedence over the command defaults")
+}
+
+func TestOverrideDefaultBeforeResolve(t *testing.T) {
+	defer suppressOutput()()
+
+	var logDir, target *string
+	init := func(app *Cli) {
+		app.StringOpt("base-dir", "/app", "")
+		logDir = app.StringOpt("log-dir", "${base-dir}/logs", "")
+		app.EnableInterpolation()
+		app.Command("deploy", "", func(cmd *Cmd) {
+			cmd.OverrideDefault("base-dir", "/srv")
+			target = cmd.StringOpt("target", "all", "")
+			cmd.Action = func() {}
+			cmd.Command("canary", "", func(sub *Cmd) {
+				sub.OverrideDefault("target", "one")
+				sub.Action = func() {}
+			})
+		})
+	}
+
+	require.Nil(t, runApp(init, "deploy"))
+	require.Equal(t, "/srv/logs", *logDir, "the parent interpolations should see the overridden default")
+	require.Equal(t, "all", *target)
+
+	require.Nil(t, runApp(init, "deploy", "canary"))
+	require.Equal(t, "/srv/logs", *logDir)
+	require.Equal(t, "one", *target)
+
+	require.Nil(t, runApp(init, "deploy", "--target", "two", "canary"))
+	require.Equal(t, "two", *target)
+}
+
+func TestOverrideDefaultErrors(t *testing.T) {
+	defer suppressOutput()()
+
+	app := App("app", "")
+	app.ErrorHandling = flag.ContinueOnError
+	app.IntOpt("count", 1, "")
+	app.Command("unknown", "", func(cmd *Cmd) {
+		cmd.OverrideDefault("cnt", "2")
+		cmd.Action = func() {}
+	})
+	app.Command("invalid", "", func(cmd *Cmd) {
+		cmd.OverrideDefault("count", "two")
+		cmd.Action = func() {}
+	})
+
+	err := app.Run([]string{"app", "unknown"})
+	require.NotNil(t, err)
+	require.Equal(t, "unknown option cnt in the defaults of command app unknown", err.Error())
+
+	err = app.Run([]string{"app", "invalid"})
+	require.NotNil(t, err)
+	require.Equal(t, `invalid value for option --count in the defaults of command app invalid: strconv.ParseInt: parsing "two": invalid syntax`, err.Error())
+}
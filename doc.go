@@ -20,7 +20,7 @@ Finally, in your main func, call Run on the app:
 
 Options
 
-To add a (global) option, call one of the (String[s]|Int[s]|Bool)Opt methods on the app:
+To add a (global) option, call one of the (String[s]|Int[s]|Float64[s]|Bool)Opt methods on the app:
 
 	recursive := cp.BoolOpt("R recursive", false, "recursively copy the src to dst")
 
@@ -30,7 +30,7 @@ To add a (global) option, call one of the (String[s]|Int[s]|Bool)Opt methods on
 
 * The third parameter is the option description, as will be shown in the help messages
 
-There is also a second set of methods Bool, String, Int, Strings and Ints, which accepts structs describing the option:
+There is also a second set of methods Bool, String, Int, Float64, Strings, Ints and Float64s, which accepts structs describing the option:
 
 	recursive = cp.Bool(BoolOpt{
 		Name:  "R",
@@ -68,7 +68,7 @@ In the command line, mow.cli accepts the following syntaxes
 
 Arguments
 
-To accept arguments, you need to explicitly declare them by calling one of the (String[s]|Int[s]|Bool)Arg methods on the app:
+To accept arguments, you need to explicitly declare them by calling one of the (String[s]|Int[s]|Float64[s]|Bool)Arg methods on the app:
 
 	src := cp.StringArg("SRC", "", "the file to copy")
 	dst := cp.StringArg("DST", "", "the destination")
@@ -79,7 +79,7 @@ To accept arguments, you need to explicitly declare them by calling one of the (
 
 * The third parameter is the argument description, as will be shown in the help messages
 
-There is also a second set of methods Bool, String, Int, Strings and Ints, which accepts structs describing the argument:
+There is also a second set of methods Bool, String, Int, Float64, Strings, Ints and Float64s, which accepts structs describing the argument:
 
 	src = cp.Strings(StringsArg{
 		Name:  "SRC",
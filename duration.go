@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+/*
+parseDuration extends time.ParseDuration with the `d` (24h) and `w` (168h) units,
+which can be mixed with the standard ones, e.g. `1w2d3h30m`
+*/
+func parseDuration(s string) (time.Duration, error) {
+	if !strings.ContainsAny(s, "dw") {
+		return time.ParseDuration(s)
+	}
+
+	invalid := fmt.Errorf("time: invalid duration %s", s)
+
+	rem := s
+	neg := false
+	if len(rem) > 0 && (rem[0] == '-' || rem[0] == '+') {
+		neg = rem[0] == '-'
+		rem = rem[1:]
+	}
+	if len(rem) == 0 {
+		return 0, invalid
+	}
+
+	var res time.Duration
+	for len(rem) > 0 {
+		i := 0
+		for i < len(rem) && (isDigit(rem[i]) || rem[i] == '.') {
+			i++
+		}
+		j := i
+		for j < len(rem) && !isDigit(rem[j]) && rem[j] != '.' {
+			j++
+		}
+		if i == 0 || j == i {
+			return 0, invalid
+		}
+		value, unit := rem[:i], rem[i:j]
+
+		switch unit {
+		case "d", "w":
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return 0, invalid
+			}
+			day := 24 * time.Hour
+			if unit == "w" {
+				day *= 7
+			}
+			res += time.Duration(f * float64(day))
+		default:
+			d, err := time.ParseDuration(value + unit)
+			if err != nil {
+				return 0, invalid
+			}
+			res += d
+		}
+		rem = rem[j:]
+	}
+
+	if neg {
+		res = -res
+	}
+	return res, nil
+}
+
+// durationStrings returns the durations of the slice v formatted like `1h30m0s`, e.g. to be serialized to JSON
+func durationStrings(v reflect.Value) []string {
+	res := []string{}
+	for i := 0; i < v.Len(); i++ {
+		res = append(res, v.Index(i).Interface().(time.Duration).String())
+	}
+	return res
+}
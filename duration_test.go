@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDuration(t *testing.T) {
+	day := 24 * time.Hour
+	cases := []struct {
+		s        string
+		expected time.Duration
+	}{
+		{"0", 0},
+		{"30s", 30 * time.Second},
+		{"1h30m", 90 * time.Minute},
+		{"1.5h", 90 * time.Minute},
+		{"250ms", 250 * time.Millisecond},
+		{"-2h", -2 * time.Hour},
+
+		{"30d", 30 * day},
+		{"1.5d", 36 * time.Hour},
+		{"2w", 14 * day},
+		{"+1d", day},
+		{"-1d", -day},
+
+		{"1w2d3h", 9*day + 3*time.Hour},
+		{"1d12h30m15s", day + 12*time.Hour + 30*time.Minute + 15*time.Second},
+		{"2d500ms", 2*day + 500*time.Millisecond},
+	}
+
+	for _, cas := range cases {
+		d, err := parseDuration(cas.s)
+		require.Nil(t, err, "parsing %s", cas.s)
+		require.Equal(t, cas.expected, d, "parsing %s", cas.s)
+	}
+
+	badCases := []string{
+		"",
+		"d",
+		"-d",
+		"1",
+		"1x",
+		"1dd",
+		"1d2",
+		"w1d",
+		"1.2.3d",
+		"1d 2h",
+	}
+
+	for _, cas := range badCases {
+		_, err := parseDuration(cas)
+		require.NotNil(t, err, "parsing %q should have failed", cas)
+	}
+}
+
+func TestDurationParse(t *testing.T) {
+	var (
+		ttl     *time.Duration
+		timeout *time.Duration
+	)
+	init := func(c *Cmd) {
+		ttl = c.DurationOpt("ttl", time.Hour, "")
+		timeout = c.DurationArg("TIMEOUT", 0, "")
+	}
+
+	okCmd(t, "[--ttl] TIMEOUT", init, []string{"--ttl", "1w", "1d12h"})
+	require.Equal(t, 7*24*time.Hour, *ttl)
+	require.Equal(t, 36*time.Hour, *timeout)
+
+	okCmd(t, "[--ttl] TIMEOUT", init, []string{"--ttl=45m", "500ms"})
+	require.Equal(t, 45*time.Minute, *ttl)
+	require.Equal(t, 500*time.Millisecond, *timeout)
+
+	failCmd(t, "[--ttl] TIMEOUT", init, []string{"--ttl", "1y", "1d"})
+	failCmd(t, "[--ttl] TIMEOUT", init, []string{"1"})
+}
@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type enumFlags struct {
+	target    *string
+	targetOpt *opt
+	flags     []*opt
+	modes     []string
+}
+
+/*
+EnumFlags adds a bool option per entry of flags, named after the key, which selects the mode given by the value, e.g.:
+
+	mode := cmd.StringOpt("mode", "a", "the mode")
+	cmd.EnumFlags(mode, map[string]string{"B": "b", "C": "c"})
+
+	$ app -B   # *mode is "b"
+
+After the call arguments got parsed, the mode of the option which was set is written into target, which keeps its value if none was.
+Setting more than one of these options is reported as a usage error, as is setting one of them together with the option target belongs to, if any,
+in the call arguments, or when that option is Immutable and was already set from another source.
+
+The keys are option names *WITHOUT* the dashes, and the options are declared in the order of their names
+*/
+func (c *Cmd) EnumFlags(target *string, flags map[string]string) {
+	names := make([]string, 0, len(flags))
+	for name := range flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	group := enumFlags{target: target}
+	for _, o := range c.options {
+		if o.value.Interface() == target {
+			group.targetOpt = o
+		}
+	}
+	for _, name := range names {
+		c.Bool(BoolOpt{Name: name, Desc: fmt.Sprintf("Select the %s mode", flags[name]), HideValue: true})
+		group.flags = append(group.flags, c.optionsIdx[mkOptStrs(name)[0]])
+		group.modes = append(group.modes, flags[name])
+	}
+	c.enumFlags = append(c.enumFlags, group)
+}
+
+func (c *Cmd) applyEnumFlags() error {
+	for _, group := range c.enumFlags {
+		opts := group.flags
+		if group.targetOpt != nil {
+			opts = append([]*opt{group.targetOpt}, opts...)
+		}
+
+		set := []string{}
+		for _, o := range opts {
+			// the flags override the target option values coming from the environment or a config file
+			if o.isOn() && (o != group.targetOpt || o.source == SourceCLI) {
+				set = append(set, o.displayName())
+			}
+		}
+		if len(set) > 1 {
+			return fmt.Errorf("only one of the options %s can be set, got %s", optNames(opts), strings.Join(set, " and "))
+		}
+
+		for i, o := range group.flags {
+			if !o.isOn() {
+				continue
+			}
+			if group.targetOpt == nil {
+				*group.target = group.modes[i]
+				break
+			}
+			if err := group.targetOpt.checkImmutable(o.source); err != nil {
+				return err
+			}
+			if err := group.targetOpt.set(group.modes[i]); err != nil {
+				return err
+			}
+			group.targetOpt.source = o.source
+		}
+	}
+	return nil
+}
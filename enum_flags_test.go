@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnumFlags(t *testing.T) {
+	defer suppressOutput()()
+
+	var mode *string
+	init := func(app *Cli) {
+		mode = app.String(StringOpt{Name: "mode", Value: "a", Choices: []string{"a", "b", "c"}})
+		app.EnumFlags(mode, map[string]string{"B": "b", "C": "c"})
+	}
+
+	require.Nil(t, runApp(init))
+	require.Equal(t, "a", *mode)
+
+	app := testApp(init)
+	require.Nil(t, app.Run([]string{"app", "-C"}))
+	require.Equal(t, "c", *mode)
+	require.Equal(t, SourceCLI, app.optionsIdx["--mode"].source)
+
+	require.Nil(t, runApp(init, "--mode", "b"))
+	require.Equal(t, "b", *mode)
+
+	os.Setenv("APP_MODE", "b")
+	defer os.Setenv("APP_MODE", "")
+	initEnv := func(app *Cli) {
+		mode = app.String(StringOpt{Name: "mode", Value: "a", EnvVar: "APP_MODE"})
+		app.EnumFlags(mode, map[string]string{"B": "b", "C": "c"})
+	}
+	require.Nil(t, runApp(initEnv, "-C"))
+	require.Equal(t, "c", *mode, "the flags should override the environment variable")
+
+	initImmutable := func(app *Cli) {
+		mode = app.String(StringOpt{Name: "mode", Value: "a", EnvVar: "APP_MODE", Immutable: true})
+		app.EnumFlags(mode, map[string]string{"B": "b", "C": "c"})
+	}
+	err := testApp(initImmutable).Run([]string{"app", "-C"})
+	require.NotNil(t, err)
+	require.Equal(t, "option --mode was already set from the environment variable APP_MODE and cannot be changed from the call arguments", err.Error())
+
+	os.Setenv("APP_MODE", "")
+	require.Nil(t, runApp(initImmutable, "-B"))
+	require.Equal(t, "b", *mode)
+
+	cases := []struct {
+		args []string
+		msg  string
+	}{
+		{[]string{"app", "-B", "-C"}, "only one of the options --mode | -B | -C can be set, got -B and -C"},
+		{[]string{"app", "--mode", "b", "-C"}, "only one of the options --mode | -B | -C can be set, got --mode and -C"},
+	}
+	for _, cas := range cases {
+		err := testApp(init).Run(cas.args)
+		require.NotNil(t, err, "args %v", cas.args)
+		require.Equal(t, cas.msg, err.Error())
+	}
+}
+
+func TestEnumFlagsPlainTarget(t *testing.T) {
+	defer suppressOutput()()
+
+	mode := "fast"
+	init := func(app *Cli) {
+		app.EnumFlags(&mode, map[string]string{"s safe": "safe", "x": "experimental"})
+	}
+
+	require.Nil(t, runApp(init))
+	require.Equal(t, "fast", mode)
+
+	require.Nil(t, runApp(init, "--safe"))
+	require.Equal(t, "safe", mode)
+
+	err := runApp(init, "-s", "-x")
+	require.NotNil(t, err)
+	require.Equal(t, "only one of the options --safe | -x can be set, got --safe and -x", err.Error())
+}
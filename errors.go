@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+/*
+ErrorKind identifies the kind of an incorrect usage
+*/
+type ErrorKind string
+
+const (
+	// ErrorKindIncorrectUsage is the kind of the call arguments which do not match the command spec, e.g. because of an extra argument
+	ErrorKindIncorrectUsage ErrorKind = "incorrect-usage"
+	// ErrorKindUnknownOption is the kind of the call arguments containing an option the command does not declare
+	ErrorKindUnknownOption ErrorKind = "unknown-option"
+	// ErrorKindMissing is the kind of the call arguments missing a required option or argument
+	ErrorKindMissing ErrorKind = "missing-required"
+	// ErrorKindInvalidValue is the kind of the call arguments containing an invalid option or argument value
+	ErrorKindInvalidValue ErrorKind = "invalid-value"
+	// ErrorKindUnknownCommand is the kind of the call arguments containing an unknown command
+	ErrorKindUnknownCommand ErrorKind = "unknown-command"
+	// ErrorKindUnexpectedArgument is the kind of the call arguments containing more arguments than the command accepts
+	ErrorKindUnexpectedArgument ErrorKind = "unexpected-argument"
+)
+
+/*
+ParseError describes an incorrect usage, i.e. call arguments which could not be parsed.
+It is the error returned by Run when the ErrorHandling policy is flag.ContinueOnError, except for the errors returned by the commands
+*/
+type ParseError struct {
+	// The kind of incorrect usage
+	Kind ErrorKind `json:"kind"`
+	// The offending call argument, if known
+	Token string `json:"token,omitempty"`
+	// The error message
+	Message string `json:"message"`
+}
+
+func (e *ParseError) Error() string {
+	return e.Message
+}
+
+// asParseError returns err as a ParseError, an error of another type being considered as an invalid value
+func asParseError(err error) *ParseError {
+	if pe, ok := err.(*ParseError); ok {
+		return pe
+	}
+	return &ParseError{Kind: ErrorKindInvalidValue, Message: err.Error()}
+}
+
+/*
+JSONErrors turns on or off the JSON errors mode, in which the incorrect usages are reported on the standard error as a JSON object
+with the kind of error, the offending call argument if known and the error message, instead of the error message followed by the help message, e.g.:
+
+	{"kind":"unknown-option","token":"--frce","message":"incorrect usage"}
+
+It is meant to be used when the app is run by another program, e.g. in CI. The exit code is left unchanged.
+*/
+func (cli *Cli) JSONErrors(enabled bool) {
+	cli.jsonErrors = enabled
+}
+
+// reportError prints err, as a JSON object in the JSON errors mode, or else followed by the help message
+func (c *Cmd) reportError(err error) {
+	if c.jsonErrors {
+		enc, _ := json.Marshal(asParseError(err))
+		fmt.Fprintf(stdErr, "%s\n", enc)
+		return
+	}
+	fmt.Fprintf(stdErr, "Error: %s\n", err.Error())
+	c.PrintHelp()
+}
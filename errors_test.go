@@ -0,0 +1,183 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONErrors(t *testing.T) {
+	errorHandling := flag.ExitOnError
+	init := func(app *Cli) {
+		app.ErrorHandling = errorHandling
+		app.JSONErrors(true)
+		app.Command("cp", "", func(cmd *Cmd) {
+			cmd.Spec = "[-f] [--count] SRC DST"
+			cmd.BoolOpt("f force", false, "")
+			cmd.IntOpt("count", 1, "")
+			cmd.StringArg("SRC", "", "")
+			cmd.StringArg("DST", "", "")
+			cmd.Action = func() {}
+		})
+	}
+
+	cases := []struct {
+		args     []string
+		expected map[string]interface{}
+	}{
+		{
+			[]string{"app", "cp", "--frce", "a", "b"},
+			map[string]interface{}{"kind": "unknown-option", "token": "--frce", "message": "incorrect usage"},
+		},
+		{
+			[]string{"app", "cp", "--count", "x", "a", "b"},
+			map[string]interface{}{"kind": "invalid-value", "token": "x", "message": `strconv.ParseInt: parsing "x": invalid syntax`},
+		},
+		{
+			[]string{"app", "cp", "-f", "a"},
+			map[string]interface{}{"kind": "missing-required", "message": "incorrect usage"},
+		},
+		{
+			[]string{"app", "cp", "a", "b", "c"},
+			map[string]interface{}{"kind": "unexpected-argument", "token": "c", "message": "unexpected argument: c"},
+		},
+		{
+			[]string{"app", "cp", "-f", "-f", "a", "b"},
+			map[string]interface{}{"kind": "incorrect-usage", "message": "incorrect usage"},
+		},
+		{
+			[]string{"app", "rm", "a"},
+			map[string]interface{}{"kind": "unknown-command", "token": "rm", "message": "incorrect usage"},
+		},
+	}
+
+	for _, cas := range cases {
+		var stdErr string
+		exitCalled := false
+		func() {
+			defer captureAndRestoreOutput(nil, &stdErr)()
+			defer exitShouldBeCalledWith(t, 2, &exitCalled)()
+			testApp(init).Run(cas.args)
+		}()
+		require.True(t, exitCalled, "exit should have been called for %v", cas.args)
+
+		var actual map[string]interface{}
+		require.Nil(t, json.Unmarshal([]byte(stdErr), &actual), "invalid JSON error %q for %v", stdErr, cas.args)
+		require.Equal(t, cas.expected, actual, "args %v", cas.args)
+	}
+
+	errorHandling = flag.ContinueOnError
+	app := testApp(init)
+	var stdErr string
+	defer captureAndRestoreOutput(nil, &stdErr)()
+	err := app.Run([]string{"app", "cp", "--frce", "a", "b"})
+	require.NotNil(t, err)
+	pe, ok := err.(*ParseError)
+	require.True(t, ok, "a ParseError should be returned, got %T", err)
+	require.Equal(t, ErrorKindUnknownOption, pe.Kind)
+	require.Equal(t, "--frce", pe.Token)
+}
+
+func TestUnexpectedArgument(t *testing.T) {
+	var src, dst *string
+	init := func(c *Cmd) {
+		c.BoolOpt("f", false, "")
+		src = c.StringArg("SRC", "", "")
+		dst = c.StringArg("DST", "", "")
+	}
+
+	okCmd(t, "[-f] SRC DST", init, []string{"a", "b"})
+	require.Equal(t, "a", *src)
+	require.Equal(t, "b", *dst)
+
+	cases := []struct {
+		spec     string
+		args     []string
+		expected string
+	}{
+		{"[-f] SRC DST", []string{"a", "b", "c"}, "c"},
+		{"[-f] SRC DST", []string{"-f", "a", "b", "c", "d", "e"}, "c"},
+		{"[-f] SRC [DST]", []string{"a", "b", "c"}, "c"},
+		{"SRC [-f] DST", []string{"a", "-f", "b", "c", "-f"}, "c"},
+	}
+	for _, cas := range cases {
+		failCmd(t, cas.spec, init, cas.args)
+
+		cmd := &Cmd{name: "test", optionsIdx: map[string]*opt{}, argsIdx: map[string]*arg{}}
+		cmd.Spec = cas.spec
+		init(cmd)
+		require.Nil(t, cmd.doInit())
+		err := cmd.fsm.parse(cas.args)
+		require.NotNil(t, err, "args %v", cas.args)
+		require.Equal(t, "unexpected argument: "+cas.expected, err.Error(), "args %v", cas.args)
+	}
+}
+
+func TestArgMissingMsg(t *testing.T) {
+	init := func(c *Cmd) {
+		c.BoolOpt("f", false, "")
+		c.String(StringArg{Name: "SRC", MissingMsg: "please specify the source file"})
+		c.String(StringArg{Name: "DST", MissingMsg: "please specify the destination file"})
+		c.String(StringArg{Name: "MODE"})
+	}
+
+	cases := []struct {
+		spec     string
+		args     []string
+		expected string
+		token    string
+	}{
+		{"[-f] SRC DST", []string{}, "please specify the source file", "SRC"},
+		{"[-f] SRC DST", []string{"-f"}, "please specify the source file", "SRC"},
+		{"[-f] SRC DST", []string{"a"}, "please specify the destination file", "DST"},
+		{"[-f] SRC DST", []string{"-f", "a"}, "please specify the destination file", "DST"},
+		{"SRC [-f] DST", []string{"a", "-f"}, "please specify the destination file", "DST"},
+		{"SRC MODE", []string{"a"}, "incorrect usage", ""},
+	}
+	for _, cas := range cases {
+		failCmd(t, cas.spec, init, cas.args)
+
+		cmd := &Cmd{name: "test", optionsIdx: map[string]*opt{}, argsIdx: map[string]*arg{}}
+		cmd.Spec = cas.spec
+		init(cmd)
+		require.Nil(t, cmd.doInit())
+		err := cmd.fsm.parse(cas.args)
+		require.NotNil(t, err, "spec %s, args %v", cas.spec, cas.args)
+		require.Equal(t, cas.expected, err.Error(), "spec %s, args %v", cas.spec, cas.args)
+		require.Equal(t, ErrorKindMissing, err.(*ParseError).Kind)
+		require.Equal(t, cas.token, err.(*ParseError).Token)
+	}
+}
+
+func TestOptValueFollowedByOpt(t *testing.T) {
+	init := func(c *Cmd) {
+		c.StringOpt("o output", "", "")
+		c.BoolOpt("v verbose", false, "")
+		c.StringArg("SRC", "", "")
+	}
+
+	cases := []struct {
+		args     []string
+		expected string
+		token    string
+	}{
+		{[]string{"--output", "--verbose", "a"}, "did --output need a value? it is followed by the option --verbose, use --output=--verbose to pass it as the value", "--output"},
+		{[]string{"-v", "-o", "-v", "a"}, "did -o need a value? it is followed by the option -v, use -o=-v to pass it as the value", "-o"},
+		{[]string{"a", "--output", "-v"}, "did --output need a value? it is followed by the option -v, use --output=-v to pass it as the value", "--output"},
+	}
+	for _, cas := range cases {
+		failCmd(t, "[OPTIONS] SRC", init, cas.args)
+
+		cmd := &Cmd{name: "test", optionsIdx: map[string]*opt{}, argsIdx: map[string]*arg{}}
+		cmd.Spec = "[OPTIONS] SRC"
+		init(cmd)
+		require.Nil(t, cmd.doInit())
+		err := cmd.fsm.parse(cas.args)
+		require.NotNil(t, err, "args %v", cas.args)
+		require.Equal(t, cas.expected, err.Error(), "args %v", cas.args)
+		require.Equal(t, ErrorKindMissing, err.(*ParseError).Kind)
+		require.Equal(t, cas.token, err.(*ParseError).Token)
+	}
+}
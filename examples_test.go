@@ -147,3 +147,35 @@ func Example_beforeAfter() {
 
 	app.Run(os.Args)
 }
+
+type loggingOpts struct {
+	level *string
+	json  *bool
+}
+
+func (l *loggingOpts) Apply(cmd *Cmd) {
+	l.level = cmd.StringOpt("log-level", "info", "Log level")
+	l.json = cmd.BoolOpt("log-json", false, "Log in JSON")
+}
+
+func Example_sharedOptions() {
+	app := App("app", "App")
+
+	var buildLogging, testLogging loggingOpts
+
+	app.Command("build", "build the project", func(cmd *Cmd) {
+		buildLogging.Apply(cmd)
+		cmd.Action = func() {
+			fmt.Printf("Building with log level %s", *buildLogging.level)
+		}
+	})
+
+	app.Command("test", "test the project", func(cmd *Cmd) {
+		testLogging.Apply(cmd)
+		cmd.Action = func() {
+			fmt.Printf("Testing with log level %s", *testLogging.level)
+		}
+	})
+
+	app.Run(os.Args)
+}
@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+const execPrefix = "exec:"
+
+// execValue runs the command of s if it starts with `exec:`, and returns its output without the trailing newlines, or else returns s
+func execValue(s string) (string, error) {
+	if !strings.HasPrefix(s, execPrefix) {
+		return s, nil
+	}
+	cmdLine := strings.TrimSpace(strings.TrimPrefix(s, execPrefix))
+	parts := strings.Fields(cmdLine)
+	if len(parts) == 0 {
+		return s, fmt.Errorf("missing command in %q", s)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(parts[0], parts[1:]...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); len(msg) > 0 {
+			return s, fmt.Errorf("command %q failed: %v: %s", cmdLine, err, msg)
+		}
+		return s, fmt.Errorf("command %q failed: %v", cmdLine, err)
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
+
+// execEnvValue replaces the option value read from an environment variable with the output of its command, if allowed and if it starts with `exec:`
+func (o *opt) execEnvValue() error {
+	if !o.allowExec || o.source != SourceEnv {
+		return nil
+	}
+	v, err := execValue(o.get().(string))
+	if err != nil {
+		return fmt.Errorf("invalid value for option %s from the environment variable %s: %v", o.displayName(), o.sourceEnvVar, err)
+	}
+	o.value.Elem().SetString(v)
+	return nil
+}
@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllowExec(t *testing.T) {
+	defer suppressOutput()()
+
+	dir, err := ioutil.TempDir("", "mow-cli-exec")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	secret := filepath.Join(dir, "secret")
+	require.Nil(t, ioutil.WriteFile(secret, []byte("s3cr3t\n"), 0600))
+
+	os.Setenv("APP_TOKEN", "")
+	defer os.Unsetenv("APP_TOKEN")
+
+	var (
+		token  *string
+		name   *string
+		called bool
+	)
+	init := func(app *Cli) {
+		called = false
+		token = app.String(StringOpt{Name: "token", EnvVar: "APP_TOKEN", AllowExec: true})
+		name = app.String(StringOpt{Name: "name"})
+		app.Action = func() {
+			called = true
+		}
+	}
+
+	require.Nil(t, runApp(init, "--token", "exec:cat "+secret))
+	require.True(t, called)
+	require.Equal(t, "exec:cat "+secret, *token, "the call arguments should not run commands")
+
+	require.Nil(t, runApp(init, "--token", "plain"))
+	require.Equal(t, "plain", *token)
+
+	require.Nil(t, runApp(init, "--name", "exec:cat "+secret))
+	require.Equal(t, "exec:cat "+secret, *name, "the options should not run commands unless allowed")
+
+	os.Setenv("APP_TOKEN", "exec:cat "+secret)
+	require.Nil(t, runApp(init))
+	require.True(t, called)
+	require.Equal(t, "s3cr3t", *token)
+
+	missing := filepath.Join(dir, "missing")
+	os.Setenv("APP_TOKEN", "exec:cat "+missing)
+	err = runApp(init)
+	require.NotNil(t, err)
+	require.True(t, strings.HasPrefix(err.Error(), `invalid value for option --token from the environment variable APP_TOKEN: command "cat `+missing+`" failed: exit status 1`), "unexpected error %s", err)
+	require.False(t, called)
+	os.Setenv("APP_TOKEN", "")
+
+	os.Setenv("APP_TOKEN", "exec:")
+	err = runApp(init)
+	require.NotNil(t, err)
+	require.Equal(t, `invalid value for option --token from the environment variable APP_TOKEN: missing command in "exec:"`, err.Error())
+}
+
+func TestAllowExecSources(t *testing.T) {
+	defer suppressOutput()()
+
+	dir, err := ioutil.TempDir("", "mow-cli-exec")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	config := filepath.Join(dir, "app.json")
+	require.Nil(t, ioutil.WriteFile(config, []byte(`{"token": "exec:echo ran"}`), 0600))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"token": "exec:echo ran"}`)
+	}))
+	defer server.Close()
+
+	var (
+		token   *string
+		sources []ValueSource
+	)
+	init := func(app *Cli) {
+		token = app.String(StringOpt{Name: "token", AllowExec: true})
+		app.ConfigFile("config")
+		app.FlagsFromJSON("flags-from-json")
+		app.ValueSources(sources...)
+	}
+
+	require.Nil(t, runApp(init, "--config", config))
+	require.Equal(t, "exec:echo ran", *token, "the config files should not run commands")
+
+	require.Nil(t, runApp(init, "--flags-from-json", `{"token": "exec:echo ran"}`))
+	require.Equal(t, "exec:echo ran", *token, "the JSON flags should not run commands")
+
+	sources = []ValueSource{&HTTPSource{URL: server.URL}}
+	require.Nil(t, runApp(init))
+	require.Equal(t, "exec:echo ran", *token, "the value sources should not run commands")
+	sources = nil
+
+	app := testApp(init)
+	require.Nil(t, app.SetFromQuery(url.Values{"token": {"exec:echo ran"}}))
+	require.Equal(t, "exec:echo ran", *token, "the queries should not run commands")
+}
@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+/*
+ExportEnv returns the current values of the command's options which differ from their initial values as environment variables,
+in the `KEY=VALUE` form expected by exec.Cmd.Env, e.g. to pass them to a child process:
+
+	child := exec.Command("worker")
+	child.Env = append(os.Environ(), cmd.ExportEnv()...)
+
+Each option is exported using the first of its EnvVar environment variables, the options without one being skipped.
+The values of a slice option are joined with its separator (a comma by default), and an EnvInvert option set to false is exported as `1`.
+The secret options are exported with their actual value
+*/
+func (c *Cmd) ExportEnv() []string {
+	res := []string{}
+	for _, opt := range c.options {
+		if kv, ok := opt.exportEnv(); ok {
+			res = append(res, kv)
+		}
+	}
+	return res
+}
+
+// exportEnv returns the option value as a `KEY=VALUE` environment variable, and false if it has no env var or if its value is the initial one
+func (o *opt) exportEnv() (string, bool) {
+	names := envVarNames(o.envVar)
+	if len(names) == 0 || reflect.DeepEqual(o.get(), o.defaultValue) {
+		return "", false
+	}
+	if o.envInvert {
+		if o.value.Elem().Bool() {
+			return "", false
+		}
+		return names[0] + "=1", true
+	}
+	return names[0] + "=" + envValue(o.value.Elem(), o.envSep()), true
+}
+
+// envValue formats v as an environment variable value, the items of the slices being joined with sep
+func envValue(v reflect.Value, sep string) string {
+	switch {
+	case v.Type() == durationType:
+		return v.Interface().(time.Duration).String()
+	case v.Kind() == reflect.Slice:
+		items := []string{}
+		for i := 0; i < v.Len(); i++ {
+			items = append(items, envValue(v.Index(i), sep))
+		}
+		return strings.Join(items, sep)
+	default:
+		return fmt.Sprintf("%v", v.Interface())
+	}
+}
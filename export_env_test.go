@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportEnv(t *testing.T) {
+	defer exitShouldNotCalled(t)()
+	defer suppressOutput()()
+	os.Setenv("APP_LEVEL", "warn")
+	defer os.Unsetenv("APP_LEVEL")
+
+	var exported []string
+	app := App("app", "")
+	app.String(StringOpt{Name: "o output", Value: "out.txt", EnvVar: "APP_OUTPUT OUTPUT"})
+	app.String(StringOpt{Name: "l level", Value: "info", EnvVar: "  APP_LEVEL "})
+	app.String(StringOpt{Name: "name", Value: "x"})
+	app.Int(IntOpt{Name: "n", Value: 1, EnvVar: "APP_N"})
+	app.Bool(BoolOpt{Name: "v verbose", EnvVar: "APP_VERBOSE"})
+	app.Bool(BoolOpt{Name: "color", Value: true, EnvVar: "NO_COLOR", EnvInvert: true})
+	app.Duration(DurationOpt{Name: "timeout", Value: 0, EnvVar: "APP_TIMEOUT"})
+	app.Strings(StringsOpt{Name: "t tag", EnvVar: "APP_TAGS"})
+	app.Strings(StringsOpt{Name: "e env", EnvVar: "APP_ENV", Sep: ";"})
+	app.Ints(IntsOpt{Name: "port", Value: []int{80}, EnvVar: "APP_PORTS"})
+	app.Action = func() {
+		exported = app.ExportEnv()
+	}
+
+	require.Nil(t, app.Run([]string{"app", "-o", "res.txt", "--name", "y", "-v", "--color=false", "--timeout", "1m30s", "-t", "a", "-t", "b", "-e", "x;y"}))
+	require.Equal(t, []string{
+		"APP_OUTPUT=res.txt",
+		"APP_LEVEL=warn",
+		"APP_VERBOSE=true",
+		"NO_COLOR=1",
+		"APP_TIMEOUT=1m30s",
+		"APP_TAGS=a,b",
+		"APP_ENV=x;y",
+	}, exported)
+
+	os.Unsetenv("APP_LEVEL")
+	app.Reset()
+	require.Nil(t, app.Run([]string{"app", "-n", "2", "--port", "443"}))
+	require.Equal(t, []string{"APP_N=2", "APP_PORTS=80,443"}, exported)
+}
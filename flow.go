@@ -6,10 +6,11 @@ import (
 )
 
 type step struct {
-	do      func()
-	success *step
-	error   *step
-	desc    string
+	do       func()
+	success  *step
+	error    *step
+	desc     string
+	exitCode func(error) int
 }
 
 func (s *step) run(p interface{}) {
@@ -25,10 +26,24 @@ func (s *step) run(p interface{}) {
 			exiter(int(code))
 			return
 		}
+		if f, ok := p.(failure); ok {
+			fmt.Fprintf(stdErr, "Error: %s\n", f.err.Error())
+			exiter(s.exitCodeFor(f.err))
+			return
+		}
 		panic(p)
 	}
 }
 
+func (s *step) exitCodeFor(err error) int {
+	if s.exitCode != nil {
+		if code := s.exitCode(err); code != 0 {
+			return code
+		}
+	}
+	return 1
+}
+
 func (s *step) callDo(p interface{}) {
 	if s.do == nil {
 		return
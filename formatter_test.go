@@ -3,6 +3,7 @@ package cli
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -20,13 +21,28 @@ func TestFormatters(t *testing.T) {
 
 		{42, "42"},
 
+		{1.5, "1.5"},
+
+		{90 * time.Minute, "1h30m0s"},
+
 		{[]string{}, `[]`},
-		{[]string{"a"}, `["a"]`},
-		{[]string{"a", "b"}, `["a", "b"]`},
+		{[]string{"a"}, `a`},
+		{[]string{"a", "b"}, `a, b`},
+		{[]string{"a b", ""}, `a b, ""`},
+		{[]string{"a,b", " c", `d"`}, `"a,b", " c", "d\""`},
 
 		{[]int{}, "[]"},
-		{[]int{1}, "[1]"},
-		{[]int{1, 2}, "[1, 2]"},
+		{[]int{1}, "1"},
+		{[]int{1, 2}, "1, 2"},
+
+		{[]float64{}, "[]"},
+		{[]float64{0.1, 2}, "0.1, 2"},
+
+		{[]time.Duration{}, "[]"},
+		{[]time.Duration{time.Second, 90 * time.Minute}, "1s, 1h30m0s"},
+
+		{map[string]string{}, "{}"},
+		{map[string]string{"b": "2", "a": "x=1"}, `{"a=x=1", "b=2"}`},
 	}
 
 	for _, cas := range cases {
@@ -34,3 +50,36 @@ func TestFormatters(t *testing.T) {
 		require.Equal(t, cas.expected, f(cas.input), "formatting error for value %v (%T)", cas.input, cas.input)
 	}
 }
+
+func TestCustomBoolFormatter(t *testing.T) {
+	require.Nil(t, customBoolFormatter("", ""))
+
+	f := customBoolFormatter("on", "off")
+	require.Equal(t, "on", f(true))
+	require.Equal(t, "off", f(false))
+
+	f = customBoolFormatter("yes", "")
+	require.Equal(t, "yes", f(true))
+	require.Equal(t, "false", f(false))
+}
+
+func TestTypeName(t *testing.T) {
+	cases := []struct {
+		input    interface{}
+		expected string
+	}{
+		{true, "bool"},
+		{"", "string"},
+		{42, "int"},
+		{time.Second, "duration"},
+		{[]string{}, "string..."},
+		{[]int{}, "int..."},
+		{[]float64{}, "float64..."},
+		{[]time.Duration{}, "duration..."},
+		{map[string]string{}, "key=value..."},
+	}
+
+	for _, cas := range cases {
+		require.Equal(t, cas.expected, typeName(reflect.TypeOf(cas.input)), "type name error for value %v (%T)", cas.input, cas.input)
+	}
+}
@@ -3,9 +3,16 @@ package cli
 import (
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
+	"time"
 )
 
 func formatterFor(t reflect.Type) func(interface{}) string {
+	if t == durationType {
+		return durationFormatter
+	}
+
 	switch t.Kind() {
 	case reflect.Bool:
 		return boolFormatter
@@ -13,24 +20,63 @@ func formatterFor(t reflect.Type) func(interface{}) string {
 		return stringFormatter
 	case reflect.Int:
 		return intFormatter
+	case reflect.Float64:
+		return floatFormatter
 	case reflect.Slice:
-		switch t.Elem().Kind() {
-		case reflect.String:
+		switch {
+		case t.Elem() == durationType:
+			return durationsFormatter
+		case t.Elem().Kind() == reflect.String:
 			return stringsFormatter
-		case reflect.Int:
+		case t.Elem().Kind() == reflect.Int:
 			return intsFormatter
+		case t.Elem().Kind() == reflect.Float64:
+			return floatsFormatter
 		default:
 			panic(fmt.Sprintf("No formatter for %v", t))
 		}
+	case reflect.Map:
+		return stringMapFormatter
 	default:
 		panic(fmt.Sprintf("No formatter for %v", t))
 	}
 }
 
+// typeName returns the name of the type t as shown in help messages, e.g. `int` or `string...` for a slice of strings
+func typeName(t reflect.Type) string {
+	switch {
+	case t == durationType:
+		return "duration"
+	case t.Kind() == reflect.Slice:
+		return typeName(t.Elem()) + "..."
+	case t.Kind() == reflect.Map:
+		return "key=value..."
+	default:
+		return t.Kind().String()
+	}
+}
+
 func boolFormatter(v interface{}) string {
 	return fmt.Sprintf("%v", v)
 }
 
+// customBoolFormatter returns a formatter showing the bool values as trueStr and falseStr, or nil if both are empty
+func customBoolFormatter(trueStr, falseStr string) func(interface{}) string {
+	if len(trueStr) == 0 && len(falseStr) == 0 {
+		return nil
+	}
+	return func(v interface{}) string {
+		switch {
+		case v == true && len(trueStr) > 0:
+			return trueStr
+		case v == false && len(falseStr) > 0:
+			return falseStr
+		default:
+			return boolFormatter(v)
+		}
+	}
+}
+
 func stringFormatter(v interface{}) string {
 	return fmt.Sprintf("%#v", v)
 }
@@ -39,26 +85,96 @@ func intFormatter(v interface{}) string {
 	return fmt.Sprintf("%v", v)
 }
 
+func floatFormatter(v interface{}) string {
+	return fmt.Sprintf("%v", v)
+}
+
+func durationFormatter(v interface{}) string {
+	return fmt.Sprintf("%v", v)
+}
+
+// stringsFormatter shows the values comma separated, as they are given in the call arguments, e.g. `a, b`.
+// The values which would be ambiguous in this form, e.g. empty or containing a comma, are quoted, and an empty slice is shown as `[]`
 func stringsFormatter(v interface{}) string {
-	res := "["
-	strings, _ := v.([]string)
-	for idx, s := range strings {
+	values, _ := v.([]string)
+	if len(values) == 0 {
+		return "[]"
+	}
+	res := ""
+	for idx, s := range values {
 		if idx > 0 {
 			res += ", "
 		}
-		res += fmt.Sprintf("%#v", s)
+		if len(strings.TrimSpace(s)) < len(s) || len(s) == 0 || strings.ContainsAny(s, ",\"") {
+			s = fmt.Sprintf("%#v", s)
+		}
+		res += s
 	}
-	return res + "]"
+	return res
 }
 
+// intsFormatter shows the values comma separated, e.g. `1, 2`, and an empty slice as `[]`
 func intsFormatter(v interface{}) string {
-	res := "["
 	ints, _ := v.([]int)
+	if len(ints) == 0 {
+		return "[]"
+	}
+	res := ""
 	for idx, s := range ints {
 		if idx > 0 {
 			res += ", "
 		}
 		res += fmt.Sprintf("%v", s)
 	}
-	return res + "]"
+	return res
+}
+
+// floatsFormatter shows the values comma separated, e.g. `0.1, 2`, and an empty slice as `[]`
+func floatsFormatter(v interface{}) string {
+	floats, _ := v.([]float64)
+	if len(floats) == 0 {
+		return "[]"
+	}
+	res := ""
+	for idx, f := range floats {
+		if idx > 0 {
+			res += ", "
+		}
+		res += fmt.Sprintf("%v", f)
+	}
+	return res
+}
+
+// durationsFormatter shows the values comma separated, e.g. `1s, 2m0s`, and an empty slice as `[]`
+func durationsFormatter(v interface{}) string {
+	durations, _ := v.([]time.Duration)
+	if len(durations) == 0 {
+		return "[]"
+	}
+	res := ""
+	for idx, d := range durations {
+		if idx > 0 {
+			res += ", "
+		}
+		res += d.String()
+	}
+	return res
+}
+
+func stringMapFormatter(v interface{}) string {
+	m, _ := v.(map[string]string)
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	res := "{"
+	for idx, k := range keys {
+		if idx > 0 {
+			res += ", "
+		}
+		res += fmt.Sprintf("%#v", k+"="+m[k])
+	}
+	return res + "}"
 }
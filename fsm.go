@@ -140,13 +140,20 @@ func dot(s *state, visited map[*state]bool) []string {
 }
 
 type parseContext struct {
-	args          map[*arg][]string
-	opts          map[*opt][]string
-	rejectOptions bool
+	args               map[*arg][]string
+	opts               map[*opt][]string
+	rejectOptions      bool
+	singleDashLongOpts bool
+	// set to true if the call arguments were all consumed before reaching a terminal state, i.e. if some were missing
+	exhausted *bool
+	// the arguments which were expected when the call arguments were all consumed
+	missing *[]*arg
+	// the parsing profile of the command, see Profile
+	profile Profile
 }
 
 func newParseContext() parseContext {
-	return parseContext{map[*arg][]string{}, map[*opt][]string{}, false}
+	return parseContext{map[*arg][]string{}, map[*opt][]string{}, false, false, new(bool), &[]*arg{}, DefaultProfile}
 }
 
 func (pc parseContext) merge(o parseContext) {
@@ -160,29 +167,106 @@ func (pc parseContext) merge(o parseContext) {
 }
 
 func (s *state) parse(args []string) error {
-	pc := newParseContext()
-	ok, err := s.apply(args, pc)
+	pc, ok, err := s.accept(args)
 	if err != nil {
 		return err
 	}
 	if !ok {
-		return fmt.Errorf("incorrect usage")
+		return s.usageError(args, *pc.exhausted, *pc.missing)
+	}
+	return pc.set()
+}
+
+// usageError returns the error reporting that args were not accepted by the state machine,
+// with the first unknown option or command, the first option missing its value because it is followed by another option,
+// the custom message of a missing argument, or the first argument in excess, if any
+func (s *state) usageError(args []string, exhausted bool, missing []*arg) *ParseError {
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if strings.HasPrefix(arg, "-") && arg != "-" && !s.cmd.isKnownOpt(arg) {
+			return &ParseError{Kind: ErrorKindUnknownOption, Token: arg, Message: "incorrect usage"}
+		}
+	}
+	for i := 0; i+1 < len(args) && args[i] != "--"; i++ {
+		arg, next := args[i], args[i+1]
+		if opt, found := s.cmd.optionsIdx[arg]; found && !opt.isFlag() && strings.HasPrefix(next, "-") && next != "--" && s.cmd.isKnownOpt(next) {
+			return &ParseError{Kind: ErrorKindMissing, Token: arg,
+				Message: fmt.Sprintf("did %s need a value? it is followed by the option %s, use %s=%s to pass it as the value", arg, next, arg, next)}
+		}
+	}
+	if len(s.cmd.commands) > 0 && len(s.cmd.args) == 0 {
+		if name, found := s.cmd.firstNonOption(args); found {
+			return &ParseError{Kind: ErrorKindUnknownCommand, Token: name, Message: "incorrect usage"}
+		}
+	}
+	if exhausted {
+		for _, arg := range missing {
+			if len(arg.missingMsg) > 0 {
+				return &ParseError{Kind: ErrorKindMissing, Token: arg.name, Message: arg.missingMsg}
+			}
+		}
+		return &ParseError{Kind: ErrorKindMissing, Message: "incorrect usage"}
+	}
+	for n := len(args) - 1; n >= 0; n-- {
+		if _, ok, _ := s.accept(args[:n]); ok {
+			if strings.HasPrefix(args[n], "-") {
+				break
+			}
+			return &ParseError{Kind: ErrorKindUnexpectedArgument, Token: args[n], Message: fmt.Sprintf("unexpected argument: %s", args[n])}
+		}
 	}
+	return &ParseError{Kind: ErrorKindIncorrectUsage, Message: "incorrect usage"}
+}
 
+// parseKnown parses the longest prefix of args accepted by the state machine and returns the remaining args
+func (s *state) parseKnown(args []string) ([]string, error) {
+	for n := len(args); n >= 0; n-- {
+		pc, ok, err := s.accept(args[:n])
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return args[n:], pc.set()
+		}
+	}
+	return nil, s.usageError(args, false, nil)
+}
+
+// accept checks if args are accepted by the state machine, and returns the matched options and arguments values without setting them
+func (s *state) accept(args []string) (parseContext, bool, error) {
+	pc := newParseContext()
+	pc.singleDashLongOpts = s.cmd.SingleDashLongOpts
+	pc.profile = s.cmd.profile
+	ok, err := s.apply(args, pc)
+	return pc, ok, err
+}
+
+// set sets the matched values into the options and arguments
+func (pc parseContext) set() error {
 	for opt, vs := range pc.opts {
+		if len(vs) > 1 && pc.rejectsDuplicates(opt) {
+			return &ParseError{Kind: ErrorKindIncorrectUsage, Token: opt.displayName(), Message: fmt.Sprintf("option %s specified multiple times", opt.displayName())}
+		}
+		if err := opt.checkImmutable(SourceCLI); err != nil {
+			return &ParseError{Kind: ErrorKindIncorrectUsage, Token: opt.displayName(), Message: err.Error()}
+		}
 		for _, v := range vs {
 			if err := opt.set(v); err != nil {
-				return err
+				return &ParseError{Kind: ErrorKindInvalidValue, Token: opt.maskString(v), Message: err.Error()}
 			}
 		}
+		opt.source = SourceCLI
 	}
 
 	for arg, vs := range pc.args {
 		for _, v := range vs {
 			if err := arg.set(v); err != nil {
-				return err
+				return &ParseError{Kind: ErrorKindInvalidValue, Token: v, Message: err.Error()}
 			}
 		}
+		arg.source = SourceCLI
 	}
 
 	return nil
@@ -192,6 +276,14 @@ func (s *state) apply(args []string, pc parseContext) (bool, error) {
 	if s.terminal && len(args) == 0 {
 		return true, nil
 	}
+	if len(args) == 0 {
+		*pc.exhausted = true
+		for _, tr := range s.transitions {
+			if arg, ok := tr.matcher.(*arg); ok {
+				*pc.missing = append(*pc.missing, arg)
+			}
+		}
+	}
 	sort.Sort(s.transitions)
 
 	if len(args) > 0 {
@@ -213,6 +305,9 @@ func (s *state) apply(args []string, pc parseContext) (bool, error) {
 	for _, tr := range s.transitions {
 		fresh := newParseContext()
 		fresh.rejectOptions = pc.rejectOptions
+		fresh.singleDashLongOpts = pc.singleDashLongOpts
+		fresh.exhausted = pc.exhausted
+		fresh.missing = pc.missing
 		if ok, rem := tr.matcher.match(args, &fresh); ok {
 			matches = append(matches, &match{tr, rem, fresh})
 		}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const helpAllName = "--help-all"
+
+/*
+PrintHelpAll prints the long help message of the app followed by the ones of all its commands, depth first, e.g. to generate a complete reference.
+Each help message is preceded by a separator line with the command path, and is indented according to the command depth:
+
+	=== app ===
+	...
+	  === app remote ===
+	  ...
+
+The hidden and deprecated commands are skipped, like in the help messages.
+It is also printed when the app is run with the `--help-all` option, unless the app declares an option with this name
+*/
+func (cli *Cli) PrintHelpAll() error {
+	if err := cli.doInitAll(); err != nil {
+		return err
+	}
+	cli.writeHelpAll(0)
+	return nil
+}
+
+// writeHelpAll writes the long help message of the command and its visible sub commands, indented by depth
+func (c *Cmd) writeHelpAll(depth int) {
+	var help bytes.Buffer
+	out := stdErr
+	stdErr = &help
+	c.writeHelp(true)
+	stdErr = out
+
+	indent := strings.Repeat("  ", depth)
+	fmt.Fprintf(out, "%s=== %s ===\n", indent, strings.Join(c.Path(), " "))
+	for _, line := range strings.Split(strings.TrimSpace(help.String()), "\n") {
+		if len(line) > 0 {
+			line = indent + line
+		}
+		fmt.Fprintln(out, line)
+	}
+	fmt.Fprintln(out)
+
+	for _, sub := range c.commands {
+		if sub.hidden || len(sub.deprecated) > 0 {
+			continue
+		}
+		sub.writeHelpAll(depth + 1)
+	}
+}
+
+func (cli *Cli) helpAllRequested(args []string) bool {
+	if _, declared := cli.optionsIdx[helpAllName]; declared {
+		return false
+	}
+	return cli.isArgSet(args, cli.callNames([]string{helpAllName}))
+}
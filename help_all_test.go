@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHelpAll(t *testing.T) {
+	var stdErr string
+	defer captureAndRestoreOutput(nil, &stdErr)()
+
+	exitCalled := false
+	defer exitShouldBeCalledWith(t, 2, &exitCalled)()
+
+	app := App("app", "The app")
+	app.BoolOpt("v verbose", false, "Verbose mode")
+	app.Command("remote", "Manage the remotes", func(cmd *Cmd) {
+		cmd.Command("add", "Add a remote", func(cmd *Cmd) {
+			cmd.StringArg("URL", "", "The remote URL")
+		})
+		cmd.Command("rm", "Remove a remote", func(cmd *Cmd) {
+			cmd.hidden = true
+		})
+	})
+	app.Command("old", "An old command", func(cmd *Cmd) {
+		cmd.Deprecated("use remote instead")
+	})
+	app.Run([]string{"app", "--help-all"})
+
+	require.Equal(t, `=== app ===
+Usage: app [OPTIONS] COMMAND [arg...]
+
+The app
+
+Options:
+  -v, --[no-]verbose=false   Verbose mode
+
+Commands:
+  remote       Manage the remotes
+
+Run 'app COMMAND --help' for more information on a command.
+
+  === app remote ===
+  Usage: app remote COMMAND [arg...]
+
+  Manage the remotes
+
+  Commands:
+    add          Add a remote
+
+  Run 'app remote COMMAND --help' for more information on a command.
+
+    === app remote add ===
+    Usage: app remote add URL
+
+    Add a remote
+
+    Arguments:
+      URL="" (string)   The remote URL
+
+`, stdErr)
+}
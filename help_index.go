@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+type optsByName []*opt
+
+func (o optsByName) Len() int           { return len(o) }
+func (o optsByName) Swap(i, j int)      { o[i], o[j] = o[j], o[i] }
+func (o optsByName) Less(i, j int) bool { return o[i].indexName() < o[j].indexName() }
+
+// indexName returns the name the option is sorted by in the indexed help message, i.e. its longest name without the dashes
+func (o *opt) indexName() string {
+	return strings.ToLower(strings.TrimLeft(o.displayName(), "-"))
+}
+
+// writeOptionsIndex prints the options sorted by name under a header for each first letter, keeping the descriptions aligned across the letters
+func (c *Cmd) writeOptionsIndex(options []*opt) {
+	sorted := append(optsByName{}, options...)
+	sort.Stable(sorted)
+
+	var b bytes.Buffer
+	w := tabwriter.NewWriter(&b, 15, 1, 3, ' ', 0)
+	letter := ""
+	for _, opt := range sorted {
+		if first := strings.ToUpper(opt.indexName()[:1]); first != letter {
+			if len(letter) > 0 {
+				fmt.Fprintf(w, "\t\n")
+			}
+			letter = first
+			fmt.Fprintf(w, "  %s\t\n", letter)
+		}
+		desc := c.formatOptDescription(opt)
+		value := c.formatOptValue(opt)
+		fmt.Fprintf(w, "  %s%s\t%s\n", opt.helpNames(), value, desc)
+	}
+	w.Flush()
+
+	// the headers are in the tabwriter to keep the alignment, and are padded with spaces
+	for _, line := range strings.SplitAfter(b.String(), "\n") {
+		fmt.Fprint(stdErr, strings.TrimRight(line, " \n"))
+		if strings.HasSuffix(line, "\n") {
+			fmt.Fprint(stdErr, "\n")
+		}
+	}
+}
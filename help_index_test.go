@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexedHelp(t *testing.T) {
+	var out, stdErr string
+	defer captureAndRestoreOutput(&out, &stdErr)()
+
+	exitCalled := false
+	defer exitShouldBeCalledWith(t, 2, &exitCalled)()
+
+	app := App("app", "App Desc")
+	app.ErrorHandling = flag.ContinueOnError
+	app.IndexedHelp = true
+	app.Spec = "[OPTIONS]"
+	app.BoolOpt("v verbose", false, "Verbose mode")
+	app.StringOpt("output", "out.txt", "Output file")
+	app.IntOpt("b batch-size", 10, "Batch size")
+	app.BoolOpt("a all", false, "All files")
+	app.StringOpt("backend", "local", "Storage backend")
+	app.StringsOpt("V", nil, "Volumes")
+	app.Action = func() {}
+
+	app.Run([]string{"app", "-h"})
+
+	require.Equal(t, `
+Usage: app [OPTIONS]
+
+App Desc
+
+Options:
+  A
+  -a, --[no-]all=false       All files
+
+  B
+  --backend="local"          Storage backend
+  -b, --batch-size=10        Batch size
+
+  O
+  --output="out.txt"         Output file
+
+  V
+  -V=[]                      Volumes
+  -v, --[no-]verbose=false   Verbose mode
+`, stdErr)
+}
@@ -1,10 +1,12 @@
 package cli
 
 import (
+	"flag"
 	"testing"
 
 	"bytes"
 
+	"io"
 	"io/ioutil"
 
 	"github.com/stretchr/testify/require"
@@ -27,6 +29,12 @@ func exitShouldNotCalled(t *testing.T) func() {
 	return func() { exiter = oldExiter }
 }
 
+func provideInput(in io.Reader) func() {
+	oldStdIn := stdIn
+	stdIn = in
+	return func() { stdIn = oldStdIn }
+}
+
 func suppressOutput() func() {
 	return captureAndRestoreOutput(nil, nil)
 }
@@ -71,3 +79,17 @@ func (w *writerTrap) Write(p []byte) (n int, err error) {
 	}
 	return
 }
+
+// testApp returns an app named app reporting the incorrect usages by returning an error, with a no-op action, configured by init
+func testApp(init func(app *Cli)) *Cli {
+	app := App("app", "")
+	app.ErrorHandling = flag.ContinueOnError
+	app.Action = func() {}
+	init(app)
+	return app
+}
+
+// runApp runs a new app configured by init with the call arguments args, returning the incorrect usage error, if any
+func runApp(init func(app *Cli), args ...string) error {
+	return testApp(init).Run(append([]string{"app"}, args...))
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"reflect"
+	"time"
+)
+
+/*
+HistoryEntry describes a value an option was set to by a parse of the call arguments
+*/
+type HistoryEntry struct {
+	// The option value
+	Value interface{} `json:"value"`
+	// Where the value comes from
+	Source Source `json:"source"`
+	// When the value was set
+	Time time.Time `json:"time"`
+}
+
+/*
+RecordHistory turns on or off the recording of the values the options of the app and its commands are set to by each parse of the call arguments,
+e.g. to diagnose the config reloads of a long running process which parses its arguments again (see Reset):
+
+	app.RecordHistory(true)
+
+The recorded values can be read with OptionHistory
+*/
+func (cli *Cli) RecordHistory(enabled bool) {
+	cli.history = enabled
+}
+
+/*
+OptionHistory returns the values the option named name (e.g. "v" or "verbose") was set to, from the oldest to the latest,
+one per parse of the call arguments which set it (see RecordHistory).
+
+It returns nil if the history is not recorded, or if the command has no such option
+*/
+func (c *Cmd) OptionHistory(name string) []HistoryEntry {
+	names := mkOptStrs(name)
+	opt, found := c.optionsIdx[names[0]]
+	if !found {
+		return nil
+	}
+	return opt.history
+}
+
+// recordHistory records the values of the options which were set, if the history is on
+func (c *Cmd) recordHistory() {
+	if !c.history {
+		return
+	}
+	now := time.Now()
+	for _, opt := range c.options {
+		if opt.source == SourceDefault {
+			continue
+		}
+		opt.history = append(opt.history, HistoryEntry{Value: vcopy(reflect.ValueOf(opt.shownValue())), Source: opt.source, Time: now})
+	}
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptionHistory(t *testing.T) {
+	defer exitShouldNotCalled(t)()
+	defer suppressOutput()()
+
+	os.Setenv("APP_LEVEL", "info")
+	defer os.Unsetenv("APP_LEVEL")
+
+	app := App("app", "")
+	app.RecordHistory(true)
+	app.String(StringOpt{Name: "l level", Value: "warn", EnvVar: "APP_LEVEL"})
+	app.StringsOpt("t tag", nil, "")
+	app.IntOpt("workers", 1, "")
+	var serve *Cmd
+	app.Command("serve", "", func(cmd *Cmd) {
+		serve = cmd
+		cmd.IntOpt("p port", 80, "")
+		cmd.Action = func() {}
+	})
+
+	start := time.Now()
+	require.Nil(t, app.Run([]string{"app", "-l", "debug", "-t", "a", "serve", "-p", "8080"}))
+	app.Reset()
+	require.Nil(t, app.Run([]string{"app", "-t", "b", "serve"}))
+
+	level := app.OptionHistory("level")
+	require.Len(t, level, 2)
+	require.Equal(t, "debug", level[0].Value)
+	require.Equal(t, SourceCLI, level[0].Source)
+	require.Equal(t, "info", level[1].Value)
+	require.Equal(t, SourceEnv, level[1].Source)
+	require.False(t, level[0].Time.Before(start))
+	require.False(t, level[1].Time.Before(level[0].Time))
+
+	require.Equal(t, level, app.OptionHistory("l"), "the option should be found by any of its names")
+
+	tags := app.OptionHistory("t")
+	require.Len(t, tags, 2)
+	require.Equal(t, []string{"a"}, tags[0].Value, "the recorded values should not change with the later parses")
+	require.Equal(t, []string{"b"}, tags[1].Value)
+
+	require.Empty(t, app.OptionHistory("workers"), "the options left to their initial value should not be recorded")
+	require.Nil(t, app.OptionHistory("unknown"))
+
+	port := serve.OptionHistory("port")
+	require.Len(t, port, 1)
+	require.Equal(t, 8080, port[0].Value)
+
+	app = App("app", "")
+	app.StringOpt("l level", "warn", "")
+	app.Action = func() {}
+	require.Nil(t, app.Run([]string{"app", "-l", "debug"}))
+	require.Nil(t, app.OptionHistory("level"), "the history should not be recorded unless enabled")
+}
@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+HTTPSource is a ValueSource reading the options values from a JSON object served by an HTTP endpoint, e.g. a fleet config service:
+
+	app.ValueSources(&HTTPSource{URL: "https://config.example.com/app.json"})
+
+The object keys are the option names without the dashes, and its values are strings, numbers, booleans or arrays of those for the slice options.
+
+The object is fetched once, when the first value is looked up, and is then cached by the source
+until the app is reset (see Cli.Reset), so that a long-lived app fetches the current values again.
+If it cannot be fetched or decoded, e.g. because the endpoint is down or too slow, the source provides no values,
+so that the options keep their initial values, and the error is passed to OnError if set.
+*/
+type HTTPSource struct {
+	// The URL of the JSON object
+	URL string
+	// The maximum duration of the request. Defaults to 5 seconds if left empty
+	Timeout time.Duration
+	// The client used to send the request. Defaults to a client using Timeout
+	Client *http.Client
+	// If set, the function called with the error preventing the values from being fetched
+	OnError func(err error)
+
+	once   sync.Once
+	values map[string]interface{}
+}
+
+// Lookup returns the value of the option from the fetched object
+func (s *HTTPSource) Lookup(name string) (string, bool, error) {
+	s.once.Do(s.load)
+	v, found := s.values[name]
+	if !found {
+		return "", false, nil
+	}
+	res, err := httpSourceValue(v)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid value from %s: %v", s.URL, err)
+	}
+	return res, true, nil
+}
+
+// reset drops the fetched object, for it to be fetched again on the next lookup
+func (s *HTTPSource) reset() {
+	s.once = sync.Once{}
+	s.values = nil
+}
+
+func (s *HTTPSource) load() {
+	values, err := s.fetch()
+	if err != nil {
+		if s.OnError != nil {
+			s.OnError(err)
+		}
+		return
+	}
+	s.values = values
+}
+
+func (s *HTTPSource) fetch() (map[string]interface{}, error) {
+	client := s.Client
+	if client == nil {
+		timeout := s.Timeout
+		if timeout == 0 {
+			timeout = 5 * time.Second
+		}
+		client = &http.Client{Timeout: timeout}
+	}
+
+	resp, err := client.Get(s.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: %s", s.URL, resp.Status)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	values, err := decodeJSONConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON object from %s: %v", s.URL, err)
+	}
+	return values, nil
+}
+
+// httpSourceValue converts a decoded JSON value to the string form accepted by the options, the arrays being comma separated
+func httpSourceValue(v interface{}) (string, error) {
+	switch x := v.(type) {
+	case string:
+		return x, nil
+	case bool:
+		return strconv.FormatBool(x), nil
+	case fmt.Stringer:
+		return x.String(), nil
+	case []interface{}:
+		strs := []string{}
+		for _, item := range x {
+			s, err := httpSourceValue(item)
+			if err != nil {
+				return "", err
+			}
+			strs = append(strs, s)
+		}
+		return strings.Join(strs, ","), nil
+	default:
+		return "", fmt.Errorf("unsupported value %v", v)
+	}
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPSource(t *testing.T) {
+	defer suppressOutput()()
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		switch r.URL.Path {
+		case "/app.json":
+			fmt.Fprint(w, `{"user": "fleet-user", "retries": 5, "debug": true, "tag": ["a", "b"], "bad": {"x": 1}}`)
+		case "/slow.json":
+			time.Sleep(200 * time.Millisecond)
+			fmt.Fprint(w, `{"user": "slow-user"}`)
+		case "/invalid.json":
+			fmt.Fprint(w, `not json`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	var (
+		user    *string
+		retries *int
+		debug   *bool
+		tags    *[]string
+		source  = &HTTPSource{URL: server.URL + "/app.json"}
+	)
+	init := func(app *Cli) {
+		user = app.StringOpt("u user", "root", "")
+		retries = app.IntOpt("retries", 1, "")
+		debug = app.BoolOpt("debug", false, "")
+		tags = app.StringsOpt("tag", nil, "")
+		app.ValueSources(source)
+	}
+
+	app := testApp(init)
+	require.Nil(t, app.Run([]string{"app", "--retries", "2"}))
+	require.Equal(t, "fleet-user", *user)
+	require.Equal(t, 2, *retries, "the call arguments should override the source values")
+	require.True(t, *debug)
+	require.Equal(t, []string{"a", "b"}, *tags)
+	require.Equal(t, SourceExternal, app.optionsIdx["--user"].source)
+	require.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	require.Nil(t, runApp(init))
+	require.Equal(t, 5, *retries)
+	require.Equal(t, int32(1), atomic.LoadInt32(&requests), "the values should be fetched once")
+
+	app.Reset()
+	require.Nil(t, app.Run([]string{"app"}))
+	require.Equal(t, "fleet-user", *user)
+	require.Equal(t, int32(2), atomic.LoadInt32(&requests), "the values should be fetched again after a reset")
+
+	_, _, err := source.Lookup("bad")
+	require.NotNil(t, err)
+
+	for _, path := range []string{"/slow.json", "/invalid.json", "/missing.json"} {
+		errs := []error{}
+		source = &HTTPSource{URL: server.URL + path, Timeout: 50 * time.Millisecond, OnError: func(err error) {
+			errs = append(errs, err)
+		}}
+		app = testApp(init)
+		require.Nil(t, app.Run([]string{"app"}), "path %s", path)
+		require.Equal(t, "root", *user, "the initial values should be kept when the values cannot be fetched from %s", path)
+		require.Equal(t, 1, *retries)
+		require.Equal(t, SourceDefault, app.optionsIdx["--user"].source)
+		require.Len(t, errs, 1, "the error should be reported for %s", path)
+	}
+}
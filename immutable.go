@@ -0,0 +1,40 @@
+package cli
+
+import "fmt"
+
+// checkImmutable checks that the option can be set by source, i.e. that it is not immutable or was not set by another source
+func (o *opt) checkImmutable(source Source) error {
+	if !o.immutable || o.source == SourceDefault || o.source == source {
+		return nil
+	}
+	return fmt.Errorf("option %s was already set from %s and cannot be changed from %s", o.displayName(), o.sourceDesc(), sourceDesc(source))
+}
+
+// sourceDesc describes where the option value comes from, e.g. `the environment variable APP_TOKEN`
+func (o *opt) sourceDesc() string {
+	if o.source == SourceEnv {
+		return fmt.Sprintf("the environment variable %s", o.sourceEnvVar)
+	}
+	return sourceDesc(o.source)
+}
+
+func sourceDesc(source Source) string {
+	switch source {
+	case SourceEnv:
+		return "the environment"
+	case SourceCLI:
+		return "the call arguments"
+	case SourceConfig:
+		return "a config file"
+	case SourceJSON:
+		return "a JSON object"
+	case SourceQuery:
+		return "a query string"
+	case SourceExternal:
+		return "a value source"
+	case SourceImplied:
+		return "an implying option"
+	default:
+		return "its initial value"
+	}
+}
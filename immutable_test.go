@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImmutableOpt(t *testing.T) {
+	defer suppressOutput()()
+	defer os.Unsetenv("APP_SANDBOX")
+
+	var (
+		sandbox *bool
+		level   *string
+		called  bool
+	)
+	init := func(app *Cli) {
+		called = false
+		sandbox = app.Bool(BoolOpt{Name: "sandbox", Value: true, EnvVar: "APP_SANDBOX", Immutable: true})
+		level = app.String(StringOpt{Name: "level", Value: "info", EnvVar: "APP_LEVEL"})
+		app.FlagsFromJSON("flags")
+		app.Action = func() {
+			called = true
+		}
+	}
+
+	require.Nil(t, runApp(init, "--sandbox=false", "--level", "debug"))
+	require.True(t, called)
+	require.False(t, *sandbox, "an immutable option should be settable from the call arguments alone")
+
+	os.Setenv("APP_SANDBOX", "true")
+	err := runApp(init, "--sandbox=false")
+	require.NotNil(t, err)
+	require.Equal(t, "option --sandbox was already set from the environment variable APP_SANDBOX and cannot be changed from the call arguments", err.Error())
+	require.False(t, called)
+
+	err = runApp(init, "--flags", `{"sandbox": false}`)
+	require.NotNil(t, err)
+	require.Equal(t, "option --sandbox was already set from the environment variable APP_SANDBOX and cannot be changed from a JSON object", err.Error())
+	require.False(t, called)
+
+	require.Nil(t, runApp(init, "--level", "debug"))
+	require.True(t, called)
+	require.True(t, *sandbox)
+	require.Equal(t, "debug", *level)
+	os.Unsetenv("APP_SANDBOX")
+
+	err = runApp(init, "--sandbox=false", "--flags", `{"sandbox": true}`)
+	require.Nil(t, err, "the call arguments should still take precedence over the JSON object")
+	require.False(t, *sandbox)
+}
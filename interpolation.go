@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+var interpolationRef = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+/*
+EnableInterpolation turns on the interpolation of the command's string options values:
+once the call arguments got parsed, the `${name}` references in these values are replaced with the value of the option named `name` (without the dashes), e.g.:
+
+	app.StringOpt("base-dir", "/app", "the base directory")
+	app.StringOpt("log-dir", "${base-dir}/logs", "the logs directory")
+	app.EnableInterpolation()
+
+	$ app --base-dir /srv
+	# log-dir is /srv/logs
+
+The referenced options can themselves contain references.
+An option referencing a secret option gets its value masked like the secret one wherever the library outputs it.
+A reference to an unknown or a slice option, or a cycle of references, is reported as a usage error.
+*/
+func (c *Cmd) EnableInterpolation() {
+	c.interpolation = true
+}
+
+func (c *Cmd) applyInterpolation() error {
+	if !c.interpolation {
+		return nil
+	}
+	done := map[*opt]bool{}
+	for _, opt := range c.options {
+		if !c.isInterpolated(opt) {
+			continue
+		}
+		if err := c.interpolate(opt, done, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Cmd) isInterpolated(o *opt) bool {
+	return o.value.Elem().Kind() == reflect.String && o != c.jsonFlags && o != c.configFile
+}
+
+// interpolate replaces the references in the value of o, after having interpolated the referenced options.
+// path holds the options being interpolated, to detect the reference cycles
+func (c *Cmd) interpolate(o *opt, done map[*opt]bool, path []*opt) error {
+	if done[o] {
+		return nil
+	}
+	for i, p := range path {
+		if p == o {
+			names := []string{}
+			for _, p := range append(path[i:], o) {
+				names = append(names, p.displayName())
+			}
+			return fmt.Errorf("reference cycle between options %s", strings.Join(names, " -> "))
+		}
+	}
+	path = append(path, o)
+
+	var err error
+	res := interpolationRef.ReplaceAllStringFunc(o.get().(string), func(ref string) string {
+		if err != nil {
+			return ref
+		}
+		name := interpolationRef.FindStringSubmatch(ref)[1]
+		referenced, found := c.optionsIdx[mkOptStrs(name)[0]]
+		switch {
+		case !found:
+			err = fmt.Errorf("unknown option %s referenced by option %s", name, o.displayName())
+		case referenced.isMulti():
+			err = fmt.Errorf("slice option %s cannot be referenced by option %s", referenced.displayName(), o.displayName())
+		case c.isInterpolated(referenced):
+			err = c.interpolate(referenced, done, path)
+		}
+		if err != nil {
+			return ref
+		}
+		if referenced.isSecret() {
+			o.secretRef = true
+		}
+		return fmt.Sprintf("%v", referenced.get())
+	})
+	if err != nil {
+		return err
+	}
+
+	o.value.Elem().SetString(res)
+	done[o] = true
+	return nil
+}
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterpolation(t *testing.T) {
+	var baseDir, logDir, logFile *string
+	var port *int
+	init := func(c *Cmd) {
+		baseDir = c.StringOpt("base-dir", "/app", "")
+		logDir = c.StringOpt("log-dir", "${base-dir}/logs", "")
+		logFile = c.StringOpt("log-file", "${log-dir}/app-${port}.log", "")
+		port = c.IntOpt("p port", 80, "")
+		c.EnableInterpolation()
+	}
+
+	okCmd(t, "[OPTIONS]", init, []string{})
+	require.Equal(t, "/app", *baseDir)
+	require.Equal(t, "/app/logs", *logDir)
+	require.Equal(t, "/app/logs/app-80.log", *logFile, "nested references should be resolved")
+
+	okCmd(t, "[OPTIONS]", init, []string{"--base-dir", "/srv", "-p", "8080"})
+	require.Equal(t, "/srv/logs", *logDir)
+	require.Equal(t, 8080, *port)
+	require.Equal(t, "/srv/logs/app-8080.log", *logFile)
+
+	okCmd(t, "[OPTIONS]", init, []string{"--log-dir", "/var/log", "--log-file", "${log-dir}/${base-dir}"})
+	require.Equal(t, "/var/log//app", *logFile)
+
+	okCmd(t, "[OPTIONS]", init, []string{"--log-dir", "${base-dir"})
+	require.Equal(t, "${base-dir", *logDir, "an unterminated reference should be kept as is")
+}
+
+func TestInterpolationDisabled(t *testing.T) {
+	var logDir *string
+	init := func(c *Cmd) {
+		c.StringOpt("base-dir", "/app", "")
+		logDir = c.StringOpt("log-dir", "${base-dir}/logs", "")
+	}
+
+	okCmd(t, "[OPTIONS]", init, []string{})
+	require.Equal(t, "${base-dir}/logs", *logDir)
+}
+
+func TestInterpolationErrors(t *testing.T) {
+	cases := []struct {
+		args []string
+		msg  string
+	}{
+		{[]string{"--first", "${first}"}, "reference cycle between options --first -> --first"},
+		{[]string{"--first", "${second}", "--second", "x${third}"}, "reference cycle between options --first -> --second -> --third -> --first"},
+		{[]string{"--second", "${unknown}"}, "unknown option unknown referenced by option --second"},
+		{[]string{"--second", "${t}"}, "slice option -t cannot be referenced by option --second"},
+	}
+
+	for _, cas := range cases {
+		cmd := &Cmd{name: "test", optionsIdx: map[string]*opt{}, argsIdx: map[string]*arg{}}
+		cmd.ErrorHandling = flag.ContinueOnError
+		cmd.StringOpt("first", "", "")
+		cmd.StringOpt("second", "", "")
+		cmd.StringOpt("third", "${first}", "")
+		cmd.StringsOpt("t", nil, "")
+		cmd.EnableInterpolation()
+		require.Nil(t, cmd.doInit())
+
+		err := cmd.fsm.parse(cas.args)
+		require.Nil(t, err)
+		err = cmd.resolve()
+		require.NotNil(t, err, "args %v should have failed", cas.args)
+		require.Equal(t, cas.msg, err.Error())
+	}
+}
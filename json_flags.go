@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+FlagsFromJSON adds an option named `name` to the command which accepts a JSON object used to set the values of the command's other options in bulk, e.g.:
+
+	app.FlagsFromJSON("flags-from-json")
+
+	$ app --flags-from-json '{"output": "x", "count": 3, "tag": ["a", "b"], "v": true}'
+
+The object keys are the option names without the dashes.
+Slice options accept an array of values, while the other options accept a string, a number or a boolean.
+
+The options explicitly set in the call arguments take precedence over the JSON object values.
+Unknown keys and invalid values are reported as usage errors.
+*/
+func (c *Cmd) FlagsFromJSON(name string) {
+	c.String(StringOpt{
+		Name:      name,
+		Value:     "",
+		Desc:      "Set the options values from a JSON object",
+		HideValue: true,
+	})
+	names := mkOptStrs(name)
+	c.jsonFlags = c.optionsIdx[names[0]]
+}
+
+func (c *Cmd) applyJSONFlags() error {
+	if c.jsonFlags == nil {
+		return nil
+	}
+	js, _ := c.jsonFlags.get().(string)
+	if len(strings.TrimSpace(js)) == 0 {
+		return nil
+	}
+
+	dec := json.NewDecoder(strings.NewReader(js))
+	dec.UseNumber()
+	var values map[string]interface{}
+	if err := dec.Decode(&values); err != nil {
+		return fmt.Errorf("invalid JSON object for option %s: %v", c.jsonFlags.displayName(), err)
+	}
+
+	return c.applyValues(values, SourceJSON, fmt.Sprintf("option %s", c.jsonFlags.displayName()))
+}
+
+// applyValues sets the options values from values, whose keys are the options names without the dashes, e.g. decoded from a JSON object.
+// The options explicitly set in the call arguments are left untouched
+func (c *Cmd) applyValues(values map[string]interface{}, source Source, from string) error {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		opt, found := c.optionsIdx[mkOptStrs(key)[0]]
+		if !found || opt == c.jsonFlags || opt == c.configFile {
+			return fmt.Errorf("unknown option %s in %s", key, from)
+		}
+		if opt.source == SourceCLI {
+			continue
+		}
+		if err := opt.checkImmutable(source); err != nil {
+			return err
+		}
+		if err := opt.setDecoded(values[key]); err != nil {
+			return fmt.Errorf("invalid value for option %s in %s: %v", key, from, err)
+		}
+		opt.source = source
+	}
+	return nil
+}
+
+// setDecoded sets the option value from v, a value decoded from a JSON object or a config file
+func (o *opt) setDecoded(v interface{}) error {
+	vs, isArray := v.([]interface{})
+	if o.isMulti() != isArray {
+		if isArray {
+			return fmt.Errorf("expected a single value, got %v", v)
+		}
+		return fmt.Errorf("expected an array, got %v", v)
+	}
+	if !isArray {
+		vs = []interface{}{v}
+	}
+
+	strs := make([]string, len(vs))
+	for i, v := range vs {
+		switch x := v.(type) {
+		case string:
+			strs[i] = x
+		case json.Number:
+			strs[i] = x.String()
+		case bool:
+			strs[i] = strconv.FormatBool(x)
+		case int, int64, uint64, float64:
+			strs[i] = fmt.Sprint(x)
+		default:
+			return fmt.Errorf("unsupported value %v", v)
+		}
+		if o.isBool() {
+			strs[i] = decodedBool(strs[i])
+		}
+	}
+
+	return o.setValues(strs)
+}
+
+// decodedBool normalizes the common representations of the booleans in the config files, e.g. `yes` or `off`, to `true` or `false`.
+// The other values are returned untouched, to be parsed by strconv.ParseBool, e.g. `1` or `false`
+func decodedBool(s string) string {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "yes", "y", "on":
+		return "true"
+	case "no", "n", "off":
+		return "false"
+	default:
+		return s
+	}
+}
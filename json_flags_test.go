@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlagsFromJSON(t *testing.T) {
+	defer suppressOutput()()
+
+	var (
+		output  *string
+		count   *int
+		verbose *bool
+		tags    *[]string
+		called  bool
+	)
+	init := func(app *Cli) {
+		called = false
+		output = app.StringOpt("o output", "out", "")
+		count = app.IntOpt("count", 1, "")
+		verbose = app.BoolOpt("v", false, "")
+		tags = app.StringsOpt("t tag", []string{"default"}, "")
+		app.FlagsFromJSON("flags-from-json")
+		app.Action = func() {
+			called = true
+		}
+	}
+
+	app := testApp(init)
+	err := app.Run([]string{"app", "--flags-from-json", `{"output": "x", "count": 3, "v": true, "tag": ["a", "b"]}`})
+	require.Nil(t, err)
+	require.True(t, called)
+	require.Equal(t, "x", *output)
+	require.Equal(t, 3, *count)
+	require.True(t, *verbose)
+	require.Equal(t, []string{"a", "b"}, *tags)
+	require.Equal(t, SourceJSON, app.optionsIdx["--count"].source)
+
+	app = testApp(init)
+	err = app.Run([]string{"app", "--count", "7", "--flags-from-json", `{"output": "x", "count": 3}`, "-o", "y"})
+	require.Nil(t, err)
+	require.Equal(t, "y", *output, "explicit options should override the JSON values")
+	require.Equal(t, 7, *count, "explicit options should override the JSON values")
+	require.Equal(t, SourceCLI, app.optionsIdx["--count"].source)
+
+	app = testApp(init)
+	err = app.Run([]string{"app", "--count", "7"})
+	require.Nil(t, err)
+	require.Equal(t, "out", *output)
+	require.Equal(t, 7, *count)
+
+	badCases := []struct {
+		js  string
+		msg string
+	}{
+		{`{"outptu": "x"}`, "unknown option outptu in option --flags-from-json"},
+		{`{"flags-from-json": "{}"}`, "unknown option flags-from-json in option --flags-from-json"},
+		{`{"count": "three"}`, `invalid value for option count in option --flags-from-json: strconv.ParseInt: parsing "three": invalid syntax`},
+		{`{"count": 1.5}`, `invalid value for option count in option --flags-from-json: strconv.ParseInt: parsing "1.5": invalid syntax`},
+		{`{"count": [1, 2]}`, "invalid value for option count in option --flags-from-json: expected a single value, got [1 2]"},
+		{`{"tag": "a"}`, "invalid value for option tag in option --flags-from-json: expected an array, got a"},
+		{`{"output": {"a": 1}}`, "invalid value for option output in option --flags-from-json: unsupported value map[a:1]"},
+		{`["x"]`, "invalid JSON object for option --flags-from-json: "},
+	}
+
+	for _, cas := range badCases {
+		app = testApp(init)
+		err = app.Run([]string{"app", "--flags-from-json", cas.js})
+		require.NotNil(t, err, "json %s should have failed", cas.js)
+		require.True(t, strings.HasPrefix(err.Error(), cas.msg), "unexpected error %s", err)
+		require.False(t, called, "action should not have been called")
+	}
+}
@@ -75,6 +75,17 @@ func (o *optMatcher) match(args []string, c *parseContext) (bool, []string) {
 			}
 			idx += consumed
 
+		case c.singleDashLongOpts && o.isSingleDashLongOpt(arg):
+			matched, consumed, nargs := o.matchLongOpt(replaceStringAt(idx, "-"+arg, args), idx, c)
+
+			if matched {
+				return true, nargs
+			}
+			if consumed == 0 {
+				return false, args
+			}
+			idx += consumed
+
 		case strings.HasPrefix(arg, "-"):
 			matched, consumed, nargs := o.matchShortOpt(args, idx, c)
 
@@ -95,14 +106,18 @@ func (o *optMatcher) match(args []string, c *parseContext) (bool, []string) {
 
 func (o *optMatcher) matchLongOpt(args []string, idx int, c *parseContext) (bool, int, []string) {
 	arg := args[idx]
-	kv := strings.Split(arg, "=")
+	kv := strings.SplitN(arg, "=", 2)
 	name := kv[0]
 	opt, found := o.optionsIdx[name]
 	if !found {
 		return false, 0, args
 	}
 
+	negated := opt.isNegatedName(name)
 	switch {
+	case len(kv) == 2 && negated:
+		// a negated form accepts no value, e.g. `--no-force=true`
+		return false, 0, args
 	case len(kv) == 2:
 		if opt != o.theOne {
 			return false, 1, args
@@ -110,11 +125,15 @@ func (o *optMatcher) matchLongOpt(args []string, idx int, c *parseContext) (bool
 		value := kv[1]
 		c.opts[o.theOne] = append(c.opts[o.theOne], value)
 		return true, 1, removeStringAt(idx, args)
-	case opt.isBool():
+	case opt.isFlag():
 		if opt != o.theOne {
 			return false, 1, args
 		}
-		c.opts[o.theOne] = append(c.opts[o.theOne], "true")
+		value := opt.flagValue()
+		if negated {
+			value = "false"
+		}
+		c.opts[o.theOne] = append(c.opts[o.theOne], value)
 		return true, 1, removeStringAt(idx, args)
 	default:
 		if len(args[idx:]) < 2 {
@@ -132,6 +151,16 @@ func (o *optMatcher) matchLongOpt(args []string, idx int, c *parseContext) (bool
 	}
 }
 
+// isSingleDashLongOpt returns true if arg is a long option name called with a single dash, e.g. `-force` or `-output=x`
+func (o *optMatcher) isSingleDashLongOpt(arg string) bool {
+	name := strings.SplitN(arg, "=", 2)[0]
+	if len(name) <= 2 {
+		return false
+	}
+	_, found := o.optionsIdx["-"+name]
+	return found
+}
+
 func (o *optMatcher) matchShortOpt(args []string, idx int, c *parseContext) (bool, int, []string) {
 	arg := args[idx]
 	if len(arg) < 2 {
@@ -164,13 +193,13 @@ func (o *optMatcher) matchShortOpt(args []string, idx int, c *parseContext) (boo
 			return false, 0, args
 		}
 
-		if opt.isBool() {
+		if opt.isFlag() {
 			if opt != o.theOne {
 				remIdx++
 				continue
 			}
 
-			c.opts[o.theOne] = append(c.opts[o.theOne], "true")
+			c.opts[o.theOne] = append(c.opts[o.theOne], opt.flagValue())
 			newRem := rem[:remIdx] + rem[remIdx+1:]
 			if newRem == "" {
 				return true, 1, removeStringAt(idx, args)
@@ -138,6 +138,36 @@ func TestOptMatcher(t *testing.T) {
 	}
 }
 
+func TestOptionalValueOptMatcher(t *testing.T) {
+	colorOpt := &opt{names: []string{"-c", "--color"}, value: reflect.New(reflect.TypeOf("")), optionalValue: "always"}
+	optMatcher := &optMatcher{
+		theOne: colorOpt,
+		optionsIdx: map[string]*opt{
+			"-c":      colorOpt,
+			"--color": colorOpt,
+			"-a":      &opt{names: []string{"-a"}, value: reflect.New(reflect.TypeOf(true))},
+		},
+	}
+	cases := []struct {
+		args  []string
+		nargs []string
+		val   []string
+	}{
+		{[]string{"-c", "x"}, []string{"x"}, []string{"always"}},
+		{[]string{"-c=never", "x"}, []string{"x"}, []string{"never"}},
+		{[]string{"-ac", "x"}, []string{"-a", "x"}, []string{"always"}},
+		{[]string{"--color", "x"}, []string{"x"}, []string{"always"}},
+		{[]string{"--color=never", "x"}, []string{"x"}, []string{"never"}},
+	}
+	for _, cas := range cases {
+		pc := newParseContext()
+		ok, nargs := optMatcher.match(cas.args, &pc)
+		require.True(t, ok, "opt should match")
+		require.Equal(t, cas.nargs, nargs, "opt should consume the option name but not the next arg")
+		require.Equal(t, cas.val, pc.opts[colorOpt], "the optional value should be stored as the option's value")
+	}
+}
+
 func TestOptsMatcher(t *testing.T) {
 	opts := optsMatcher{
 		options: []*opt{
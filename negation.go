@@ -0,0 +1,70 @@
+package cli
+
+import "strings"
+
+// addNegatedNames registers the `--no-<name>` form of the long names of a bool option, setting it to false,
+// unless the name already starts with `no-` or the negated name is already taken by another option
+func (c *Cmd) addNegatedNames(o *opt) {
+	if !o.isBool() || o.isCounter() || o.hasOptionalValue() {
+		return
+	}
+	for _, name := range o.names {
+		if !strings.HasPrefix(name, "--") || strings.HasPrefix(name, "--no-") {
+			continue
+		}
+		negated := "--no-" + strings.TrimPrefix(name, "--")
+		if _, taken := c.optionsIdx[negated]; taken {
+			continue
+		}
+		o.negatedNames = append(o.negatedNames, negated)
+		c.optionsIdx[negated] = o
+	}
+}
+
+// removeNegatedNames unregisters the negated forms of the option, e.g. for the version option which has nothing to turn off
+func (c *Cmd) removeNegatedNames(o *opt) {
+	for _, name := range o.negatedNames {
+		if c.optionsIdx[name] == o {
+			delete(c.optionsIdx, name)
+		}
+	}
+	o.negatedNames = nil
+}
+
+// releaseNegatedName unregisters name if it is the negated form of an already declared option, so that it can be used by a new option
+func (c *Cmd) releaseNegatedName(name string) {
+	o, found := c.optionsIdx[name]
+	if !found || !o.isNegatedName(name) {
+		return
+	}
+	delete(c.optionsIdx, name)
+	res := []string{}
+	for _, n := range o.negatedNames {
+		if n != name {
+			res = append(res, n)
+		}
+	}
+	o.negatedNames = res
+}
+
+// isNegatedName returns true if name is one of the `--no-<name>` forms of the option
+func (o *opt) isNegatedName(name string) bool {
+	for _, n := range o.negatedNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// helpNames returns the option names as shown in the help message, the long names having a negated form being shown as `--[no-]name`
+func (o *opt) helpNames() string {
+	res := make([]string, len(o.names))
+	for i, name := range o.names {
+		res[i] = name
+		if o.isNegatedName("--no-" + strings.TrimPrefix(name, "--")) {
+			res[i] = "--[no-]" + strings.TrimPrefix(name, "--")
+		}
+	}
+	return strings.Join(res, ", ")
+}
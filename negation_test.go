@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegatedBoolOpt(t *testing.T) {
+	var (
+		force, color, noCache *bool
+		count                 *int
+	)
+	init := func(c *Cmd) {
+		force = c.BoolOpt("f force", true, "")
+		color = c.Bool(BoolOpt{Name: "color", Value: false})
+		noCache = c.BoolOpt("no-cache", false, "")
+		count = c.IntCounter(IntCounterOpt{Name: "v verbose"})
+	}
+
+	okCmd(t, "[OPTIONS]", init, []string{"--no-force"})
+	require.False(t, *force)
+
+	okCmd(t, "[OPTIONS]", init, []string{"--no-force", "--force"})
+	require.True(t, *force, "the last occurrence should win")
+
+	okCmd(t, "[OPTIONS]", init, []string{"--color", "--no-color"})
+	require.False(t, *color)
+
+	okCmd(t, "[OPTIONS]", init, []string{"--no-cache"})
+	require.True(t, *noCache, "an option named no-... should not be negated")
+
+	okCmd(t, "[--no-force]", init, []string{"--no-force"})
+	require.False(t, *force)
+
+	failCmd(t, "[OPTIONS]", init, []string{"--no-force=true"})
+	failCmd(t, "[OPTIONS]", init, []string{"--no-force=false"})
+	failCmd(t, "[OPTIONS]", init, []string{"--no-f"})
+	failCmd(t, "[OPTIONS]", init, []string{"--no-no-cache"})
+	failCmd(t, "[OPTIONS]", init, []string{"--no-verbose"})
+	require.Equal(t, 0, *count)
+}
+
+func TestNegatedNameTaken(t *testing.T) {
+	var color, noColor *bool
+	init := func(c *Cmd) {
+		noColor = c.BoolOpt("no-color", false, "")
+		color = c.BoolOpt("color", true, "")
+	}
+
+	okCmd(t, "[OPTIONS]", init, []string{"--no-color"})
+	require.True(t, *noColor, "an explicitly declared option should take precedence")
+	require.True(t, *color)
+
+	cmd := &Cmd{optionsIdx: map[string]*opt{}}
+	cmd.BoolOpt("color", true, "")
+	cmd.BoolOpt("no-color", false, "")
+	require.Equal(t, "--color", cmd.optionsIdx["--color"].helpNames(), "an option declared afterwards should take over the negated name")
+	require.Equal(t, "--no-color", cmd.optionsIdx["--no-color"].names[0])
+}
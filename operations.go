@@ -0,0 +1,126 @@
+package cli
+
+import "strings"
+
+/*
+Operation is a value passed to one of the options declared using Operations, e.g. `--set a=1`
+*/
+type Operation struct {
+	// The option the value was passed to, i.e. its longest name without the dashes, e.g. `set`
+	Name string
+	// The value as passed in the call arguments, e.g. `a=1`
+	Raw string
+	// The part of the value before its first `=`, or the whole value if it has none, e.g. `a`
+	Key string
+	// The part of the value after its first `=`, e.g. `1`
+	Value string
+	// A boolean telling if the value contains a `=`, i.e. if Value was set, e.g. false for `--unset c`
+	HasValue bool
+}
+
+// OperationOpt describes an option declared using Operations
+type OperationOpt struct {
+	// A space separated list of the option names, e.g. `s set`
+	Name string
+	// The option description as will be shown in help messages
+	Desc string
+}
+
+/*
+Operations adds repeatable string options to the command, and returns a pointer to the list of the values passed to any of them,
+in the order they were passed, e.g. for a tool applying changes to a config:
+
+	ops := cmd.Operations(
+		OperationOpt{Name: "s set", Desc: "Set a key, e.g. key=value"},
+		OperationOpt{Name: "unset", Desc: "Remove a key"},
+	)
+
+	$ app --set a=1 --unset b --set c=2
+	// *ops is [{set a=1 a 1 true} {unset b b  false} {set c=2 c 2 true}]
+
+The pointed list will be populated when the app is run and the call arguments get parsed
+*/
+func (c *Cmd) Operations(ops ...OperationOpt) *[]Operation {
+	res := &[]Operation{}
+	for _, op := range ops {
+		c.Strings(StringsOpt{Name: op.Name, Desc: op.Desc, Value: nil})
+		c.optionsIdx[mkOptStrs(op.Name)[0]].operations = res
+	}
+	return res
+}
+
+// collectOperations fills the operations lists with the values of the options declared using Operations, in the order of args
+func (c *Cmd) collectOperations(args []string) {
+	values := map[*opt][]string{}
+	for _, opt := range c.options {
+		if opt.operations != nil {
+			*opt.operations = []Operation{}
+			values[opt] = opt.get().([]string)
+		}
+	}
+	if len(values) == 0 {
+		return
+	}
+
+	for _, opt := range c.optsOrder(args) {
+		vs, found := values[opt]
+		if !found || len(vs) == 0 {
+			continue
+		}
+		*opt.operations = append(*opt.operations, newOperation(opt, vs[0]))
+		values[opt] = vs[1:]
+	}
+	// in case some values could not be located in args, e.g. if set from a config file
+	for _, opt := range c.options {
+		for _, v := range values[opt] {
+			*opt.operations = append(*opt.operations, newOperation(opt, v))
+		}
+	}
+}
+
+// optsOrder returns the command's options in the order they appear in args, once per occurrence
+func (c *Cmd) optsOrder(args []string) []*opt {
+	res := []*opt{}
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if arg == "-" || !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name := strings.SplitN(arg, "=", 2)[0]
+		if opt, found := c.optionsIdx[name]; found {
+			res = append(res, opt)
+			continue
+		}
+		if c.SingleDashLongOpts {
+			if opt, found := c.optionsIdx["-"+name]; found {
+				res = append(res, opt)
+				continue
+			}
+		}
+		if strings.HasPrefix(arg, "--") {
+			continue
+		}
+		// a group of short options, the first one accepting a value ending it
+		for _, r := range arg[1:] {
+			opt, found := c.optionsIdx["-"+string(r)]
+			if !found {
+				break
+			}
+			res = append(res, opt)
+			if !opt.isFlag() {
+				break
+			}
+		}
+	}
+	return res
+}
+
+func newOperation(o *opt, raw string) Operation {
+	res := Operation{Name: strings.TrimLeft(o.displayName(), "-"), Raw: raw, Key: raw}
+	if kv := strings.SplitN(raw, "=", 2); len(kv) == 2 {
+		res.Key, res.Value, res.HasValue = kv[0], kv[1], true
+	}
+	return res
+}
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOperations(t *testing.T) {
+	defer exitShouldNotCalled(t)()
+	defer suppressOutput()()
+
+	var ops *[]Operation
+	app := App("app", "")
+	app.BoolOpt("v verbose", false, "")
+	ops = app.Operations(
+		OperationOpt{Name: "s set", Desc: "Set a key"},
+		OperationOpt{Name: "unset", Desc: "Remove a key"},
+	)
+	app.Action = func() {}
+
+	require.Nil(t, app.Run([]string{"app", "--set", "a=1", "--unset", "c", "-v", "--set=b=2=3", "-vs", "d=", "--unset=a", "-se"}))
+	require.Equal(t, []Operation{
+		{Name: "set", Raw: "a=1", Key: "a", Value: "1", HasValue: true},
+		{Name: "unset", Raw: "c", Key: "c"},
+		{Name: "set", Raw: "b=2=3", Key: "b", Value: "2=3", HasValue: true},
+		{Name: "set", Raw: "d=", Key: "d", Value: "", HasValue: true},
+		{Name: "unset", Raw: "a", Key: "a"},
+		{Name: "set", Raw: "e", Key: "e"},
+	}, *ops)
+
+	app.Reset()
+	require.Empty(t, *ops)
+
+	require.Nil(t, app.Run([]string{"app", "--unset", "x", "--set", "x=1"}))
+	require.Equal(t, []Operation{
+		{Name: "unset", Raw: "x", Key: "x"},
+		{Name: "set", Raw: "x=1", Key: "x", Value: "1", HasValue: true},
+	}, *ops)
+
+	app.Reset()
+	require.Nil(t, app.Run([]string{"app", "-v"}))
+	require.Empty(t, *ops)
+}
@@ -3,7 +3,10 @@ package cli
 import (
 	"fmt"
 	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // BoolOpt describes a boolean option
@@ -17,10 +20,31 @@ type BoolOpt struct {
 	Desc string
 	// A space separated list of environment variables names to be used to initialize this option
 	EnvVar string
+	// A boolean to require the option to be set from one of its EnvVar environment variables, and never in the call arguments,
+	// e.g. to enforce the sourcing of secrets from the environment
+	EnvRequired bool
+	// A boolean to reject the changes of the option value by a source once set by another one, e.g. by the call arguments once set from an environment variable
+	Immutable bool
+	// A boolean to leave the option out of the shell completion candidates (see Complete and WriteCompletionIndex), e.g. for a debug option.
+	// The option is still accepted in the call arguments, and listed in the help message
+	NoComplete bool
 	// The option's inital value
 	Value bool
+	// A boolean to set the option to false when one of the EnvVar environment variables is set to any non empty value,
+	// following conventions like `NO_COLOR`, instead of parsing the variable value
+	EnvInvert bool
+	// A boolean to reject the call arguments if they specify this option more than once, instead of keeping the last value
+	Once bool
+	// If set, the option is only listed in the help message when this function returns true, e.g. for experimental options.
+	// The option is always accepted in the call arguments
+	VisibleWhen func() bool
 	// A boolean to display or not the current value of the option in the help message
 	HideValue bool
+	// The representations of the true and false values in the help message, e.g. `on` and `off`, instead of `true` and `false`.
+	// The call arguments still accept the values accepted by strconv.ParseBool
+	TrueStr, FalseStr string
+	// If set, the function called with the option value every time it gets set, e.g. from the call arguments or from an environment variable
+	OnSet func(bool)
 }
 
 // StringOpt describes a string option
@@ -34,10 +58,52 @@ type StringOpt struct {
 	Desc string
 	// A space separated list of environment variables names to be used to initialize this option
 	EnvVar string
+	// A boolean to require the option to be set from one of its EnvVar environment variables, and never in the call arguments,
+	// e.g. to enforce the sourcing of secrets from the environment
+	EnvRequired bool
+	// A boolean to reject the changes of the option value by a source once set by another one, e.g. by the call arguments once set from an environment variable
+	Immutable bool
+	// A boolean to leave the option out of the shell completion candidates (see Complete and WriteCompletionIndex), e.g. for a debug option.
+	// The option is still accepted in the call arguments, and listed in the help message
+	NoComplete bool
 	// The option's inital value
 	Value string
+	// If not empty, the option can also be used without a value, e.g. `--color` instead of `--color=always`,
+	// in which case it is set to OptionalValue. An explicit value can then only be passed using the `=` form
+	OptionalValue string
+	// A boolean to reject empty or whitespace-only values passed in the call arguments
+	NonEmpty bool
+	// A boolean to reject the call arguments if they specify this option more than once, instead of keeping the last value
+	Once bool
+	// If set, the function returning the values the option accepts, e.g. fetched from an API.
+	// It is called when the first value gets validated, and its result is cached until the call arguments get parsed again
+	ChoiceFunc func() []string
+	// The fixed set of values the option accepts, e.g. `debug info warn`, listed in the help message. Cannot be used with ChoiceFunc
+	Choices []string
+	// A boolean to match the values against Choices (or ChoiceFunc) ignoring their case, the matching choice being stored as is
+	CaseInsensitive bool
+	// A regular expression the values passed in the call arguments must match, e.g. `^[a-z0-9-]+$`.
+	// An invalid expression panics when the option is declared
+	Pattern string
+	// An example value to be shown in the help message, e.g. `https://api.example.com`
+	Example string
+	// Functions applied in order to each value passed in the call arguments before it gets stored, e.g. to trim, normalize or validate it.
+	// The first error aborts the parsing
+	Transforms []func(string) (string, error)
+	// A boolean to allow a value of the form `exec:cmd args...`, read from an environment variable,
+	// to be replaced with the output of the command, e.g. `exec:cat /run/secrets/token`.
+	// The command is run directly, not by a shell, and its failure aborts the parsing
+	AllowExec bool
+	// A boolean to mask the option value, e.g. a password or a token, wherever the library outputs it:
+	// in the help message, the error messages, Trace, ToArgs, WriteConfig, WriteJSONSchema and OptionHistory
+	Secret bool
+	// If set, the option is only listed in the help message when this function returns true, e.g. for experimental options.
+	// The option is always accepted in the call arguments
+	VisibleWhen func() bool
 	// A boolean to display or not the current value of the option in the help message
 	HideValue bool
+	// If set, the function called with the option value every time it gets set, e.g. from the call arguments or from an environment variable
+	OnSet func(string)
 }
 
 // IntOpt describes an int option
@@ -51,8 +117,165 @@ type IntOpt struct {
 	Desc string
 	// A space separated list of environment variables names to be used to initialize this option
 	EnvVar string
+	// A boolean to require the option to be set from one of its EnvVar environment variables, and never in the call arguments,
+	// e.g. to enforce the sourcing of secrets from the environment
+	EnvRequired bool
+	// A boolean to reject the changes of the option value by a source once set by another one, e.g. by the call arguments once set from an environment variable
+	Immutable bool
+	// A boolean to leave the option out of the shell completion candidates (see Complete and WriteCompletionIndex), e.g. for a debug option.
+	// The option is still accepted in the call arguments, and listed in the help message
+	NoComplete bool
 	// The option's inital value
 	Value int
+	// The fixed set of values the option accepts, e.g. `12 13`, listed in the help message
+	Choices []int
+	// If set, the minimum value the option accepts, shown in the help message
+	Min *int
+	// If set, the maximum value the option accepts, shown in the help message
+	Max *int
+	// A boolean to reject the call arguments if they specify this option more than once, instead of keeping the last value
+	Once bool
+	// An example value to be shown in the help message, e.g. `8080`
+	Example string
+	// Functions applied in order to each value passed in the call arguments before it gets stored, e.g. to trim, normalize or validate it.
+	// The first error aborts the parsing
+	Transforms []func(string) (string, error)
+	// A boolean to accept underscores between digits, e.g. `1_000_000`, like in Go literals, in the call arguments and the env vars.
+	// The underscores are removed before the Transforms are applied
+	AllowUnderscore bool
+	// If set, the option is only listed in the help message when this function returns true, e.g. for experimental options.
+	// The option is always accepted in the call arguments
+	VisibleWhen func() bool
+	// A boolean to display or not the current value of the option in the help message
+	HideValue bool
+	// If set, the function called with the option value every time it gets set, e.g. from the call arguments or from an environment variable
+	OnSet func(int)
+}
+
+// Float64Opt describes a float64 option
+type Float64Opt struct {
+	Float64Param
+
+	// A space separated list of the option names *WITHOUT* the dashes, e.g. `f force` and *NOT* `-f --force`.
+	// The one letter names will then be called with a single dash (short option), the others with two (long options).
+	Name string
+	// The option description as will be shown in help messages
+	Desc string
+	// A space separated list of environment variables names to be used to initialize this option
+	EnvVar string
+	// A boolean to require the option to be set from one of its EnvVar environment variables, and never in the call arguments,
+	// e.g. to enforce the sourcing of secrets from the environment
+	EnvRequired bool
+	// A boolean to reject the changes of the option value by a source once set by another one, e.g. by the call arguments once set from an environment variable
+	Immutable bool
+	// A boolean to leave the option out of the shell completion candidates (see Complete and WriteCompletionIndex), e.g. for a debug option.
+	// The option is still accepted in the call arguments, and listed in the help message
+	NoComplete bool
+	// The option's inital value
+	Value float64
+	// A boolean to reject the call arguments if they specify this option more than once, instead of keeping the last value
+	Once bool
+	// An example value to be shown in the help message, e.g. `0.5`
+	Example string
+	// Functions applied in order to each value passed in the call arguments before it gets stored, e.g. to trim, normalize or validate it.
+	// The first error aborts the parsing
+	Transforms []func(string) (string, error)
+	// If set, the option is only listed in the help message when this function returns true, e.g. for experimental options.
+	// The option is always accepted in the call arguments
+	VisibleWhen func() bool
+	// A boolean to display or not the current value of the option in the help message
+	HideValue bool
+}
+
+// DurationOpt describes a duration option, e.g. `1h30m`, `30d` or `1w2d`
+type DurationOpt struct {
+	DurationParam
+
+	// A space separated list of the option names *WITHOUT* the dashes, e.g. `f force` and *NOT* `-f --force`.
+	// The one letter names will then be called with a single dash (short option), the others with two (long options).
+	Name string
+	// The option description as will be shown in help messages
+	Desc string
+	// A space separated list of environment variables names to be used to initialize this option
+	EnvVar string
+	// A boolean to require the option to be set from one of its EnvVar environment variables, and never in the call arguments,
+	// e.g. to enforce the sourcing of secrets from the environment
+	EnvRequired bool
+	// A boolean to reject the changes of the option value by a source once set by another one, e.g. by the call arguments once set from an environment variable
+	Immutable bool
+	// A boolean to leave the option out of the shell completion candidates (see Complete and WriteCompletionIndex), e.g. for a debug option.
+	// The option is still accepted in the call arguments, and listed in the help message
+	NoComplete bool
+	// The option's inital value
+	Value time.Duration
+	// If set, the option is only listed in the help message when this function returns true, e.g. for experimental options.
+	// The option is always accepted in the call arguments
+	VisibleWhen func() bool
+	// A boolean to display or not the current value of the option in the help message
+	HideValue bool
+}
+
+// IntCounterOpt describes an int option which is incremented every time it appears in the call arguments, e.g. `-vvv`
+type IntCounterOpt struct {
+	IntCounterParam
+
+	// A space separated list of the option names *WITHOUT* the dashes, e.g. `v verbose` and *NOT* `-v --verbose`.
+	// The one letter names will then be called with a single dash (short option), the others with two (long options).
+	Name string
+	// The option description as will be shown in help messages
+	Desc string
+	// A space separated list of environment variables names to be used to initialize this option
+	EnvVar string
+	// A boolean to require the option to be set from one of its EnvVar environment variables, and never in the call arguments,
+	// e.g. to enforce the sourcing of secrets from the environment
+	EnvRequired bool
+	// A boolean to reject the changes of the option value by a source once set by another one, e.g. by the call arguments once set from an environment variable
+	Immutable bool
+	// A boolean to leave the option out of the shell completion candidates (see Complete and WriteCompletionIndex), e.g. for a debug option.
+	// The option is still accepted in the call arguments, and listed in the help message
+	NoComplete bool
+	// The option's inital value
+	Value int
+	// The amount added to the option's value every time it appears in the call arguments.
+	// Defaults to 1 if left empty. A negative step can be used to count down, e.g. for a `-q` quietness flag
+	Step int
+	// If set, the option is only listed in the help message when this function returns true, e.g. for experimental options.
+	// The option is always accepted in the call arguments
+	VisibleWhen func() bool
+	// A boolean to display or not the current value of the option in the help message
+	HideValue bool
+	// If set, the function called with the option value every time it gets incremented or set, e.g. from an environment variable
+	OnSet func(int)
+}
+
+// UnitOpt describes an option accepting a quantity with a unit, e.g. `5km` or `20C`, whose value is stored converted to a base unit
+type UnitOpt struct {
+	UnitParam
+
+	// A space separated list of the option names *WITHOUT* the dashes, e.g. `d distance` and *NOT* `-d --distance`.
+	// The one letter names will then be called with a single dash (short option), the others with two (long options).
+	Name string
+	// The option description as will be shown in help messages
+	Desc string
+	// A space separated list of environment variables names to be used to initialize this option
+	EnvVar string
+	// A boolean to require the option to be set from one of its EnvVar environment variables, and never in the call arguments,
+	// e.g. to enforce the sourcing of secrets from the environment
+	EnvRequired bool
+	// A boolean to reject the changes of the option value by a source once set by another one, e.g. by the call arguments once set from an environment variable
+	Immutable bool
+	// A boolean to leave the option out of the shell completion candidates (see Complete and WriteCompletionIndex), e.g. for a debug option.
+	// The option is still accepted in the call arguments, and listed in the help message
+	NoComplete bool
+	// The option's inital value, in the base unit
+	Value float64
+	// The base unit suffix, e.g. `m`, the values being converted to this unit. A value without a unit is also taken as being in the base unit
+	Base string
+	// The other accepted units by suffix, e.g. `{"km": {Factor: 1000}, "cm": {Factor: 0.01}}`
+	Units map[string]Unit
+	// If set, the option is only listed in the help message when this function returns true, e.g. for experimental options.
+	// The option is always accepted in the call arguments
+	VisibleWhen func() bool
 	// A boolean to display or not the current value of the option in the help message
 	HideValue bool
 }
@@ -67,10 +290,29 @@ type StringsOpt struct {
 	// The option description as will be shown in help messages
 	Desc string
 	// A space separated list of environment variables names to be used to initialize this option.
-	// The env variable should contain a comma separated list of values
+	// The env variable should contain a comma (or Sep) separated list of values
 	EnvVar string
+	// A boolean to require the option to be set from one of its EnvVar environment variables, and never in the call arguments,
+	// e.g. to enforce the sourcing of secrets from the environment
+	EnvRequired bool
+	// A boolean to reject the changes of the option value by a source once set by another one, e.g. by the call arguments once set from an environment variable
+	Immutable bool
+	// A boolean to leave the option out of the shell completion candidates (see Complete and WriteCompletionIndex), e.g. for a debug option.
+	// The option is still accepted in the call arguments, and listed in the help message
+	NoComplete bool
+	// The separator of the values in the EnvVar environment variables and in each value passed in the call arguments, e.g. `,` for `--tag a,b`.
+	// If empty, the environment variables values are split on commas while the call arguments values are not split
+	Sep string
 	// The option's inital value
 	Value []string
+	// An example value to be shown in the help message, e.g. `web,db`
+	Example string
+	// Functions applied in order to each value passed in the call arguments before it gets stored, e.g. to trim, normalize or validate it.
+	// The first error aborts the parsing
+	Transforms []func(string) (string, error)
+	// If set, the option is only listed in the help message when this function returns true, e.g. for experimental options.
+	// The option is always accepted in the call arguments
+	VisibleWhen func() bool
 	// A boolean to display or not the current value of the option in the help message
 	HideValue bool
 }
@@ -85,10 +327,106 @@ type IntsOpt struct {
 	// The option description as will be shown in help messages
 	Desc string
 	// A space separated list of environment variables names to be used to initialize this option.
-	// The env variable should contain a comma separated list of values
+	// The env variable should contain a comma (or Sep) separated list of values
 	EnvVar string
+	// A boolean to require the option to be set from one of its EnvVar environment variables, and never in the call arguments,
+	// e.g. to enforce the sourcing of secrets from the environment
+	EnvRequired bool
+	// A boolean to reject the changes of the option value by a source once set by another one, e.g. by the call arguments once set from an environment variable
+	Immutable bool
+	// A boolean to leave the option out of the shell completion candidates (see Complete and WriteCompletionIndex), e.g. for a debug option.
+	// The option is still accepted in the call arguments, and listed in the help message
+	NoComplete bool
+	// The separator of the values in the EnvVar environment variables and in each value passed in the call arguments, e.g. `,` for `--tag a,b`.
+	// If empty, the environment variables values are split on commas while the call arguments values are not split
+	Sep string
 	// The option's inital value
 	Value []int
+	// An example value to be shown in the help message, e.g. `80,443`
+	Example string
+	// Functions applied in order to each value passed in the call arguments before it gets stored, e.g. to trim, normalize or validate it.
+	// The first error aborts the parsing
+	Transforms []func(string) (string, error)
+	// A boolean to accept underscores between digits, e.g. `1_000_000`, like in Go literals, in the call arguments and the env vars.
+	// The underscores are removed before the Transforms are applied
+	AllowUnderscore bool
+	// If set, the option is only listed in the help message when this function returns true, e.g. for experimental options.
+	// The option is always accepted in the call arguments
+	VisibleWhen func() bool
+	// A boolean to display or not the current value of the option in the help message
+	HideValue bool
+}
+
+// DurationsOpt describes a duration slice option, each value being a duration like `1h30m`, `30d` or `1w2d`
+type DurationsOpt struct {
+	DurationsParam
+
+	// A space separated list of the option names *WITHOUT* the dashes, e.g. `f force` and *NOT* `-f --force`.
+	// The one letter names will then be called with a single dash (short option), the others with two (long options).
+	Name string
+	// The option description as will be shown in help messages
+	Desc string
+	// A space separated list of environment variables names to be used to initialize this option.
+	// The env variable should contain a comma (or Sep) separated list of values
+	EnvVar string
+	// A boolean to require the option to be set from one of its EnvVar environment variables, and never in the call arguments,
+	// e.g. to enforce the sourcing of secrets from the environment
+	EnvRequired bool
+	// A boolean to reject the changes of the option value by a source once set by another one, e.g. by the call arguments once set from an environment variable
+	Immutable bool
+	// A boolean to leave the option out of the shell completion candidates (see Complete and WriteCompletionIndex), e.g. for a debug option.
+	// The option is still accepted in the call arguments, and listed in the help message
+	NoComplete bool
+	// The separator of the values in the EnvVar environment variables and in each value passed in the call arguments, e.g. `,` for `--backoff 1s,2s`.
+	// If empty, the environment variables values are split on commas while the call arguments values are not split
+	Sep string
+	// The option's inital value
+	Value []time.Duration
+	// An example value to be shown in the help message, e.g. `1h,30m`
+	Example string
+	// Functions applied in order to each value passed in the call arguments before it gets stored, e.g. to trim, normalize or validate it.
+	// The first error aborts the parsing
+	Transforms []func(string) (string, error)
+	// If set, the option is only listed in the help message when this function returns true, e.g. for experimental options.
+	// The option is always accepted in the call arguments
+	VisibleWhen func() bool
+	// A boolean to display or not the current value of the option in the help message
+	HideValue bool
+}
+
+// Float64sOpt describes a float64 slice option
+type Float64sOpt struct {
+	Float64sParam
+
+	// A space separated list of the option names *WITHOUT* the dashes, e.g. `f force` and *NOT* `-f --force`.
+	// The one letter names will then be called with a single dash (short option), the others with two (long options).
+	Name string
+	// The option description as will be shown in help messages
+	Desc string
+	// A space separated list of environment variables names to be used to initialize this option.
+	// The env variable should contain a comma (or Sep) separated list of values
+	EnvVar string
+	// A boolean to require the option to be set from one of its EnvVar environment variables, and never in the call arguments,
+	// e.g. to enforce the sourcing of secrets from the environment
+	EnvRequired bool
+	// A boolean to reject the changes of the option value by a source once set by another one, e.g. by the call arguments once set from an environment variable
+	Immutable bool
+	// A boolean to leave the option out of the shell completion candidates (see Complete and WriteCompletionIndex), e.g. for a debug option.
+	// The option is still accepted in the call arguments, and listed in the help message
+	NoComplete bool
+	// The separator of the values in the EnvVar environment variables and in each value passed in the call arguments, e.g. `,` for `--weight 0.1,0.2`.
+	// If empty, the environment variables values are split on commas while the call arguments values are not split
+	Sep string
+	// The option's inital value
+	Value []float64
+	// An example value to be shown in the help message, e.g. `0.1,0.25`
+	Example string
+	// Functions applied in order to each value passed in the call arguments before it gets stored, e.g. to trim, normalize or validate it.
+	// The first error aborts the parsing
+	Transforms []func(string) (string, error)
+	// If set, the option is only listed in the help message when this function returns true, e.g. for experimental options.
+	// The option is always accepted in the call arguments
+	VisibleWhen func() bool
 	// A boolean to display or not the current value of the option in the help message
 	HideValue bool
 }
@@ -132,6 +470,46 @@ func (c *Cmd) IntOpt(name string, value int, desc string) *int {
 	return c.mkOpt(opt{name: name, desc: desc}, value).(*int)
 }
 
+/*
+IntChoiceOpt defines an int option on the command c named `name`, accepting only the `allowed` values, with an initial value of `value`
+and a description of `desc` which will be used in help messages, together with the allowed values.
+
+The name is a space separated list of the option names *WITHOUT* the dashes, e.g. `tls-version` and *NOT* `--tls-version`.
+The one letter names will then be called with a single dash (short option), the others with two (long options).
+
+The result should be stored in a variable (a pointer to an int) which will be populated when the app is run and the call arguments get parsed
+*/
+func (c *Cmd) IntChoiceOpt(name string, allowed []int, value int, desc string) *int {
+	return c.Int(IntOpt{Name: name, Choices: allowed, Value: value, Desc: desc})
+}
+
+/*
+Float64Opt defines a float64 option on the command c named `name`, with an initial value of `value` and a description of `desc` which will be used in help messages.
+
+The name is a space separated list of the option names *WITHOUT* the dashes, e.g. `t threshold` and *NOT* `-t --threshold`.
+The one letter names will then be called with a single dash (short option), the others with two (long options).
+
+
+The result should be stored in a variable (a pointer to a float64) which will be populated when the app is run and the call arguments get parsed
+*/
+func (c *Cmd) Float64Opt(name string, value float64, desc string) *float64 {
+	return c.mkOpt(opt{name: name, desc: desc}, value).(*float64)
+}
+
+/*
+DurationOpt defines a duration option on the command c named `name`, with an initial value of `value` and a description of `desc` which will be used in help messages.
+
+The name is a space separated list of the option names *WITHOUT* the dashes, e.g. `f force` and *NOT* `-f --force`.
+The one letter names will then be called with a single dash (short option), the others with two (long options).
+
+Besides the units accepted by time.ParseDuration, the value can use the `d` (24h) and `w` (7d) units, e.g. `30d` or `1w2d3h`.
+
+The result should be stored in a variable (a pointer to a time.Duration) which will be populated when the app is run and the call arguments get parsed
+*/
+func (c *Cmd) DurationOpt(name string, value time.Duration, desc string) *time.Duration {
+	return c.mkOpt(opt{name: name, desc: desc}, value).(*time.Duration)
+}
+
 /*
 StringsOpt defines a string slice option on the command c named `name`, with an initial value of `value` and a description of `desc` which will be used in help messages.
 
@@ -158,6 +536,76 @@ func (c *Cmd) IntsOpt(name string, value []int, desc string) *[]int {
 	return c.mkOpt(opt{name: name, desc: desc}, value).(*[]int)
 }
 
+/*
+IntCounterOpt defines an int counter option on the command c named `name`, with an initial value of `value` and a description of `desc` which will be used in help messages.
+
+The name is a space separated list of the option names *WITHOUT* the dashes, e.g. `v verbose` and *NOT* `-v --verbose`.
+The one letter names will then be called with a single dash (short option), the others with two (long options).
+
+The option expects no value in the call arguments: it is incremented by one every time it appears, e.g. `-v -v -v` or `-vvv`.
+Use IntCounter to declare a different step.
+
+The result should be stored in a variable (a pointer to an int) which will be populated when the app is run and the call arguments get parsed
+*/
+func (c *Cmd) IntCounterOpt(name string, value int, desc string) *int {
+	return c.IntCounter(IntCounterOpt{Name: name, Value: value, Desc: desc})
+}
+
+/*
+DurationsOpt defines a duration slice option on the command c named `name`, with an initial value of `value` and a description of `desc` which will be used in help messages.
+
+The name is a space separated list of the option names *WITHOUT* the dashes, e.g. `b backoff` and *NOT* `-b --backoff`.
+The one letter names will then be called with a single dash (short option), the others with two (long options).
+
+Each value can use the units accepted by time.ParseDuration, as well as the `d` (24h) and `w` (7d) units.
+
+The result should be stored in a variable (a pointer to a time.Duration slice) which will be populated when the app is run and the call arguments get parsed
+*/
+func (c *Cmd) DurationsOpt(name string, value []time.Duration, desc string) *[]time.Duration {
+	return c.mkOpt(opt{name: name, desc: desc}, value).(*[]time.Duration)
+}
+
+/*
+Float64sOpt defines a float64 slice option on the command c named `name`, with an initial value of `value` and a description of `desc` which will be used in help messages.
+
+The name is a space separated list of the option names *WITHOUT* the dashes, e.g. `w weight` and *NOT* `-w --weight`.
+The one letter names will then be called with a single dash (short option), the others with two (long options).
+
+The result should be stored in a variable (a pointer to a float64 slice) which will be populated when the app is run and the call arguments get parsed
+*/
+func (c *Cmd) Float64sOpt(name string, value []float64, desc string) *[]float64 {
+	return c.mkOpt(opt{name: name, desc: desc}, value).(*[]float64)
+}
+
+/*
+BoolFunc defines a boolean option on the command c named `name`, with an initial value of `value` and a description of `desc` which will be used in help messages.
+
+Instead of being stored in a variable, the option value is passed to fn every time the option gets set, e.g. to call a setter:
+
+	cmd.BoolFunc("v verbose", false, "verbose mode", logger.SetVerbose)
+*/
+func (c *Cmd) BoolFunc(name string, value bool, desc string, fn func(bool)) {
+	c.Bool(BoolOpt{Name: name, Value: value, Desc: desc, OnSet: fn})
+}
+
+/*
+StringFunc defines a string option on the command c named `name`, with an initial value of `value` and a description of `desc` which will be used in help messages.
+
+Instead of being stored in a variable, the option value is passed to fn every time the option gets set, e.g. to call a setter
+*/
+func (c *Cmd) StringFunc(name string, value string, desc string, fn func(string)) {
+	c.String(StringOpt{Name: name, Value: value, Desc: desc, OnSet: fn})
+}
+
+/*
+IntFunc defines an int option on the command c named `name`, with an initial value of `value` and a description of `desc` which will be used in help messages.
+
+Instead of being stored in a variable, the option value is passed to fn every time the option gets set, e.g. to call a setter
+*/
+func (c *Cmd) IntFunc(name string, value int, desc string, fn func(int)) {
+	c.Int(IntOpt{Name: name, Value: value, Desc: desc, OnSet: fn})
+}
+
 type opt struct {
 	name          string
 	desc          string
@@ -166,12 +614,88 @@ type opt struct {
 	helpFormatter func(interface{}) string
 	value         reflect.Value
 	hideValue     bool
+	step          int
+	optionalValue string
+	nonEmpty      bool
+	source        Source
+	sourceEnvVar  string
+	once          bool
+	example       string
+	transforms    []func(string) (string, error)
+	// the library conversions of the values, e.g. the normalization of the ints, applied after the transforms and in the dry runs
+	conversions   []func(string) (string, error)
+	// the underscores between digits are removed from the values, before the transforms
+	underscores   bool
+	envInvert     bool
+	sep           string
+	visibleWhen   func() bool
+	choiceFunc    func() []string
+	choices       []string
+	allowed       []string
+	ignoreCase    bool
+	pattern       string
+	patternRe     *regexp.Regexp
+	bounds        *intBounds
+	defaultValue  interface{}
+	envRequired   bool
+	onSet         func(interface{})
+	history       []HistoryEntry
+	allowExec     bool
+	secret        bool
+	secretRef     bool
+	operations    *[]Operation
+	immutable     bool
+	noComplete    bool
+	units         *unitConverter
+	negatedNames  []string
 }
 
 func (o *opt) isBool() bool {
 	return o.value.Elem().Kind() == reflect.Bool
 }
 
+func (o *opt) isCounter() bool {
+	return o.step != 0
+}
+
+// isMulti returns true for the slice options, which accumulate multiple values
+func (o *opt) isMulti() bool {
+	return o.value.Elem().Kind() == reflect.Slice
+}
+
+// clear resets the option value to the zero value of its type, e.g. an empty slice
+func (o *opt) clear() {
+	o.value.Elem().Set(reflect.Zero(o.value.Elem().Type()))
+}
+
+func (o *opt) hasOptionalValue() bool {
+	return len(o.optionalValue) > 0
+}
+
+// isFlag returns true for the options which do not expect a value in the call arguments
+func (o *opt) isFlag() bool {
+	return o.isBool() || o.isCounter() || o.hasOptionalValue()
+}
+
+// isOn returns true if the option value was set (from the call arguments, an env variable, ...), and is not false for a bool option
+func (o *opt) isOn() bool {
+	if o.source == SourceDefault {
+		return false
+	}
+	if o.isBool() {
+		return o.value.Elem().Bool()
+	}
+	return true
+}
+
+// flagValue returns the value to use for an option appearing without an explicit value in the call arguments
+func (o *opt) flagValue() string {
+	if o.hasOptionalValue() {
+		return o.optionalValue
+	}
+	return "true"
+}
+
 func (o *opt) String() string {
 	return fmt.Sprintf("Opt(%v)", o.names)
 }
@@ -179,8 +703,285 @@ func (o *opt) String() string {
 func (o *opt) get() interface{} {
 	return o.value.Elem().Interface()
 }
+
+// reset initializes the option value from its env vars, or else with its initial value
+func (o *opt) reset() {
+	switch {
+	case o.envInvert:
+		o.sourceEnvVar = vinitPresence(o.value, o.envVar, false, o.defaultValue)
+	case o.units != nil:
+		o.sourceEnvVar = o.units.vinit(o.value, o.envVar, o.defaultValue)
+	default:
+		o.sourceEnvVar = vinitFunc(o.value, o.envVar, o.envConv, o.defaultValue)
+	}
+	o.source = sourceFor(o.sourceEnvVar)
+	o.choices = nil
+	o.secretRef = false
+	if o.operations != nil {
+		*o.operations = []Operation{}
+	}
+}
+
+// envConv converts the env var value v to the option type, after having removed its underscores if allowed
+func (o *opt) envConv(v string) (reflect.Value, error) {
+	if o.underscores {
+		var err error
+		if v, err = stripUnderscores(v); err != nil {
+			return reflect.Value{}, err
+		}
+	}
+	return vconvSep(v, o.value.Elem().Type(), o.envSep())
+}
+
+// checkChoice checks that s is one of the values returned by the option's choice function, if any, and returns the matching choice
+func (o *opt) checkChoice(s string) (string, error) {
+	if o.choiceFunc == nil {
+		return s, nil
+	}
+	if o.choices == nil {
+		o.choices = o.choiceFunc()
+	}
+	if choice, found := matchChoice(s, o.choices, o.ignoreCase); found {
+		return choice, nil
+	}
+	if o.isSecret() {
+		return "", fmt.Errorf("invalid value %s for option %s", s, o.displayName())
+	}
+	return "", fmt.Errorf("invalid value %s for option %s: expected one of %v", s, o.displayName(), o.choices)
+}
+
+// checkEnvChoice checks that the value of the option read from an environment variable is one of its choices, if any
+func (o *opt) checkEnvChoice() error {
+	if o.choiceFunc == nil || o.source != SourceEnv {
+		return nil
+	}
+	s := fmt.Sprint(o.get())
+	choice, err := o.checkChoice(s)
+	if err != nil {
+		return o.maskError(fmt.Errorf("%v (from the environment variable %s)", err, o.sourceEnvVar), s)
+	}
+	if o.value.Elem().Kind() == reflect.String {
+		o.value.Elem().SetString(choice)
+	}
+	return nil
+}
+
+// checkEnvPattern checks that the value of the option read from an environment variable matches its pattern, if any
+func (o *opt) checkEnvPattern() error {
+	if o.patternRe == nil || o.source != SourceEnv {
+		return nil
+	}
+	s := fmt.Sprint(o.get())
+	if !o.patternRe.MatchString(s) {
+		return o.maskError(fmt.Errorf("invalid value %s for option %s: expected a value matching %s (from the environment variable %s)", s, o.displayName(), o.pattern, o.sourceEnvVar), s)
+	}
+	return nil
+}
+
+// matchChoice returns the choice s matches, optionally ignoring the case
+func matchChoice(s string, choices []string, ignoreCase bool) (string, bool) {
+	for _, choice := range choices {
+		if s == choice || ignoreCase && strings.EqualFold(s, choice) {
+			return choice, true
+		}
+	}
+	return "", false
+}
+
+// checkEnvRequired checks that an option required to be set from the environment was
+func (o *opt) checkEnvRequired() error {
+	if !o.envRequired || o.source == SourceEnv {
+		return nil
+	}
+	return fmt.Errorf("option %s must be set from the environment variable %s", o.displayName(), strings.Join(envVarNames(o.envVar), " or "))
+}
+
+// isVisible returns true if the option should be listed in the help message
+func (o *opt) isVisible() bool {
+	return o.visibleWhen == nil || o.visibleWhen()
+}
+
+// displayName returns the option's longest name, e.g. `--force` for `-f --force`
+func (o *opt) displayName() string {
+	res := ""
+	for _, name := range o.names {
+		if len(name) > len(res) {
+			res = name
+		}
+	}
+	return res
+}
+
+// setValues replaces the option value with the passed values, e.g. read from a JSON object or a query string.
+// Multi valued options are cleared first, and counters are set to the value instead of being incremented
+func (o *opt) setValues(strs []string) error {
+	if o.isMulti() {
+		o.clear()
+	}
+	for _, s := range strs {
+		if o.isCounter() {
+			if err := vset(o.value, s); err != nil {
+				return err
+			}
+			o.notifySet()
+			continue
+		}
+		if err := o.set(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func boolCallback(f func(bool)) func(interface{}) {
+	if f == nil {
+		return nil
+	}
+	return func(v interface{}) { f(v.(bool)) }
+}
+
+func stringCallback(f func(string)) func(interface{}) {
+	if f == nil {
+		return nil
+	}
+	return func(v interface{}) { f(v.(string)) }
+}
+
+func intCallback(f func(int)) func(interface{}) {
+	if f == nil {
+		return nil
+	}
+	return func(v interface{}) { f(v.(int)) }
+}
+
+// notifySet passes the option value to its callback, if any
+func (o *opt) notifySet() {
+	if o.onSet != nil {
+		o.onSet(o.get())
+	}
+}
+
+// withIntChoices adds the normalization of the int values, e.g. `012` to `12`, to conversions if the option has choices,
+// and returns the choices as strings, to be matched against the normalized values
+func withIntChoices(conversions []func(string) (string, error), choices []int) ([]func(string) (string, error), []string) {
+	if len(choices) == 0 {
+		return conversions, nil
+	}
+	allowed := make([]string, len(choices))
+	for i, choice := range choices {
+		allowed[i] = strconv.Itoa(choice)
+	}
+	return append(append([]func(string) (string, error){}, conversions...), normalizeInt), allowed
+}
+
+// normalizeInt formats s as an int, leaving it untouched if it is not one
+func normalizeInt(s string) (string, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return s, nil
+	}
+	return strconv.Itoa(n), nil
+}
+
+// stripUnderscores removes the underscores from s, e.g. `1_000`, provided that each one separates two digits
+func stripUnderscores(s string) (string, error) {
+	if !strings.Contains(s, "_") {
+		return s, nil
+	}
+	digits := strings.TrimLeft(s, "+-")
+	for i, c := range digits {
+		if c != '_' {
+			continue
+		}
+		if i == 0 || i == len(digits)-1 || !isDigit(digits[i-1]) || !isDigit(digits[i+1]) {
+			return s, fmt.Errorf("misplaced underscore in %s", s)
+		}
+	}
+	return strings.Replace(s, "_", "", -1), nil
+}
+
+// envSep returns the separator of the values of a slice option in an env var
+func (o *opt) envSep() string {
+	if len(o.sep) > 0 {
+		return o.sep
+	}
+	return ","
+}
+
 func (o *opt) set(s string) error {
-	return vset(o.value, s)
+	for _, part := range o.splitValue(s) {
+		if err := o.setOne(part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitValue returns the values of s, split using the option separator if it is a slice option declared with one
+func (o *opt) splitValue(s string) []string {
+	if len(o.sep) == 0 || !o.isMulti() {
+		return []string{s}
+	}
+	res := []string{}
+	for _, part := range strings.Split(s, o.sep) {
+		res = append(res, strings.TrimSpace(part))
+	}
+	return res
+}
+
+func (o *opt) setOne(s string) error {
+	s, err := o.convert(s, true)
+	if err != nil {
+		return err
+	}
+	if o.isCounter() {
+		dest := o.value.Elem()
+		dest.SetInt(dest.Int() + int64(o.step))
+		o.notifySet()
+		return nil
+	}
+	if err := vset(o.value, s); err != nil {
+		return err
+	}
+	o.notifySet()
+	return nil
+}
+
+// convert returns s after having removed its underscores if allowed, applied the option transforms, only if transform is true,
+// and the library conversions to it, and checks the result against the option choices, pattern, bounds and non emptiness
+func (o *opt) convert(s string, transform bool) (string, error) {
+	raw := s
+	fs := []func(string) (string, error){}
+	if o.underscores {
+		fs = append(fs, stripUnderscores)
+	}
+	if transform {
+		fs = append(fs, o.transforms...)
+	}
+	fs = append(fs, o.conversions...)
+	for _, f := range fs {
+		var err error
+		if s, err = f(s); err != nil {
+			return s, o.maskError(fmt.Errorf("invalid value for option %s: %v", o.displayName(), err), raw, s)
+		}
+	}
+	choice, err := o.checkChoice(s)
+	if err != nil {
+		return s, o.maskError(err, raw, s)
+	}
+	s = choice
+	if o.patternRe != nil && !o.patternRe.MatchString(s) {
+		return s, o.maskError(fmt.Errorf("invalid value %s for option %s: expected a value matching %s", s, o.displayName(), o.pattern), raw, s)
+	}
+	if o.bounds != nil {
+		if err := o.bounds.checkString(s); err != nil {
+			return s, fmt.Errorf("invalid value for option %s: %v", o.displayName(), err)
+		}
+	}
+	if o.nonEmpty && len(strings.TrimSpace(s)) == 0 {
+		return s, fmt.Errorf("option %s requires a non empty value", o.displayName())
+	}
+	return s, nil
 }
 
 func mkOptStrs(optName string) []string {
@@ -199,17 +1000,37 @@ func (c *Cmd) mkOpt(opt opt, defaultValue interface{}) interface{} {
 	value := reflect.ValueOf(defaultValue)
 	res := reflect.New(value.Type())
 
-	opt.helpFormatter = formatterFor(value.Type())
+	if opt.helpFormatter == nil {
+		opt.helpFormatter = formatterFor(value.Type())
+	}
+
+	if opt.envRequired && len(strings.TrimSpace(opt.envVar)) == 0 {
+		panic(fmt.Sprintf("Option %s cannot be EnvRequired without an EnvVar", opt.name))
+	}
+
+	if len(opt.pattern) > 0 {
+		opt.patternRe = mustCompilePattern(fmt.Sprintf("Option %s", opt.name), opt.pattern)
+	}
 
-	vinit(res, opt.envVar, defaultValue)
+	if len(opt.allowed) > 0 {
+		if opt.choiceFunc != nil {
+			panic(fmt.Sprintf("Option %s cannot have both Choices and a ChoiceFunc", opt.name))
+		}
+		allowed := opt.allowed
+		opt.choiceFunc = func() []string { return allowed }
+	}
 
 	opt.names = mkOptStrs(opt.name)
 	opt.value = res
+	opt.defaultValue = defaultValue
+	opt.reset()
 
 	c.options = append(c.options, &opt)
 	for _, name := range opt.names {
+		c.releaseNegatedName(name)
 		c.optionsIdx[name] = &opt
 	}
+	c.addNegatedNames(&opt)
 
 	return res.Interface()
 }
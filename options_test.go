@@ -1,9 +1,13 @@
 package cli
 
 import (
+	"flag"
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -58,6 +62,121 @@ func TestBoolOpt(t *testing.T) {
 	require.False(t, *b)
 }
 
+func TestBoolOptEnvInvert(t *testing.T) {
+	cmd := &Cmd{optionsIdx: map[string]*opt{}}
+
+	os.Setenv("NO_COLOR", "")
+	a := cmd.Bool(BoolOpt{Name: "a", Value: true, EnvVar: "NO_COLOR", EnvInvert: true})
+	require.True(t, *a)
+	require.Equal(t, SourceDefault, cmd.optionsIdx["-a"].source)
+
+	for _, v := range []string{"1", "true", "false", "yes"} {
+		os.Setenv("NO_COLOR", v)
+		b := cmd.Bool(BoolOpt{Name: "b", Value: true, EnvVar: "NO_COLOR", EnvInvert: true})
+		require.False(t, *b, "env=%s", v)
+		require.Equal(t, SourceEnv, cmd.optionsIdx["-b"].source)
+		require.Equal(t, "NO_COLOR", cmd.optionsIdx["-b"].sourceEnvVar)
+	}
+
+	os.Setenv("NO_COLOR", "")
+	os.Setenv("APP_NO_COLOR", "1")
+	c := cmd.Bool(BoolOpt{Name: "c", Value: true, EnvVar: "NO_COLOR APP_NO_COLOR", EnvInvert: true})
+	require.False(t, *c)
+	os.Setenv("APP_NO_COLOR", "")
+
+	os.Setenv("NO_COLOR", "1")
+	defer os.Setenv("NO_COLOR", "")
+	var color *bool
+	init := func(c *Cmd) {
+		color = c.Bool(BoolOpt{Name: "color", Value: true, EnvVar: "NO_COLOR", EnvInvert: true})
+	}
+	okCmd(t, "[--color]", init, []string{})
+	require.False(t, *color)
+	okCmd(t, "[--color]", init, []string{"--color"})
+	require.True(t, *color, "the call arguments should override the env var")
+}
+
+func TestEnvRequiredOpt(t *testing.T) {
+	var token *string
+	init := func(c *Cmd) {
+		token = c.String(StringOpt{Name: "token", Value: "", EnvVar: "APP_TOKEN APP_API_TOKEN", EnvRequired: true})
+		c.StringOpt("o output", "", "")
+	}
+
+	os.Setenv("APP_TOKEN", "")
+	os.Setenv("APP_API_TOKEN", "secret")
+	defer os.Setenv("APP_API_TOKEN", "")
+	okCmd(t, "[OPTIONS]", init, []string{"-o", "x"})
+	require.Equal(t, "secret", *token)
+
+	failCmd(t, "[OPTIONS]", init, []string{"--token", "other"})
+
+	os.Setenv("APP_API_TOKEN", "")
+	failCmd(t, "[OPTIONS]", init, []string{})
+	failCmd(t, "[OPTIONS]", init, []string{"--token", "secret"})
+
+	cmd := &Cmd{name: "test", optionsIdx: map[string]*opt{}, argsIdx: map[string]*arg{}}
+	cmd.ErrorHandling = flag.ContinueOnError
+	init(cmd)
+	require.Nil(t, cmd.doInit())
+	require.Nil(t, cmd.fsm.parse([]string{}))
+	err := cmd.resolve()
+	require.NotNil(t, err)
+	require.Equal(t, "option --token must be set from the environment variable APP_TOKEN or APP_API_TOKEN", err.Error())
+
+	require.Panics(t, func() {
+		cmd.String(StringOpt{Name: "password", Value: "", EnvRequired: true})
+	}, "an option without an env var cannot be env required")
+}
+
+func TestOptFuncs(t *testing.T) {
+	var (
+		levels   []int
+		names    []string
+		verboses []bool
+	)
+	init := func(c *Cmd) {
+		levels, names, verboses = nil, nil, nil
+		c.IntFunc("l level", 1, "", func(v int) { levels = append(levels, v) })
+		c.StringFunc("name", "", "", func(v string) { names = append(names, v) })
+		c.BoolFunc("v", false, "", func(v bool) { verboses = append(verboses, v) })
+	}
+
+	okCmd(t, "[OPTIONS]", init, []string{})
+	require.Nil(t, levels, "the callbacks should not be called for the initial values")
+	require.Nil(t, names)
+	require.Nil(t, verboses)
+
+	okCmd(t, "[OPTIONS]", init, []string{"-l", "3", "--name=x", "-v", "--level", "5"})
+	require.Equal(t, []int{3, 5}, levels)
+	require.Equal(t, []string{"x"}, names)
+	require.Equal(t, []bool{true}, verboses)
+
+	failCmd(t, "[OPTIONS]", init, []string{"-l", "x"})
+	require.Nil(t, levels, "the callback should not be called for an invalid value")
+
+	os.Setenv("APP_LEVEL", "7")
+	defer os.Setenv("APP_LEVEL", "")
+	var level *int
+	okCmd(t, "[OPTIONS]", func(c *Cmd) {
+		levels = nil
+		level = c.Int(IntOpt{Name: "l level", Value: 1, EnvVar: "APP_LEVEL", OnSet: func(v int) { levels = append(levels, v) }})
+	}, []string{})
+	require.Equal(t, 7, *level)
+	require.Equal(t, []int{7}, levels, "the callback should be called for the env var value")
+
+	var (
+		verbosity *int
+		counts    []int
+	)
+	okCmd(t, "[OPTIONS]", func(c *Cmd) {
+		counts = nil
+		verbosity = c.IntCounter(IntCounterOpt{Name: "v", Value: 0, OnSet: func(v int) { counts = append(counts, v) }})
+	}, []string{"-vvv"})
+	require.Equal(t, 3, *verbosity)
+	require.Equal(t, []int{1, 2, 3}, counts, "the callback should be called for every increment of a counter")
+}
+
 func TestIntOpt(t *testing.T) {
 	cmd := &Cmd{optionsIdx: map[string]*opt{}}
 	a := cmd.Int(IntOpt{Name: "a", Value: -1, Desc: ""})
@@ -88,6 +207,89 @@ func TestIntOpt(t *testing.T) {
 	require.Equal(t, 42, *b)
 }
 
+func TestFloat64Opt(t *testing.T) {
+	cmd := &Cmd{optionsIdx: map[string]*opt{}}
+	a := cmd.Float64(Float64Opt{Name: "a", Value: -1.5, Desc: ""})
+	require.Equal(t, -1.5, *a)
+
+	os.Setenv("B", "")
+	b := cmd.Float64(Float64Opt{Name: "b", Value: -1.5, EnvVar: "B", Desc: ""})
+	require.Equal(t, -1.5, *b)
+
+	goodValues := map[string]float64{"1": 1, "0": 0, "0.95": 0.95, "-2.5": -2.5, "1e3": 1000}
+	for env, tv := range goodValues {
+		os.Setenv("B", env)
+		b := cmd.Float64(Float64Opt{Name: "b", Value: -1.5, EnvVar: "B", Desc: ""})
+		require.Equal(t, tv, *b, "env=%s", env)
+	}
+
+	badValues := []string{"", "b", "q1", "0.9.5", "1,5"}
+	for _, tv := range badValues {
+		os.Setenv("B", tv)
+		b := cmd.Float64(Float64Opt{Name: "b", Value: -1.5, EnvVar: "B", Desc: ""})
+		require.Equal(t, -1.5, *b, "env=%s", tv)
+	}
+
+	os.Setenv("B", "")
+	os.Setenv("C", "0.42")
+	os.Setenv("D", "666")
+	b = cmd.Float64(Float64Opt{Name: "b", Value: -1.5, EnvVar: "B C D", Desc: ""})
+	require.Equal(t, 0.42, *b)
+}
+
+func TestFloat64Parsing(t *testing.T) {
+	var (
+		threshold *float64
+		ratio     *float64
+	)
+	init := func(c *Cmd) {
+		threshold = c.Float64Opt("t threshold", 0.5, "")
+		ratio = c.Float64Arg("RATIO", 1, "")
+	}
+
+	okCmd(t, "[OPTIONS] RATIO", init, []string{"--threshold", "0.95", "2.5"})
+	require.Equal(t, 0.95, *threshold)
+	require.Equal(t, 2.5, *ratio)
+
+	okCmd(t, "[OPTIONS] RATIO", init, []string{"-t=-1e-3", "3"})
+	require.Equal(t, -0.001, *threshold)
+	require.Equal(t, 3.0, *ratio)
+
+	failCmd(t, "[OPTIONS] RATIO", init, []string{"--threshold", "high", "2"})
+	failCmd(t, "[OPTIONS] RATIO", init, []string{"--threshold", "0.9", "half"})
+
+	cmd := &Cmd{name: "test", optionsIdx: map[string]*opt{}, argsIdx: map[string]*arg{}}
+	init(cmd)
+	require.Nil(t, cmd.doInit())
+	err := cmd.fsm.parse([]string{"--threshold", "high", "2"})
+	require.NotNil(t, err)
+	require.Equal(t, `strconv.ParseFloat: parsing "high": invalid syntax`, err.Error())
+}
+
+func TestDurationOpt(t *testing.T) {
+	cmd := &Cmd{optionsIdx: map[string]*opt{}}
+	a := cmd.Duration(DurationOpt{Name: "a", Value: time.Minute, Desc: ""})
+	require.Equal(t, time.Minute, *a)
+
+	os.Setenv("B", "")
+	b := cmd.Duration(DurationOpt{Name: "b", Value: time.Minute, EnvVar: "B", Desc: ""})
+	require.Equal(t, time.Minute, *b)
+
+	os.Setenv("B", "1w2d")
+	b = cmd.Duration(DurationOpt{Name: "b", Value: time.Minute, EnvVar: "B", Desc: ""})
+	require.Equal(t, 9*24*time.Hour, *b)
+
+	os.Setenv("B", "forever")
+	b = cmd.Duration(DurationOpt{Name: "b", Value: time.Minute, EnvVar: "B", Desc: ""})
+	require.Equal(t, time.Minute, *b)
+
+	os.Setenv("B", "")
+	os.Setenv("C", "90s")
+	os.Setenv("D", "3d")
+	b = cmd.Duration(DurationOpt{Name: "b", Value: time.Minute, EnvVar: "B C D", Desc: ""})
+	require.Equal(t, 90*time.Second, *b)
+}
+
 func TestStringsOpt(t *testing.T) {
 	cmd := &Cmd{optionsIdx: map[string]*opt{}}
 	v := []string{"test"}
@@ -139,3 +341,511 @@ func TestIntsOpt(t *testing.T) {
 	b = cmd.Ints(IntsOpt{Name: "b", Value: nil, EnvVar: "B C D E F", Desc: ""})
 	require.Equal(t, vi, *b)
 }
+
+func TestDurationsOpt(t *testing.T) {
+	cmd := &Cmd{optionsIdx: map[string]*opt{}}
+	vd := []time.Duration{time.Second}
+	a := cmd.Durations(DurationsOpt{Name: "a", Value: vd, Desc: ""})
+	require.Equal(t, vd, *a)
+
+	os.Setenv("B", "1s, 2m ,1d")
+	b := cmd.Durations(DurationsOpt{Name: "b", Value: nil, EnvVar: "B", Desc: ""})
+	require.Equal(t, []time.Duration{time.Second, 2 * time.Minute, 24 * time.Hour}, *b)
+
+	os.Setenv("B", "")
+	os.Setenv("C", "1s, soon")
+	os.Setenv("D", "1h30m")
+	b = cmd.Durations(DurationsOpt{Name: "b", Value: vd, EnvVar: "B C D", Desc: ""})
+	require.Equal(t, []time.Duration{90 * time.Minute}, *b)
+
+	var backoff *[]time.Duration
+	init := func(c *Cmd) {
+		backoff = c.Durations(DurationsOpt{Name: "b backoff", Sep: ","})
+	}
+	okCmd(t, "[OPTIONS]", init, []string{"-b", "1s", "--backoff", "2s", "--backoff=500ms,1w"})
+	require.Equal(t, []time.Duration{time.Second, 2 * time.Second, 500 * time.Millisecond, 7 * 24 * time.Hour}, *backoff)
+
+	failCmd(t, "[OPTIONS]", init, []string{"-b", "1s,5x"})
+
+	cmd = &Cmd{name: "test", optionsIdx: map[string]*opt{}, argsIdx: map[string]*arg{}}
+	init(cmd)
+	require.Nil(t, cmd.doInit())
+	err := cmd.fsm.parse([]string{"--backoff", "1s,5x"})
+	require.NotNil(t, err)
+	require.Equal(t, `time: unknown unit "x" in duration "5x"`, err.Error())
+}
+
+func TestFloat64sOpt(t *testing.T) {
+	cmd := &Cmd{optionsIdx: map[string]*opt{}}
+	vf := []float64{0.5}
+	a := cmd.Float64s(Float64sOpt{Name: "a", Value: vf, Desc: ""})
+	require.Equal(t, vf, *a)
+
+	os.Setenv("B", "0.1, 2 ,-3.5")
+	b := cmd.Float64s(Float64sOpt{Name: "b", Value: nil, EnvVar: "B", Desc: ""})
+	require.Equal(t, []float64{0.1, 2, -3.5}, *b)
+
+	os.Setenv("B", "0.1,heavy")
+	b = cmd.Float64s(Float64sOpt{Name: "b", Value: vf, EnvVar: "B", Desc: ""})
+	require.Equal(t, vf, *b, "an invalid element should leave the initial value untouched")
+
+	var weights *[]float64
+	init := func(c *Cmd) {
+		weights = c.Float64sOpt("w weight", nil, "")
+	}
+	okCmd(t, "[OPTIONS]", init, []string{"-w", "0.1", "--weight", "0.2", "--weight=1e3"})
+	require.Equal(t, []float64{0.1, 0.2, 1000}, *weights)
+
+	failCmd(t, "[OPTIONS]", init, []string{"-w", "0.1", "-w", "heavy"})
+}
+
+func TestIntCounterOpt(t *testing.T) {
+	cmd := &Cmd{optionsIdx: map[string]*opt{}}
+	a := cmd.IntCounter(IntCounterOpt{Name: "a", Value: 2, Desc: ""})
+	require.Equal(t, 2, *a)
+
+	os.Setenv("B", "3")
+	b := cmd.IntCounter(IntCounterOpt{Name: "b", Value: 0, EnvVar: "B", Desc: ""})
+	require.Equal(t, 3, *b)
+}
+
+func TestIntCounterOptShorthand(t *testing.T) {
+	var (
+		v     *int
+		force *bool
+		out   *string
+	)
+	init := func(c *Cmd) {
+		v = c.IntCounterOpt("v verbose", 0, "")
+		force = c.BoolOpt("f", false, "")
+		out = c.StringOpt("o", "", "")
+	}
+
+	cases := []struct {
+		args     []string
+		expected int
+	}{
+		{[]string{}, 0},
+		{[]string{"-v"}, 1},
+		{[]string{"-v", "-v", "-v"}, 3},
+		{[]string{"-vvv"}, 3},
+		{[]string{"-vv", "--verbose", "-v"}, 4},
+		{[]string{"--verbose=5"}, 1},
+	}
+	for _, cas := range cases {
+		okCmd(t, "[OPTIONS]", init, cas.args)
+		require.Equal(t, cas.expected, *v, "%v", cas.args)
+	}
+
+	okCmd(t, "[OPTIONS]", init, []string{"-vfv", "-vo", "x"})
+	require.Equal(t, 3, *v)
+	require.True(t, *force)
+	require.Equal(t, "x", *out)
+}
+
+func TestIntCounterOptStep(t *testing.T) {
+	var v *int
+	init := func(c *Cmd) {
+		v = c.IntCounter(IntCounterOpt{Name: "v verbose", Value: 1, Desc: ""})
+	}
+
+	okCmd(t, "[-v...]", init, []string{})
+	require.Equal(t, 1, *v)
+
+	okCmd(t, "[-v...]", init, []string{"-v", "--verbose"})
+	require.Equal(t, 3, *v)
+
+	var q *int
+	init = func(c *Cmd) {
+		q = c.IntCounter(IntCounterOpt{Name: "q quiet", Value: 3, Step: -1, Desc: ""})
+	}
+
+	okCmd(t, "[-q...]", init, []string{"-q", "-q"})
+	require.Equal(t, 1, *q)
+
+	okCmd(t, "[-q...]", init, []string{"-qqq", "--quiet"})
+	require.Equal(t, -1, *q)
+}
+
+func TestOptionalValueOpt(t *testing.T) {
+	var (
+		color *string
+		file  *string
+	)
+	init := func(c *Cmd) {
+		color = c.String(StringOpt{Name: "color", Value: "auto", OptionalValue: "always", Desc: ""})
+		file = c.StringArg("FILE", "", "")
+	}
+
+	okCmd(t, "[--color] [FILE]", init, []string{})
+	require.Equal(t, "auto", *color)
+
+	okCmd(t, "[--color] [FILE]", init, []string{"--color"})
+	require.Equal(t, "always", *color)
+
+	okCmd(t, "[--color] [FILE]", init, []string{"--color=never"})
+	require.Equal(t, "never", *color)
+
+	okCmd(t, "[--color] [FILE]", init, []string{"--color", "never"})
+	require.Equal(t, "always", *color)
+	require.Equal(t, "never", *file)
+}
+
+func TestNonEmptyStringOpt(t *testing.T) {
+	var o *string
+	init := func(c *Cmd) {
+		o = c.String(StringOpt{Name: "o output", Value: "out", NonEmpty: true, Desc: ""})
+	}
+
+	okCmd(t, "[-o]", init, []string{})
+	require.Equal(t, "out", *o)
+
+	okCmd(t, "[-o]", init, []string{"--output", "file"})
+	require.Equal(t, "file", *o)
+
+	failCmd(t, "[-o]", init, []string{"--output="})
+	failCmd(t, "[-o]", init, []string{"--output", "  "})
+	failCmd(t, "[-o]", init, []string{"-o", "\t"})
+
+	cmd := &Cmd{optionsIdx: map[string]*opt{}}
+	cmd.String(StringOpt{Name: "o output", Value: "", NonEmpty: true, Desc: ""})
+	err := cmd.optionsIdx["-o"].set(" ")
+	require.NotNil(t, err)
+	require.Equal(t, "option --output requires a non empty value", err.Error())
+}
+
+func TestOnceOpt(t *testing.T) {
+	var (
+		o    *string
+		f    *bool
+		tags *[]string
+	)
+	init := func(c *Cmd) {
+		o = c.String(StringOpt{Name: "o output", Value: "", Once: true})
+		f = c.Bool(BoolOpt{Name: "f force", Value: false, Once: true})
+		tags = c.StringsOpt("t tag", nil, "")
+	}
+
+	okCmd(t, "[OPTIONS]", init, []string{"-o", "a", "-f", "-t", "x", "--tag", "y"})
+	require.Equal(t, "a", *o)
+	require.True(t, *f)
+	require.Equal(t, []string{"x", "y"}, *tags)
+
+	failCmd(t, "[OPTIONS]", init, []string{"-o", "a", "--output", "b"})
+	failCmd(t, "[OPTIONS]", init, []string{"-f", "--force"})
+	failCmd(t, "[OPTIONS]", init, []string{"-ff"})
+
+	cmd := &Cmd{name: "test", optionsIdx: map[string]*opt{}, argsIdx: map[string]*arg{}}
+	init(cmd)
+	require.Nil(t, cmd.doInit())
+	err := cmd.fsm.parse([]string{"--output=a", "-ob"})
+	require.NotNil(t, err)
+	require.Equal(t, "option --output specified multiple times", err.Error())
+}
+
+func TestOptTransforms(t *testing.T) {
+	trim := func(s string) (string, error) {
+		return strings.TrimSpace(s), nil
+	}
+	lower := func(s string) (string, error) {
+		return strings.ToLower(s), nil
+	}
+	oneOf := func(allowed ...string) func(string) (string, error) {
+		return func(s string) (string, error) {
+			for _, a := range allowed {
+				if s == a {
+					return s, nil
+				}
+			}
+			return s, fmt.Errorf("%q is not one of %v", s, allowed)
+		}
+	}
+
+	var (
+		level  *string
+		colors *[]string
+		calls  int
+	)
+	init := func(c *Cmd) {
+		calls = 0
+		count := func(s string) (string, error) {
+			calls++
+			return s, nil
+		}
+		level = c.String(StringOpt{Name: "l level", Value: "info", Transforms: []func(string) (string, error){trim, lower, oneOf("debug", "info", "warn"), count}})
+		colors = c.Strings(StringsOpt{Name: "c color", Value: nil, Transforms: []func(string) (string, error){trim, lower}})
+	}
+
+	okCmd(t, "[OPTIONS]", init, []string{"-l", " DEBUG ", "-c", "Red", "--color", " BLUE"})
+	require.Equal(t, "debug", *level)
+	require.Equal(t, []string{"red", "blue"}, *colors)
+	require.Equal(t, 1, calls)
+
+	okCmd(t, "[OPTIONS]", init, []string{})
+	require.Equal(t, "info", *level, "the transforms should not apply to the initial value")
+
+	failCmd(t, "[OPTIONS]", init, []string{"-l", "trace"})
+	require.Equal(t, 0, calls, "the transforms should stop at the first error")
+
+	cmd := &Cmd{name: "test", optionsIdx: map[string]*opt{}, argsIdx: map[string]*arg{}}
+	init(cmd)
+	require.Nil(t, cmd.doInit())
+	err := cmd.fsm.parse([]string{"--level", " Trace"})
+	require.NotNil(t, err)
+	require.Equal(t, `invalid value for option --level: "trace" is not one of [debug info warn]`, err.Error())
+}
+
+func TestIntOptAllowUnderscore(t *testing.T) {
+	var (
+		count *int
+		ids   *[]int
+	)
+	init := func(c *Cmd) {
+		count = c.Int(IntOpt{Name: "c count", Value: 0, AllowUnderscore: true})
+		c.Int(IntOpt{Name: "s strict", Value: 0})
+		ids = c.Ints(IntsOpt{Name: "i id", Value: nil, AllowUnderscore: true})
+	}
+
+	okCmd(t, "[OPTIONS]", init, []string{"--count", "1_000", "-i", "1_000_000", "-i", "42"})
+	require.Equal(t, 1000, *count)
+	require.Equal(t, []int{1000000, 42}, *ids)
+
+	okCmd(t, "[OPTIONS]", init, []string{"--count=-2_500"})
+	require.Equal(t, -2500, *count)
+
+	failCmd(t, "[OPTIONS]", init, []string{"--count", "_1"})
+	failCmd(t, "[OPTIONS]", init, []string{"--count", "1_"})
+	failCmd(t, "[OPTIONS]", init, []string{"--count", "1__0"})
+	failCmd(t, "[OPTIONS]", init, []string{"--count=-_1"})
+	failCmd(t, "[OPTIONS]", init, []string{"--strict", "1_000"})
+
+	cmd := &Cmd{name: "test", optionsIdx: map[string]*opt{}, argsIdx: map[string]*arg{}}
+	init(cmd)
+	require.Nil(t, cmd.doInit())
+	err := cmd.fsm.parse([]string{"--count", "1_"})
+	require.NotNil(t, err)
+	require.Equal(t, "invalid value for option --count: misplaced underscore in 1_", err.Error())
+
+	os.Setenv("UNDERSCORE_COUNT", "2_000")
+	os.Setenv("UNDERSCORE_IDS", "1_000,2_000")
+	defer os.Setenv("UNDERSCORE_COUNT", "")
+	defer os.Setenv("UNDERSCORE_IDS", "")
+	var max *int
+	okCmd(t, "[OPTIONS]", func(c *Cmd) {
+		count = c.Int(IntOpt{Name: "c count", Value: 0, EnvVar: "UNDERSCORE_COUNT", AllowUnderscore: true})
+		ids = c.Ints(IntsOpt{Name: "i id", Value: nil, EnvVar: "UNDERSCORE_IDS", AllowUnderscore: true})
+		max = c.Int(IntOpt{Name: "max", Value: 0, AllowUnderscore: true, Transforms: []func(string) (string, error){
+			func(s string) (string, error) {
+				if _, err := strconv.Atoi(s); err != nil {
+					return s, fmt.Errorf("%s is not stripped yet", s)
+				}
+				return s, nil
+			},
+		}})
+	}, []string{"--max", "1_024"})
+	require.Equal(t, 2000, *count)
+	require.Equal(t, []int{1000, 2000}, *ids)
+	require.Equal(t, 1024, *max)
+}
+
+func TestSliceOptSep(t *testing.T) {
+	cmd := &Cmd{optionsIdx: map[string]*opt{}}
+
+	os.Setenv("B", "a;b , c")
+	b := cmd.Strings(StringsOpt{Name: "b", Value: nil, EnvVar: "B", Sep: ";"})
+	require.Equal(t, []string{"a", "b , c"}, *b)
+
+	os.Setenv("B", "1|2 | 3")
+	i := cmd.Ints(IntsOpt{Name: "i", Value: nil, EnvVar: "B", Sep: "|"})
+	require.Equal(t, []int{1, 2, 3}, *i)
+
+	os.Setenv("B", "a,b")
+	b = cmd.Strings(StringsOpt{Name: "b", Value: nil, EnvVar: "B"})
+	require.Equal(t, []string{"a", "b"}, *b, "the env values should be split on commas by default")
+	os.Setenv("B", "")
+
+	var (
+		tags  *[]string
+		ports *[]int
+		names *[]string
+	)
+	init := func(c *Cmd) {
+		tags = c.Strings(StringsOpt{Name: "t tag", Value: nil, Sep: ","})
+		ports = c.Ints(IntsOpt{Name: "p port", Value: nil, Sep: ":"})
+		names = c.Strings(StringsOpt{Name: "n name", Value: nil})
+	}
+
+	okCmd(t, "[OPTIONS]", init, []string{"--tag", "a,b", "-t", "c", "-p", "80:443", "-p", "8080", "-n", "x,y"})
+	require.Equal(t, []string{"a", "b", "c"}, *tags)
+	require.Equal(t, []int{80, 443, 8080}, *ports)
+	require.Equal(t, []string{"x,y"}, *names, "the call arguments values should not be split by default")
+
+	failCmd(t, "[OPTIONS]", init, []string{"-p", "80:http"})
+}
+
+func TestChoiceFuncOpt(t *testing.T) {
+	defer suppressOutput()()
+
+	calls := 0
+	regions := func() []string {
+		calls++
+		return []string{"eu-west-1", "us-east-1"}
+	}
+
+	var region *string
+	var zones *[]string
+	init := func(app *Cli) {
+		region = app.String(StringOpt{Name: "r region", Value: "", ChoiceFunc: regions})
+		zones = app.StringsOpt("z zone", nil, "")
+	}
+
+	app := testApp(init)
+	require.Nil(t, app.Run([]string{"app", "-z", "a"}))
+	require.Equal(t, []string{"a"}, *zones)
+	require.Equal(t, 0, calls, "the choices should only be computed when validating a value")
+
+	require.Nil(t, app.Run([]string{"app", "--region", "us-east-1"}))
+	require.Equal(t, "us-east-1", *region)
+	require.Equal(t, 1, calls)
+
+	require.Nil(t, app.Run([]string{"app", "--region", "us-east-1", "-r", "eu-west-1"}))
+	require.Equal(t, "eu-west-1", *region)
+	require.Equal(t, 2, calls, "the choices should be computed once per parse")
+
+	err := runApp(init, "--region", "ap-south-1")
+	require.NotNil(t, err)
+	require.Equal(t, "invalid value ap-south-1 for option --region: expected one of [eu-west-1 us-east-1]", err.Error())
+}
+
+func TestChoicesOpt(t *testing.T) {
+	defer suppressOutput()()
+
+	var (
+		level           *string
+		caseInsensitive bool
+	)
+	init := func(app *Cli) {
+		level = app.String(StringOpt{Name: "l level", Value: "info", Choices: []string{"debug", "info", "warn"}, CaseInsensitive: caseInsensitive})
+	}
+
+	require.Nil(t, runApp(init, "--level", "warn"))
+	require.Equal(t, "warn", *level)
+
+	err := runApp(init, "--level", "WARN")
+	require.NotNil(t, err)
+	require.Equal(t, "invalid value WARN for option --level: expected one of [debug info warn]", err.Error())
+
+	caseInsensitive = true
+	require.Nil(t, runApp(init, "-l", "Debug"))
+	require.Equal(t, "debug", *level, "the matching choice should be stored")
+
+	err = runApp(init, "-l", "trace")
+	require.NotNil(t, err)
+	require.Equal(t, "invalid value trace for option --level: expected one of [debug info warn]", err.Error())
+
+	require.Panics(t, func() {
+		app := App("app", "")
+		app.String(StringOpt{Name: "level", Choices: []string{"info"}, ChoiceFunc: func() []string { return nil }})
+	})
+}
+
+func TestChoicesFromEnv(t *testing.T) {
+	defer suppressOutput()()
+	defer os.Setenv("APP_LEVEL", "")
+	defer os.Setenv("APP_FORMAT", "")
+
+	var level, format *string
+	init := func(app *Cli) {
+		level = app.String(StringOpt{Name: "level", Value: "info", EnvVar: "APP_LEVEL", Choices: []string{"debug", "info"}, CaseInsensitive: true})
+		format = app.String(StringArg{Name: "FORMAT", Value: "json", EnvVar: "APP_FORMAT", Choices: []string{"json", "text"}})
+		app.Spec = "[OPTIONS] [FORMAT]"
+	}
+
+	os.Setenv("APP_LEVEL", "DEBUG")
+	os.Setenv("APP_FORMAT", "text")
+	require.Nil(t, runApp(init))
+	require.Equal(t, "debug", *level, "the matching choice should be stored")
+	require.Equal(t, "text", *format)
+
+	os.Setenv("APP_LEVEL", "bogus")
+	err := runApp(init)
+	require.NotNil(t, err)
+	require.Equal(t, "invalid value bogus for option --level: expected one of [debug info] (from the environment variable APP_LEVEL)", err.Error())
+
+	require.Nil(t, runApp(init, "--level", "info"), "the call arguments should override the environment variable")
+
+	os.Setenv("APP_LEVEL", "")
+	os.Setenv("APP_FORMAT", "xml")
+	err = runApp(init)
+	require.NotNil(t, err)
+	require.Equal(t, "invalid value xml for argument FORMAT: expected one of [json text] (from the environment variable APP_FORMAT)", err.Error())
+}
+
+func TestPatternFromEnv(t *testing.T) {
+	defer suppressOutput()()
+	defer os.Setenv("APP_NAME", "")
+	defer os.Setenv("APP_ID", "")
+
+	init := func(app *Cli) {
+		app.String(StringOpt{Name: "name", EnvVar: "APP_NAME", Pattern: "^[a-z]+$"})
+		app.String(StringArg{Name: "ID", EnvVar: "APP_ID", Pattern: "^[0-9]+$"})
+		app.Spec = "[OPTIONS] [ID]"
+	}
+
+	os.Setenv("APP_NAME", "web")
+	os.Setenv("APP_ID", "42")
+	require.Nil(t, runApp(init))
+
+	os.Setenv("APP_NAME", "123")
+	err := runApp(init)
+	require.NotNil(t, err)
+	require.Equal(t, "invalid value 123 for option --name: expected a value matching ^[a-z]+$ (from the environment variable APP_NAME)", err.Error())
+
+	os.Setenv("APP_NAME", "")
+	os.Setenv("APP_ID", "x42")
+	err = runApp(init)
+	require.NotNil(t, err)
+	require.Equal(t, "invalid value x42 for argument ID: expected a value matching ^[0-9]+$ (from the environment variable APP_ID)", err.Error())
+}
+
+func TestPatternOpt(t *testing.T) {
+	defer suppressOutput()()
+
+	var id *string
+	init := func(app *Cli) {
+		id = app.String(StringOpt{Name: "id", Pattern: "^[a-z0-9-]+$"})
+	}
+
+	require.Nil(t, runApp(init, "--id", "web-01"))
+	require.Equal(t, "web-01", *id)
+
+	err := runApp(init, "--id", "Web_01")
+	require.NotNil(t, err)
+	require.Equal(t, "invalid value Web_01 for option --id: expected a value matching ^[a-z0-9-]+$", err.Error())
+
+	require.Panics(t, func() {
+		app := App("app", "")
+		app.String(StringOpt{Name: "id", Pattern: "[a-z"})
+	})
+}
+
+func TestIntChoiceOpt(t *testing.T) {
+	defer suppressOutput()()
+
+	var version *int
+	init := func(app *Cli) {
+		version = app.IntChoiceOpt("tls-version", []int{12, 13}, 13, "")
+	}
+
+	require.Nil(t, runApp(init, "--tls-version", "12"))
+	require.Equal(t, 12, *version)
+
+	require.Nil(t, runApp(init, "--tls-version", "012"))
+	require.Equal(t, 12, *version)
+
+	for _, value := range []string{"11", "twelve"} {
+		err := runApp(init, "--tls-version", value)
+		require.NotNil(t, err)
+		require.Equal(t, fmt.Sprintf("invalid value %s for option --tls-version: expected one of [12 13]", value), err.Error())
+	}
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+/*
+UsePager turns on or off the paging of the help messages: when on, and if they are printed to a terminal,
+the help messages are piped through the pager command set in the PAGER environment variable, e.g. `less`.
+
+The help messages are printed directly if PAGER is not set, if the pager cannot be started, or if the output is not a terminal, e.g. a file or a pipe.
+Quitting the pager before the end of the help message is fine
+*/
+func (cli *Cli) UsePager(enabled bool) {
+	cli.pager = enabled
+}
+
+// isTerminal returns true if w is a terminal
+var isTerminal = func(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// printHelp prints the command's help message, through the pager if enabled
+func (c *Cmd) printHelp(longDesc bool) {
+	pager := strings.Fields(os.Getenv("PAGER"))
+	if !c.pager || len(pager) == 0 || !isTerminal(stdErr) {
+		c.writeHelp(longDesc)
+		return
+	}
+
+	var help bytes.Buffer
+	out := stdErr
+	stdErr = &help
+	c.writeHelp(longDesc)
+	stdErr = out
+
+	if err := runPager(pager, &help, out); err != nil {
+		out.Write(help.Bytes())
+	}
+}
+
+// runPager runs the pager command with in as its input, and returns an error only if it cannot be started
+func runPager(pager []string, in io.Reader, out io.Writer) error {
+	cmd := exec.Command(pager[0], pager[1:]...)
+	cmd.Stdin = in
+	cmd.Stdout = out
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// the pager may exit without reading the whole input, e.g. when quit early, which is not an error
+	cmd.Wait()
+	return nil
+}
@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsePager(t *testing.T) {
+	defer os.Setenv("PAGER", os.Getenv("PAGER"))
+	oldIsTerminal := isTerminal
+	defer func() { isTerminal = oldIsTerminal }()
+
+	help := func(pager bool) string {
+		exitCalled := false
+		defer exitShouldBeCalledWith(t, 2, &exitCalled)()
+		var stdErr string
+		defer captureAndRestoreOutput(nil, &stdErr)()
+
+		app := App("app", "App Desc")
+		app.UsePager(pager)
+		app.BoolOpt("v", false, "Verbose")
+		app.Command("run", "Run", func(cmd *Cmd) {
+			cmd.BoolOpt("d", false, "Detach")
+			cmd.Action = func() {}
+		})
+		app.Run([]string{"app", "run", "-h"})
+		return stdErr
+	}
+	direct := `
+Usage: app run [OPTIONS]
+
+Run
+
+Options:
+  -d=false     Detach
+`
+
+	os.Setenv("PAGER", "sed s/^/paged:/")
+	isTerminal = func(w io.Writer) bool { return false }
+	require.Equal(t, direct, help(true), "the help should be printed directly when not a terminal")
+
+	isTerminal = func(w io.Writer) bool { return true }
+	require.Equal(t, direct, help(false), "the help should be printed directly when the pager is off")
+	require.Equal(t, `paged:
+paged:Usage: app run [OPTIONS]
+paged:
+paged:Run
+paged:
+paged:Options:
+paged:  -d=false     Detach
+`, help(true), "the help should be piped through the pager")
+
+	os.Setenv("PAGER", "head -n 2")
+	require.Equal(t, "\nUsage: app run [OPTIONS]\n", help(true), "a pager quitting early should be fine")
+
+	os.Setenv("PAGER", "mow-cli-missing-pager")
+	require.Equal(t, direct, help(true), "the help should be printed directly when the pager cannot be started")
+
+	os.Setenv("PAGER", "")
+	require.Equal(t, direct, help(true), "the help should be printed directly when no pager is set")
+}
@@ -0,0 +1,135 @@
+package cli
+
+import "strings"
+
+/*
+Passthrough makes the command collect the options it does not know, instead of rejecting them, e.g. to forward them to a child process.
+It returns a pointer to the collected arguments, in their original order, which will be populated when the app is run and the call arguments get parsed:
+
+	cmd.Spec = "[-v] IMAGE"
+	verbose := cmd.BoolOpt("v", false, "")
+	image := cmd.StringArg("IMAGE", "", "")
+	rest := cmd.Passthrough()
+
+	$ app -v --memory 2g --rm ubuntu
+	// rest is []string{"--memory", "2g", "--rm"}
+
+An unknown long option without an inline value is assumed to take the following argument as its value, unless it starts with a dash
+or it is needed by the command's required arguments, e.g. `ubuntu` above, as the options cannot be told apart from the flags.
+An explicit value can always be passed using the `=` form, e.g. `--memory=2g`.
+The arguments following `--` are never collected.
+*/
+func (c *Cmd) Passthrough() *[]string {
+	c.passthrough = &[]string{}
+	return c.passthrough
+}
+
+// extractPassthrough moves the unknown options from args to the passthrough slice, if enabled, and returns the remaining args.
+// With the Lenient profile, the unknown options are dropped if the passthrough is not enabled
+func (c *Cmd) extractPassthrough(args []string) []string {
+	c.ignored = nil
+	if c.passthrough == nil && c.profile != Lenient {
+		return args
+	}
+
+	res := []string{}
+	unknown := []string{}
+	positionals := 0
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			res = append(res, args[i:]...)
+			break
+		}
+		if arg == "-" || !strings.HasPrefix(arg, "-") {
+			res = append(res, arg)
+			positionals++
+			continue
+		}
+		if c.isKnownOpt(arg) {
+			res = append(res, arg)
+			if c.takesNextArg(arg) && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+				i++
+				res = append(res, args[i])
+			}
+			continue
+		}
+
+		unknown = append(unknown, arg)
+		dropped := arg
+		if strings.HasPrefix(arg, "--") && !strings.Contains(arg, "=") && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") &&
+			c.countPositionals(args[i+2:]) >= len(c.requiredArgs())-positionals {
+			i++
+			unknown = append(unknown, args[i])
+			dropped += " " + args[i]
+		}
+		if c.passthrough == nil {
+			c.ignored = append(c.ignored, dropped)
+		}
+	}
+	if c.passthrough != nil {
+		*c.passthrough = unknown
+	}
+	return res
+}
+
+// takesNextArg returns true if the known option arg, e.g. `--name` or `-vn`, expects its value in the following argument
+func (c *Cmd) takesNextArg(arg string) bool {
+	if strings.Contains(arg, "=") {
+		return false
+	}
+	if o, found := c.optionsIdx[arg]; found {
+		return !o.isFlag()
+	}
+	if c.SingleDashLongOpts {
+		if o, found := c.optionsIdx["-"+arg]; found {
+			return !o.isFlag()
+		}
+	}
+	// a short options cluster, e.g. `-vn`, the first option expecting a value taking the rest of the cluster, if any, as its value
+	for j := 1; j < len(arg); j++ {
+		o, found := c.optionsIdx["-"+arg[j:j+1]]
+		if !found {
+			return false
+		}
+		if !o.isFlag() {
+			return j == len(arg)-1
+		}
+	}
+	return false
+}
+
+// countPositionals returns the number of arguments in args which would be positional arguments, not counting the values of the known options
+func (c *Cmd) countPositionals(args []string) int {
+	res := 0
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			return res + len(args) - i - 1
+		case arg == "-" || !strings.HasPrefix(arg, "-"):
+			res++
+		case c.isKnownOpt(arg) && c.takesNextArg(arg) && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-"):
+			i++
+		}
+	}
+	return res
+}
+
+// isKnownOpt returns true if arg, e.g. `--name=value` or `-abc`, starts with one of the command's options
+func (c *Cmd) isKnownOpt(arg string) bool {
+	name := strings.SplitN(arg, "=", 2)[0]
+	if _, found := c.optionsIdx[name]; found {
+		return true
+	}
+	if strings.HasPrefix(name, "--") {
+		return false
+	}
+	if c.SingleDashLongOpts {
+		if _, found := c.optionsIdx["-"+name]; found {
+			return true
+		}
+	}
+	_, found := c.optionsIdx[name[:2]]
+	return found
+}
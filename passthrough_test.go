@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPassthrough(t *testing.T) {
+	defer suppressOutput()()
+
+	var (
+		verbose *bool
+		name    *string
+		image   *string
+		rest    *[]string
+	)
+	init := func(app *Cli) {
+		app.Spec = "[OPTIONS] IMAGE"
+		verbose = app.BoolOpt("v verbose", false, "")
+		name = app.StringOpt("n name", "", "")
+		image = app.StringArg("IMAGE", "", "")
+		rest = app.Passthrough()
+	}
+
+	cases := []struct {
+		args    []string
+		rest    []string
+		verbose bool
+		name    string
+		image   string
+	}{
+		{[]string{"ubuntu"}, []string{}, false, "", "ubuntu"},
+		{[]string{"-v", "--name", "x", "ubuntu"}, []string{}, true, "x", "ubuntu"},
+		{[]string{"-v", "--memory", "2g", "--rm", "-n=x", "ubuntu"}, []string{"--memory", "2g", "--rm"}, true, "x", "ubuntu"},
+		{[]string{"-v", "--memory", "2g", "--rm", "ubuntu"}, []string{"--memory", "2g", "--rm"}, true, "", "ubuntu"},
+		{[]string{"--rm", "-n", "x", "ubuntu"}, []string{"--rm"}, false, "x", "ubuntu"},
+		{[]string{"--memory", "2g", "--", "ubuntu"}, []string{"--memory", "2g"}, false, "", "ubuntu"},
+		{[]string{"--cpus=2", "-it", "--rm", "--verbose", "ubuntu"}, []string{"--cpus=2", "-it", "--rm"}, true, "", "ubuntu"},
+		{[]string{"-x", "ubuntu", "--env", "A=1", "-e"}, []string{"-x", "--env", "A=1", "-e"}, false, "", "ubuntu"},
+		{[]string{"-vn", "x", "--", "-image"}, []string{}, true, "x", "-image"},
+	}
+
+	for _, cas := range cases {
+		app := testApp(init)
+		err := app.Run(append([]string{"app"}, cas.args...))
+		require.Nil(t, err, "parsing %v", cas.args)
+		require.Equal(t, cas.rest, *rest, "parsing %v", cas.args)
+		require.Equal(t, cas.verbose, *verbose, "parsing %v", cas.args)
+		require.Equal(t, cas.name, *name, "parsing %v", cas.args)
+		require.Equal(t, cas.image, *image, "parsing %v", cas.args)
+	}
+}
+
+func TestRequiredArgs(t *testing.T) {
+	cases := []struct {
+		spec     string
+		expected []string
+	}{
+		{"", nil},
+		{"SRC DST", []string{"SRC", "DST"}},
+		{"[OPTIONS] SRC... [DST]", []string{"SRC"}},
+		{"-f (SRC | URL) DST", []string{"DST"}},
+		{"(SRC DST)", []string{"SRC", "DST"}},
+		{"SRC | URL", nil},
+		{"[SRC DST]", nil},
+	}
+	for _, cas := range cases {
+		cmd := &Cmd{Spec: cas.spec}
+		require.Equal(t, cas.expected, cmd.requiredArgs(), "spec %q", cas.spec)
+	}
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+)
+
+/*
+Profile is a preset of parsing rules, set on an app using Cli.Profile
+*/
+type Profile int
+
+const (
+	// DefaultProfile applies the rules set on the app, its commands and their options
+	DefaultProfile Profile = iota
+	// Strict rejects the call arguments specifying a single-valued option more than once, as if all of them were declared with Once,
+	// and the invalid values of the environment variables, instead of ignoring them.
+	// The unknown options and the arguments in excess are rejected, as with the default profile
+	Strict
+	// Lenient ignores the unknown options, or collects them if Passthrough was called, and keeps the last value of an option specified more than once,
+	// even if declared with Once
+	Lenient
+)
+
+/*
+Profile switches the app and its commands to a preset of parsing rules, e.g. to be strict when run from a script:
+
+	app.Profile(cli.Strict)
+*/
+func (cli *Cli) Profile(profile Profile) {
+	cli.profile = profile
+}
+
+// rejectsDuplicates returns true if the option cannot be specified more than once in the call arguments with the parse context profile
+func (pc parseContext) rejectsDuplicates(o *opt) bool {
+	switch pc.profile {
+	case Strict:
+		return o.once || (!o.isMulti() && !o.isCounter())
+	case Lenient:
+		return false
+	default:
+		return o.once
+	}
+}
+
+// checkEnvValue checks that the option's environment variables have valid values, the invalid ones being otherwise ignored
+func (o *opt) checkEnvValue() error {
+	if o.source != SourceDefault || o.envInvert {
+		return nil
+	}
+	for _, ev := range envVarNames(o.envVar) {
+		v := os.Getenv(ev)
+		if len(v) == 0 {
+			continue
+		}
+		if _, err := o.envConv(v); err != nil {
+			return fmt.Errorf("invalid value %s for option %s in the environment variable %s: %v", o.maskString(v), o.displayName(), ev, o.maskError(err, v))
+		}
+	}
+	return nil
+}
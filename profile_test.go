@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfile(t *testing.T) {
+	defer suppressOutput()()
+	os.Setenv("APP_PORT", "eighty")
+	defer os.Unsetenv("APP_PORT")
+
+	var (
+		name    *string
+		tags    *[]string
+		port    *int
+		level   *string
+		called  bool
+		profile = DefaultProfile
+	)
+	init := func(app *Cli) {
+		called = false
+		app.Profile(profile)
+		name = app.StringOpt("name", "", "")
+		tags = app.StringsOpt("tag", nil, "")
+		port = app.Int(IntOpt{Name: "port", Value: 8080, EnvVar: "APP_PORT"})
+		level = app.String(StringOpt{Name: "level", Once: true})
+		app.Action = func() {
+			called = true
+		}
+	}
+	tricky := []string{"app", "--name", "a", "--name", "b", "--tag", "x", "--tag", "y", "--color", "auto"}
+	duplicates := []string{"app", "--name", "a", "--name", "b", "--tag", "x", "--tag", "y"}
+
+	err := testApp(init).Run(tricky)
+	require.NotNil(t, err)
+	require.Equal(t, ErrorKindUnknownOption, err.(*ParseError).Kind)
+	require.False(t, called)
+
+	require.Nil(t, testApp(init).Run(duplicates))
+	require.True(t, called)
+	require.Equal(t, "b", *name)
+	require.Equal(t, 8080, *port, "the invalid env values should be ignored")
+
+	err = runApp(init, "--level", "info", "--level", "debug")
+	require.NotNil(t, err)
+	require.Equal(t, "option --level specified multiple times", err.Error())
+
+	profile = Lenient
+	require.Nil(t, testApp(init).Run(tricky))
+	require.True(t, called)
+	require.Equal(t, "b", *name)
+	require.Equal(t, []string{"x", "y"}, *tags)
+	require.Equal(t, 8080, *port)
+
+	require.Nil(t, runApp(init, "--level", "info", "--level", "debug"))
+	require.Equal(t, "debug", *level, "the lenient profile should keep the last value")
+
+	app := testApp(init)
+	rest := app.Passthrough()
+	require.Nil(t, app.Run(tricky))
+	require.Equal(t, []string{"--color", "auto"}, *rest)
+
+	profile = Strict
+	err = testApp(init).Run(tricky)
+	require.NotNil(t, err)
+	require.Equal(t, ErrorKindUnknownOption, err.(*ParseError).Kind)
+	require.False(t, called)
+
+	err = testApp(init).Run(duplicates)
+	require.NotNil(t, err)
+	require.Equal(t, "option --name specified multiple times", err.Error())
+	require.False(t, called)
+
+	err = runApp(init, "--tag", "x", "--tag", "y")
+	require.NotNil(t, err)
+	require.Equal(t, `invalid value eighty for option --port in the environment variable APP_PORT: strconv.ParseInt: parsing "eighty": invalid syntax`, err.Error())
+	require.False(t, called)
+
+	require.Nil(t, runApp(init, "--tag", "x", "--tag", "y", "--port", "80"), "the env values overridden by the call arguments should not be checked")
+	require.True(t, called)
+	require.Equal(t, 80, *port)
+
+	app = testApp(init)
+	app.Command("sub", "", func(cmd *Cmd) {
+		cmd.StringOpt("mode", "", "")
+		cmd.Action = func() {}
+	})
+	err = app.Run([]string{"app", "--port", "80", "sub", "--mode", "a", "--mode", "b"})
+	require.NotNil(t, err)
+	require.Equal(t, "option --mode specified multiple times", err.Error(), "the commands should inherit the profile")
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+)
+
+/*
+SetFromQuery sets the values of the command's options from a query string, e.g. to bridge the input of an HTTP handler:
+
+	values, _ := url.ParseQuery("output=x&count=3&tag=a&tag=b")
+	err := cmd.SetFromQuery(values)
+
+The keys are the option names without the dashes.
+A key can be repeated to set the values of a slice option, while the other options accept a single value.
+
+The precedence of the query values depends on when this method is called:
+before the app is run, they act as initial values which the call arguments can override,
+while after the call arguments got parsed, e.g. in a Before interceptor, they only apply to the options which were not explicitly set in the call arguments.
+
+Unknown keys and invalid values are reported as errors.
+*/
+func (c *Cmd) SetFromQuery(values url.Values) error {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	opts := []*opt{}
+	optValues := map[*opt][]string{}
+	for _, key := range keys {
+		opt, found := c.optionsIdx[mkOptStrs(key)[0]]
+		if !found {
+			return fmt.Errorf("unknown option %s in query", key)
+		}
+		if _, seen := optValues[opt]; !seen {
+			opts = append(opts, opt)
+		}
+		optValues[opt] = append(optValues[opt], values[key]...)
+	}
+
+	for _, opt := range opts {
+		if opt.source == SourceCLI {
+			continue
+		}
+		if err := opt.checkImmutable(SourceQuery); err != nil {
+			return err
+		}
+		vs := optValues[opt]
+		if !opt.isMulti() && len(vs) > 1 {
+			return fmt.Errorf("invalid value for option %s in query: expected a single value, got %v", opt.displayName(), vs)
+		}
+		if err := opt.setValues(vs); err != nil {
+			return fmt.Errorf("invalid value for option %s in query: %v", opt.displayName(), err)
+		}
+		opt.source = SourceQuery
+	}
+	return nil
+}
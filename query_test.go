@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetFromQuery(t *testing.T) {
+	var (
+		output  *string
+		count   *int
+		verbose *bool
+		tags    *[]string
+	)
+	mkCmd := func() *Cmd {
+		cmd := &Cmd{optionsIdx: map[string]*opt{}}
+		output = cmd.StringOpt("o output", "out", "")
+		count = cmd.IntOpt("count", 1, "")
+		verbose = cmd.BoolOpt("v", false, "")
+		tags = cmd.StringsOpt("t tag", []string{"default"}, "")
+		return cmd
+	}
+
+	cmd := mkCmd()
+	values, err := url.ParseQuery("output=x&count=3&v=true")
+	require.Nil(t, err)
+	require.Nil(t, cmd.SetFromQuery(values))
+	require.Equal(t, "x", *output)
+	require.Equal(t, 3, *count)
+	require.True(t, *verbose)
+	require.Equal(t, []string{"default"}, *tags)
+	require.Equal(t, SourceQuery, cmd.optionsIdx["--count"].source)
+	require.Equal(t, SourceDefault, cmd.optionsIdx["--tag"].source)
+
+	cmd = mkCmd()
+	values, err = url.ParseQuery("tag=a&t=b&tag=c")
+	require.Nil(t, err)
+	require.Nil(t, cmd.SetFromQuery(values))
+	require.Equal(t, []string{"b", "a", "c"}, *tags)
+
+	cmd = mkCmd()
+	cmd.optionsIdx["--output"].source = SourceCLI
+	require.Nil(t, cmd.SetFromQuery(url.Values{"output": {"x"}, "count": {"3"}}))
+	require.Equal(t, "out", *output, "options set in the call arguments should not be overridden")
+	require.Equal(t, 3, *count)
+
+	badCases := []struct {
+		values url.Values
+		msg    string
+	}{
+		{url.Values{"outptu": {"x"}}, "unknown option outptu in query"},
+		{url.Values{"count": {"three"}}, `invalid value for option --count in query: strconv.ParseInt: parsing "three": invalid syntax`},
+		{url.Values{"count": {"1", "2"}}, "invalid value for option --count in query: expected a single value, got [1 2]"},
+		{url.Values{"o": {"x"}, "output": {"y"}}, "invalid value for option --output in query: expected a single value, got [x y]"},
+	}
+
+	for _, cas := range badCases {
+		cmd = mkCmd()
+		err := cmd.SetFromQuery(cas.values)
+		require.NotNil(t, err, "query %v should have failed", cas.values)
+		require.Equal(t, cas.msg, err.Error())
+	}
+}
+
+func TestSetFromQueryBeforeRun(t *testing.T) {
+	defer exitShouldNotCalled(t)()
+	defer suppressOutput()()
+
+	app := App("app", "")
+	output := app.StringOpt("o output", "out", "")
+	count := app.IntOpt("count", 1, "")
+	app.Action = func() {}
+
+	require.Nil(t, app.SetFromQuery(url.Values{"output": {"x"}, "count": {"3"}}))
+	app.Run([]string{"app", "--count", "7"})
+	require.Equal(t, "x", *output)
+	require.Equal(t, 7, *count, "the call arguments should override the query values")
+	require.Equal(t, SourceCLI, app.optionsIdx["--count"].source)
+}
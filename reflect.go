@@ -9,6 +9,19 @@ import (
 )
 
 func vconv(s string, to reflect.Type) (reflect.Value, error) {
+	return vconvSep(s, to, ",")
+}
+
+// vconvSep converts s to the type to, splitting it on sep if to is a slice type
+func vconvSep(s string, to reflect.Type, sep string) (reflect.Value, error) {
+	if to == durationType {
+		d, err := parseDuration(s)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(d), nil
+	}
+
 	switch to.Kind() {
 	case reflect.String:
 		return reflect.ValueOf(s), nil
@@ -24,9 +37,25 @@ func vconv(s string, to reflect.Type) (reflect.Value, error) {
 			return reflect.Value{}, err
 		}
 		return reflect.ValueOf(int(i)), nil
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(f), nil
+	case reflect.Map:
+		res := reflect.MakeMap(to)
+		for _, v := range strings.Split(s, sep) {
+			key, value, err := splitKeyValue(strings.TrimSpace(v))
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			res.SetMapIndex(reflect.ValueOf(key), reflect.ValueOf(value))
+		}
+		return res, nil
 	case reflect.Slice:
 		res := reflect.New(to)
-		vs := strings.Split(s, ",")
+		vs := strings.Split(s, sep)
 		for _, v := range vs {
 			conv, err := vconv(strings.TrimSpace(v), to.Elem())
 			if err != nil {
@@ -50,6 +79,18 @@ func vset(into reflect.Value, s string) error {
 			return err
 		}
 		dest.Set(reflect.Append(dest, v))
+	case reflect.Map:
+		key, value, err := splitKeyValue(s)
+		if err != nil {
+			return err
+		}
+		// copy the map instead of updating it in place, as it may be the initial value
+		res := reflect.MakeMap(dest.Type())
+		for _, k := range dest.MapKeys() {
+			res.SetMapIndex(k, dest.MapIndex(k))
+		}
+		res.SetMapIndex(reflect.ValueOf(key), reflect.ValueOf(value))
+		dest.Set(res)
 	default:
 		conv, err := vconv(s, dest.Type())
 		if err != nil {
@@ -60,22 +101,79 @@ func vset(into reflect.Value, s string) error {
 	return nil
 }
 
-func vinit(into reflect.Value, envVars string, defaultValue interface{}) {
-	if len(envVars) > 0 {
-		for _, rev := range strings.Split(envVars, " ") {
-			ev := strings.TrimSpace(rev)
-			if len(ev) > 0 {
-				v := os.Getenv(ev)
-				if len(v) > 0 {
-					conv, err := vconv(v, into.Elem().Type())
-					if err == nil {
-						into.Elem().Set(conv)
-						return
-					}
-				}
+// splitKeyValue splits s on its first `=` into a key and a value
+func splitKeyValue(s string) (string, string, error) {
+	i := strings.Index(s, "=")
+	if i < 0 {
+		return "", "", fmt.Errorf("expected a key=value pair, got %q", s)
+	}
+	return s[:i], s[i+1:], nil
+}
+
+// envVarNames returns the env var names of the space separated list envVars, without the duplicates, in their order of first appearance
+func envVarNames(envVars string) []string {
+	res := []string{}
+	seen := map[string]bool{}
+	for _, ev := range strings.Fields(envVars) {
+		if !seen[ev] {
+			seen[ev] = true
+			res = append(res, ev)
+		}
+	}
+	return res
+}
+
+// vinit initializes into from the first env var in envVars with a valid value, or else with defaultValue.
+// The env var values are split on sep for the slice types, a slice value being valid only if all of its items are.
+// It returns the name of the env var which was used, if any
+func vinit(into reflect.Value, envVars, sep string, defaultValue interface{}) string {
+	return vinitFunc(into, envVars, func(v string) (reflect.Value, error) {
+		return vconvSep(v, into.Elem().Type(), sep)
+	}, defaultValue)
+}
+
+// vinitFunc initializes into from the first env var in envVars with a value conv accepts, or else with defaultValue.
+// It returns the name of the env var which was used, if any
+func vinitFunc(into reflect.Value, envVars string, conv func(string) (reflect.Value, error), defaultValue interface{}) string {
+	for _, ev := range envVarNames(envVars) {
+		v := os.Getenv(ev)
+		if len(v) > 0 {
+			value, err := conv(v)
+			if err == nil {
+				into.Elem().Set(value)
+				return ev
 			}
 		}
+	}
+	into.Elem().Set(reflect.ValueOf(defaultValue))
+	return ""
+}
 
+// vinitPresence initializes into with value if one of the env vars in envVars is set to a non empty value, or else with defaultValue.
+// It returns the name of the env var which was used, if any
+func vinitPresence(into reflect.Value, envVars string, value, defaultValue interface{}) string {
+	for _, ev := range envVarNames(envVars) {
+		if len(os.Getenv(ev)) > 0 {
+			into.Elem().Set(reflect.ValueOf(value))
+			return ev
+		}
 	}
 	into.Elem().Set(reflect.ValueOf(defaultValue))
+	return ""
+}
+
+// vcopy returns a copy of the value v, i.e. not sharing the content of the slices and maps
+func vcopy(v reflect.Value) interface{} {
+	switch {
+	case v.Kind() == reflect.Slice && !v.IsNil():
+		return reflect.AppendSlice(reflect.MakeSlice(v.Type(), 0, v.Len()), v).Interface()
+	case v.Kind() == reflect.Map && !v.IsNil():
+		res := reflect.MakeMap(v.Type())
+		for _, k := range v.MapKeys() {
+			res.SetMapIndex(k, v.MapIndex(k))
+		}
+		return res.Interface()
+	default:
+		return v.Interface()
+	}
 }
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"os"
 	"reflect"
 	"testing"
 
@@ -43,3 +44,44 @@ func TestVConv(t *testing.T) {
 		require.NotNil(t, err)
 	}
 }
+
+func TestEnvVarNames(t *testing.T) {
+	require.Equal(t, []string{"FOO", "BAR"}, envVarNames(" FOO  FOO BAR "))
+	require.Equal(t, []string{"FOO", "BAR"}, envVarNames("FOO\tBAR FOO"))
+	require.Equal(t, []string{}, envVarNames("   "))
+	require.Equal(t, []string{}, envVarNames(""))
+}
+
+func TestVInitDuplicateEnvVars(t *testing.T) {
+	defer os.Unsetenv("MOW_FOO")
+	defer os.Unsetenv("MOW_BAR")
+	envVars := " MOW_FOO  MOW_FOO MOW_BAR "
+
+	cases := []struct {
+		foo, bar string
+		into     interface{}
+		value    interface{}
+		from     string
+	}{
+		{"7", "42", new(int), 7, "MOW_FOO"},
+		{"x", "42", new(int), 42, "MOW_BAR"},
+		{"", "42", new(int), 42, "MOW_BAR"},
+		{"x", "y", new(int), 1, ""},
+		{"7,x", "4,2", new([]int), []int{4, 2}, "MOW_BAR"},
+		{"7,8", "4,2", new([]int), []int{7, 8}, "MOW_FOO"},
+		{"7,x", "4,y", new([]int), []int{1}, ""},
+	}
+
+	for _, cas := range cases {
+		os.Setenv("MOW_FOO", cas.foo)
+		os.Setenv("MOW_BAR", cas.bar)
+		into := reflect.ValueOf(cas.into)
+		var defaultValue interface{} = 1
+		if into.Elem().Kind() == reflect.Slice {
+			defaultValue = []int{1}
+		}
+		from := vinit(into, envVars, ",", defaultValue)
+		require.Equal(t, cas.from, from, "FOO=%s BAR=%s", cas.foo, cas.bar)
+		require.Equal(t, cas.value, into.Elem().Interface(), "FOO=%s BAR=%s", cas.foo, cas.bar)
+	}
+}
@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type jsonSchema struct {
+	Schema      string                 `json:"$schema,omitempty"`
+	Title       string                 `json:"title,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Type        string                 `json:"type"`
+	Items       *jsonSchema            `json:"items,omitempty"`
+	Default     interface{}            `json:"default,omitempty"`
+	Enum        []interface{}          `json:"enum,omitempty"`
+	Properties  map[string]*jsonSchema `json:"properties,omitempty"`
+	Required    []string               `json:"required,omitempty"`
+
+	AdditionalProperties *bool `json:"additionalProperties,omitempty"`
+}
+
+/*
+WriteJSONSchema writes to w a JSON Schema (draft-07) describing the command's options and arguments, e.g. to generate a UI or to validate a config file.
+
+The schema describes an object with a property per option, named after the option's longest name without the dashes (as accepted by FlagsFromJSON),
+and a property per argument, named after the argument.
+Each property has the type of the option or argument (slices map to arrays), its description and its initial value as default.
+The choices of an option or an argument, if any, are listed as the property's enum,
+and the arguments the spec makes mandatory are listed as required.
+*/
+func (c *Cmd) WriteJSONSchema(w io.Writer) error {
+	if err := c.doInit(); err != nil {
+		return err
+	}
+
+	noAdditional := false
+	res := jsonSchema{
+		Schema:      "http://json-schema.org/draft-07/schema#",
+		Title:       strings.Join(append(c.parents, c.name), " "),
+		Description: c.desc,
+		Type:        "object",
+		Properties:  map[string]*jsonSchema{},
+
+		AdditionalProperties: &noAdditional,
+	}
+
+	for _, o := range c.options {
+		if o == c.jsonFlags {
+			continue
+		}
+		prop := newJSONSchema(o.desc, reflect.ValueOf(o.shownValue()))
+		prop.setEnum(o.allowed)
+		res.Properties[strings.TrimLeft(o.displayName(), "-")] = prop
+	}
+	for _, a := range c.args {
+		prop := newJSONSchema(a.desc, a.value.Elem())
+		prop.setEnum(a.choices)
+		res.Properties[a.name] = prop
+	}
+	res.Required = c.requiredArgs()
+
+	enc, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(enc, '\n'))
+	return err
+}
+
+func newJSONSchema(desc string, v reflect.Value) *jsonSchema {
+	res := jsonSchemaFor(v.Type())
+	res.Description = desc
+
+	switch {
+	case v.Type() == durationType:
+		res.Default = v.Interface().(time.Duration).String()
+	case v.Kind() == reflect.Slice && v.Type().Elem() == durationType:
+		res.Default = durationStrings(v)
+	case v.Kind() == reflect.Slice && v.IsNil():
+		res.Default = reflect.MakeSlice(v.Type(), 0, 0).Interface()
+	case v.Kind() == reflect.Map && v.IsNil():
+		res.Default = reflect.MakeMap(v.Type()).Interface()
+	default:
+		res.Default = v.Interface()
+	}
+	return res
+}
+
+// setEnum lists the choices as the enum of the schema, or of its items for an array, converted to the schema's type
+func (s *jsonSchema) setEnum(choices []string) {
+	if len(choices) == 0 {
+		return
+	}
+	if s.Items != nil {
+		s = s.Items
+	}
+	s.Enum = make([]interface{}, len(choices))
+	for i, choice := range choices {
+		s.Enum[i] = choice
+		switch s.Type {
+		case "integer":
+			if n, err := strconv.Atoi(choice); err == nil {
+				s.Enum[i] = n
+			}
+		case "number":
+			if f, err := strconv.ParseFloat(choice, 64); err == nil {
+				s.Enum[i] = f
+			}
+		}
+	}
+}
+
+func jsonSchemaFor(t reflect.Type) *jsonSchema {
+	if t == durationType {
+		return &jsonSchema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}
+	case reflect.Int:
+		return &jsonSchema{Type: "integer"}
+	case reflect.Float64:
+		return &jsonSchema{Type: "number"}
+	case reflect.Slice:
+		return &jsonSchema{Type: "array", Items: jsonSchemaFor(t.Elem())}
+	case reflect.Map:
+		return &jsonSchema{Type: "object"}
+	default:
+		return &jsonSchema{Type: "string"}
+	}
+}
@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteJSONSchema(t *testing.T) {
+	app := App("app", "")
+	app.Command("deploy", "Deploy the app", func(cmd *Cmd) {
+		cmd.StringOpt("e env", "dev", "Target environment")
+		cmd.BoolOpt("f force", false, "Force the deployment")
+		cmd.IntsOpt("p port", nil, "Exposed ports")
+		cmd.IntCounter(IntCounterOpt{Name: "v", Desc: "Verbosity"})
+		cmd.DurationOpt("timeout", 90*time.Second, "")
+		cmd.FlagsFromJSON("flags-from-json")
+		cmd.StringsArg("HOST", []string{"localhost"}, "Target hosts")
+		cmd.Spec = "[OPTIONS] HOST..."
+	})
+	require.Nil(t, app.doInit())
+
+	var out bytes.Buffer
+	err := app.commands[0].WriteJSONSchema(&out)
+	require.Nil(t, err)
+	require.Equal(t, `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "title": "app deploy",
+  "description": "Deploy the app",
+  "type": "object",
+  "properties": {
+    "HOST": {
+      "description": "Target hosts",
+      "type": "array",
+      "items": {
+        "type": "string"
+      },
+      "default": [
+        "localhost"
+      ]
+    },
+    "env": {
+      "description": "Target environment",
+      "type": "string",
+      "default": "dev"
+    },
+    "force": {
+      "description": "Force the deployment",
+      "type": "boolean",
+      "default": false
+    },
+    "port": {
+      "description": "Exposed ports",
+      "type": "array",
+      "items": {
+        "type": "integer"
+      },
+      "default": []
+    },
+    "timeout": {
+      "type": "string",
+      "default": "1m30s"
+    },
+    "v": {
+      "description": "Verbosity",
+      "type": "integer",
+      "default": 0
+    }
+  },
+  "required": [
+    "HOST"
+  ],
+  "additionalProperties": false
+}
+`, out.String())
+}
+
+func TestWriteJSONSchemaChoices(t *testing.T) {
+	app := App("app", "")
+	app.Command("deploy", "", func(cmd *Cmd) {
+		cmd.String(StringOpt{Name: "e env", Value: "dev", Choices: []string{"dev", "prod"}})
+		cmd.Int(IntOpt{Name: "l level", Value: 1, Choices: []int{1, 2, 3}})
+		cmd.String(StringArg{Name: "REGION", Choices: []string{"eu", "us"}})
+		cmd.String(StringArg{Name: "HOST"})
+		cmd.String(StringArg{Name: "PORT"})
+		cmd.Spec = "[OPTIONS] REGION HOST [PORT]"
+	})
+	require.Nil(t, app.doInit())
+
+	var out bytes.Buffer
+	err := app.commands[0].WriteJSONSchema(&out)
+	require.Nil(t, err)
+	require.Equal(t, `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "title": "app deploy",
+  "type": "object",
+  "properties": {
+    "HOST": {
+      "type": "string",
+      "default": ""
+    },
+    "PORT": {
+      "type": "string",
+      "default": ""
+    },
+    "REGION": {
+      "type": "string",
+      "default": "",
+      "enum": [
+        "eu",
+        "us"
+      ]
+    },
+    "env": {
+      "type": "string",
+      "default": "dev",
+      "enum": [
+        "dev",
+        "prod"
+      ]
+    },
+    "level": {
+      "type": "integer",
+      "default": 1,
+      "enum": [
+        1,
+        2,
+        3
+      ]
+    }
+  },
+  "required": [
+    "REGION",
+    "HOST"
+  ],
+  "additionalProperties": false
+}
+`, out.String())
+}
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"errors"
+	"strings"
+)
+
+const secretMask = "****"
+
+// isSecret returns true if the option is secret, or if a secret option value was interpolated in its value
+func (o *opt) isSecret() bool {
+	return o.secret || o.secretRef
+}
+
+// shownValue returns the option value to be output by the library, i.e. masked if the option is secret and its value is not empty
+func (o *opt) shownValue() interface{} {
+	v := o.get()
+	if o.isSecret() && v != "" {
+		return secretMask
+	}
+	return v
+}
+
+// maskString returns s with the secret option value masked, if the option is secret
+func (o *opt) maskString(s string) string {
+	if !o.isSecret() || len(s) == 0 {
+		return s
+	}
+	return secretMask
+}
+
+// maskError returns err with the occurrences of values masked, if the option is secret
+func (o *opt) maskError(err error, values ...string) error {
+	if !o.isSecret() || err == nil {
+		return err
+	}
+	msg := err.Error()
+	for _, v := range values {
+		if len(v) > 0 {
+			msg = strings.Replace(msg, v, secretMask, -1)
+		}
+	}
+	return errors.New(msg)
+}
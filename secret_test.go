@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretOpt(t *testing.T) {
+	const secret = "hunter2"
+	os.Setenv("APP_TOKEN", secret)
+	defer os.Unsetenv("APP_TOKEN")
+
+	var out bytes.Buffer
+	init := func(app *Cli) {
+		app.RecordHistory(true)
+		app.String(StringOpt{Name: "token", EnvVar: "APP_TOKEN", Secret: true, ChoiceFunc: func() []string {
+			return []string{secret, secret + "-other"}
+		}})
+		app.String(StringOpt{Name: "user", Value: "bob"})
+		app.ShowConfig("show-config")
+		app.Action = func() {
+			js, err := json.Marshal(app.Trace())
+			require.Nil(t, err)
+			out.Write(js)
+			fmt.Fprint(&out, app.ToArgs())
+			for _, format := range []string{"yaml", "json"} {
+				require.Nil(t, app.WriteConfig(&out, format))
+			}
+			require.Nil(t, app.WriteJSONSchema(&out))
+			fmt.Fprint(&out, app.OptionHistory("token"))
+		}
+	}
+
+	var stdOut, stdErr string
+	func() {
+		defer captureAndRestoreOutput(&stdOut, &stdErr)()
+		exitCalled := false
+		defer exitShouldBeCalledWith(t, 0, &exitCalled)()
+		runApp(init, "--show-config")
+	}()
+	out.WriteString(stdOut)
+	out.WriteString(stdErr)
+
+	for _, args := range [][]string{{"app"}, {"app", "--token", secret + "-other"}} {
+		app := testApp(init)
+		func() {
+			defer captureAndRestoreOutput(&stdOut, &stdErr)()
+			require.Nil(t, app.Run(args))
+		}()
+		out.WriteString(stdOut)
+		out.WriteString(stdErr)
+		require.True(t, strings.HasPrefix(app.optionsIdx["--token"].get().(string), secret), "the option value should not be masked")
+		require.Contains(t, out.String(), "bob", "the other options should not be masked")
+	}
+
+	var helpOut, errOut, jsonErrOut string
+	func() {
+		defer captureAndRestoreOutput(nil, &helpOut)()
+		exitCalled := false
+		defer exitShouldBeCalledWith(t, 2, &exitCalled)()
+		runApp(init, "-h")
+	}()
+	require.Contains(t, helpOut, `--token="****"`)
+
+	func() {
+		defer captureAndRestoreOutput(nil, &errOut)()
+		err := runApp(init, "--token", secret+"-bad")
+		require.NotNil(t, err)
+		out.WriteString(err.Error())
+	}()
+	require.Contains(t, errOut, "****")
+
+	func() {
+		defer captureAndRestoreOutput(nil, &jsonErrOut)()
+		app := testApp(init)
+		app.JSONErrors(true)
+		require.NotNil(t, app.Run([]string{"app", "--token", secret + "-bad"}))
+	}()
+
+	for name, output := range map[string]string{"outputs": out.String(), "help": helpOut, "error": errOut, "JSON error": jsonErrOut} {
+		require.NotEmpty(t, output)
+		require.False(t, strings.Contains(output, secret), "the %s should not contain the secret value:\n%s", name, output)
+	}
+}
+
+func TestSecretInterpolation(t *testing.T) {
+	const secret = "hunter2"
+	os.Setenv("APP_TOKEN", secret)
+	defer os.Unsetenv("APP_TOKEN")
+
+	var (
+		url   *string
+		trace Trace
+		args  []string
+	)
+	init := func(app *Cli) {
+		app.String(StringOpt{Name: "token", EnvVar: "APP_TOKEN", Secret: true})
+		url = app.String(StringOpt{Name: "url", Value: "https://example.com"})
+		app.EnableInterpolation()
+		app.Action = func() {
+			trace = app.Trace()
+			args = app.ToArgs()
+		}
+	}
+
+	app := testApp(init)
+	require.Nil(t, app.Run([]string{"app", "--url", "https://${token}@example.com"}))
+	require.Equal(t, "https://"+secret+"@example.com", *url, "the option value should not be masked")
+	require.Equal(t, []string{"--token", "****", "--url", "****"}, args)
+	require.Equal(t, "****", trace.Options[1].Value, "an option referencing a secret option should be masked")
+
+	app.Reset()
+	require.Nil(t, app.Run([]string{"app", "--url", "https://example.org"}))
+	require.Equal(t, []string{"--token", "****", "--url", "https://example.org"}, args, "the option should not be masked when it references no secret option")
+}
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+)
+
+/*
+shellTokenize splits a command line into words the way a POSIX shell would (minus the expansions):
+words are separated by unquoted blanks, single quotes preserve everything literally,
+double quotes preserve everything but backslash escapes of `"` and `\`,
+and an unquoted backslash escapes the following character.
+*/
+func shellTokenize(line string) ([]string, error) {
+	var (
+		res    = []string{}
+		word   bytes.Buffer
+		inWord = false
+		quote  = byte(0)
+	)
+
+	for pos := 0; pos < len(line); pos++ {
+		c := line[pos]
+		switch {
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+				continue
+			}
+			word.WriteByte(c)
+		case quote == '"':
+			switch {
+			case c == '"':
+				quote = 0
+			case c == '\\' && pos+1 < len(line) && (line[pos+1] == '"' || line[pos+1] == '\\'):
+				pos++
+				word.WriteByte(line[pos])
+			default:
+				word.WriteByte(c)
+			}
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inWord {
+				res = append(res, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case c == '\'' || c == '"':
+			quote = c
+			inWord = true
+		case c == '\\':
+			if pos+1 >= len(line) {
+				return nil, fmt.Errorf("unexpected end of input after \\")
+			}
+			pos++
+			word.WriteByte(line[pos])
+			inWord = true
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unclosed quote %c", quote)
+	}
+	if inWord {
+		res = append(res, word.String())
+	}
+	return res, nil
+}
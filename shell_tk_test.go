@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShellTokenize(t *testing.T) {
+	cases := []struct {
+		line     string
+		expected []string
+	}{
+		{"", []string{}},
+		{"   ", []string{}},
+		{"a", []string{"a"}},
+		{"a b  c", []string{"a", "b", "c"}},
+		{" \ta\tb ", []string{"a", "b"}},
+		{`'a b' c`, []string{"a b", "c"}},
+		{`"a b" c`, []string{"a b", "c"}},
+		{`''`, []string{""}},
+		{`a""b`, []string{"ab"}},
+		{`"a 'b' c"`, []string{"a 'b' c"}},
+		{`'a "b" c'`, []string{`a "b" c`}},
+		{`"a \"b\" \\ \c"`, []string{`a "b" \ \c`}},
+		{`'a \b'`, []string{`a \b`}},
+		{`a\ b`, []string{"a b"}},
+		{`--name="John Doe" -x`, []string{"--name=John Doe", "-x"}},
+	}
+
+	for _, cas := range cases {
+		res, err := shellTokenize(cas.line)
+		require.Nil(t, err, "tokenizing %q", cas.line)
+		require.Equal(t, cas.expected, res, "tokenizing %q", cas.line)
+	}
+
+	badCases := []string{
+		`'a`,
+		`"a`,
+		`a\`,
+		`"a\"`,
+	}
+
+	for _, cas := range badCases {
+		_, err := shellTokenize(cas)
+		require.NotNil(t, err, "tokenizing %q should have failed", cas)
+	}
+}
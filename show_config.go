@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var configFormats = []string{"yaml", "toml", "json"}
+
+/*
+ShowConfig adds an option named `name` to the command which prints the values of the command's other options once resolved
+(from the call arguments, the environment variables, a config file, ...) and exits, e.g.:
+
+	app.ShowConfig("show-config")
+
+	$ app --show-config
+	$ app --show-config=toml
+
+The values are printed in YAML by default, or in the format passed to the option: `yaml`, `toml` or `json` (see WriteConfig)
+*/
+func (c *Cmd) ShowConfig(name string) {
+	c.String(StringOpt{
+		Name:          name,
+		Value:         "",
+		Desc:          "Print the options values (as yaml, toml or json) and exit",
+		HideValue:     true,
+		OptionalValue: "yaml",
+		ChoiceFunc:    func() []string { return configFormats },
+	})
+	names := mkOptStrs(name)
+	c.showConfig = c.optionsIdx[names[0]]
+}
+
+/*
+WriteConfig writes to w the current values of the command's options in the passed format, `yaml`, `toml` or `json`,
+e.g. to save the effective configuration.
+
+Each option is written in its declaration order, under its longest name without the dashes, so that the output can be loaded back using ConfigFile.
+The durations are written as strings, e.g. `"1h30m0s"`, and the slices as arrays
+*/
+func (c *Cmd) WriteConfig(w io.Writer, format string) error {
+	options := []*opt{}
+	for _, opt := range c.options {
+		if opt != c.jsonFlags && opt != c.configFile && opt != c.showConfig {
+			options = append(options, opt)
+		}
+	}
+
+	switch format {
+	case "yaml", "toml":
+		sep := ": "
+		if format == "toml" {
+			sep = " = "
+		}
+		for _, opt := range options {
+			if _, err := fmt.Fprintf(w, "%s%s%s\n", strings.TrimLeft(opt.displayName(), "-"), sep, configValue(reflect.ValueOf(opt.shownValue()))); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "json":
+		values := map[string]interface{}{}
+		for _, opt := range options {
+			v := reflect.ValueOf(opt.shownValue())
+			switch {
+			case v.Type() == durationType:
+				values[strings.TrimLeft(opt.displayName(), "-")] = v.Interface().(time.Duration).String()
+			case v.Kind() == reflect.Slice && v.Type().Elem() == durationType:
+				values[strings.TrimLeft(opt.displayName(), "-")] = durationStrings(v)
+			case v.Kind() == reflect.Slice && v.IsNil():
+				values[strings.TrimLeft(opt.displayName(), "-")] = reflect.MakeSlice(v.Type(), 0, 0).Interface()
+			default:
+				values[strings.TrimLeft(opt.displayName(), "-")] = v.Interface()
+			}
+		}
+		enc, err := json.MarshalIndent(values, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(enc, '\n'))
+		return err
+	default:
+		return fmt.Errorf("unsupported config format %q", format)
+	}
+}
+
+// configValue formats v as a YAML or TOML value, the strings being double quoted and the slices written as inline arrays
+func configValue(v reflect.Value) string {
+	switch {
+	case v.Type() == durationType:
+		return strconv.Quote(v.Interface().(time.Duration).String())
+	case v.Kind() == reflect.String:
+		return strconv.Quote(v.String())
+	case v.Kind() == reflect.Slice:
+		items := []string{}
+		for i := 0; i < v.Len(); i++ {
+			items = append(items, configValue(v.Index(i)))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	default:
+		return fmt.Sprintf("%v", v.Interface())
+	}
+}
+
+// printConfig prints the options values and exits if the option added by ShowConfig was set, and returns true in this case
+func (c *Cmd) printConfig() (bool, error) {
+	if c.showConfig == nil || c.showConfig.source != SourceCLI {
+		return false, nil
+	}
+	if err := c.WriteConfig(stdOut, c.showConfig.get().(string)); err != nil {
+		return true, err
+	}
+	exiter(0)
+	return true, nil
+}
@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShowConfig(t *testing.T) {
+	var (
+		output  *string
+		count   *int
+		verbose *bool
+		timeout *time.Duration
+		tags    *[]string
+		ids     *[]int
+	)
+	init := func(app *Cli) {
+		output = app.StringOpt("o output", "out.txt", "")
+		count = app.IntOpt("count", 1, "")
+		verbose = app.BoolOpt("v", false, "")
+		timeout = app.DurationOpt("timeout", time.Minute, "")
+		tags = app.StringsOpt("t tag", nil, "")
+		ids = app.IntsOpt("id", []int{1}, "")
+		app.ConfigFile("config")
+		app.ShowConfig("show-config")
+	}
+	show := func(args ...string) string {
+		var out string
+		defer captureAndRestoreOutput(&out, nil)()
+		exitCalled := false
+		defer exitShouldBeCalledWith(t, 0, &exitCalled)()
+		require.Nil(t, testApp(init).Run(append([]string{"app"}, args...)))
+		require.True(t, exitCalled, "the app should exit after showing the config")
+		return out
+	}
+
+	require.Equal(t, `output: "out.txt"
+count: 1
+v: false
+timeout: "1m0s"
+tag: []
+id: [1]
+`, show("--show-config"))
+
+	require.Equal(t, `output = "say \"hi\""
+count = 3
+v = true
+timeout = "1h30m0s"
+tag = ["a", "b"]
+id = [1, 2]
+`, show("-o", `say "hi"`, "--count", "3", "-v", "--timeout", "90m", "-t", "a", "-t", "b", "--id", "2", "--show-config=toml"))
+
+	js := show("-o", "x", "-v", "-t", "a", "--timeout", "2h", "--show-config=json")
+	require.Equal(t, `{
+  "count": 1,
+  "id": [
+    1
+  ],
+  "output": "x",
+  "tag": [
+    "a"
+  ],
+  "timeout": "2h0m0s",
+  "v": true
+}
+`, js)
+
+	configs := map[string]string{"app.json": js}
+	for _, format := range []string{"yaml", "toml"} {
+		configs["app."+format] = show("-o", "x", "-v", "-t", "a", "--timeout", "2h", "--show-config="+format)
+	}
+
+	dir, err := ioutil.TempDir("", "mow-cli-show-config")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	defer exitShouldNotCalled(t)()
+	defer suppressOutput()()
+	for name, content := range configs {
+		path := filepath.Join(dir, name)
+		require.Nil(t, ioutil.WriteFile(path, []byte(content), 0644))
+
+		require.Nil(t, runApp(init, "--config", path), "config %s", name)
+		require.Equal(t, "x", *output, "the written config %s should be loaded back", name)
+		require.Equal(t, 1, *count, "config %s", name)
+		require.True(t, *verbose, "config %s", name)
+		require.Equal(t, 2*time.Hour, *timeout, "config %s", name)
+		require.Equal(t, []string{"a"}, *tags, "config %s", name)
+		require.Equal(t, []int{1}, *ids, "config %s", name)
+	}
+
+	require.NotNil(t, runApp(init, "--show-config=xml"))
+
+	var buf bytes.Buffer
+	err = testApp(init).WriteConfig(&buf, "ini")
+	require.NotNil(t, err)
+	require.Equal(t, `unsupported config format "ini"`, err.Error())
+}
@@ -299,6 +299,95 @@ func TestSpecIntOpt(t *testing.T) {
 	}
 }
 
+func TestSpecAttachedShortOptValue(t *testing.T) {
+	var (
+		x, v *bool
+		j    *int
+		o    *string
+	)
+	init := func(c *Cmd) {
+		x = c.BoolOpt("x", false, "")
+		v = c.BoolOpt("v", false, "")
+		j = c.IntOpt("j", 1, "")
+		o = c.StringOpt("O", "0", "")
+	}
+
+	spec := "[OPTIONS]"
+	cases := []struct {
+		args []string
+		x, v bool
+		j    int
+		o    string
+	}{
+		{[]string{"-j4"}, false, false, 4, "0"},
+		{[]string{"-j", "4"}, false, false, 4, "0"},
+		{[]string{"-j=4"}, false, false, 4, "0"},
+		{[]string{"-xj4"}, true, false, 4, "0"},
+		{[]string{"-xvj4", "-O2"}, true, true, 4, "2"},
+		{[]string{"-xj", "4"}, true, false, 4, "0"},
+		{[]string{"-O2", "-j10"}, false, false, 10, "2"},
+		{[]string{"-Ox=1"}, false, false, 1, "x=1"},
+	}
+	for _, cas := range cases {
+		okCmd(t, spec, init, cas.args)
+		require.Equal(t, cas.x, *x, "args %v", cas.args)
+		require.Equal(t, cas.v, *v, "args %v", cas.args)
+		require.Equal(t, cas.j, *j, "args %v", cas.args)
+		require.Equal(t, cas.o, *o, "args %v", cas.args)
+	}
+
+	badCases := [][]string{
+		{"-j4x"},
+		{"-jx"},
+		{"-j"},
+		{"-xj"},
+	}
+	for _, args := range badCases {
+		failCmd(t, spec, init, args)
+	}
+}
+
+func TestSpecOptValueNeverConsumesAnOption(t *testing.T) {
+	var (
+		output  *string
+		verbose *bool
+	)
+	init := func(c *Cmd) {
+		output = c.StringOpt("o output", "", "")
+		verbose = c.BoolOpt("v verbose", false, "")
+	}
+
+	for _, args := range [][]string{
+		{"--output", "--verbose"},
+		{"-o", "-v"},
+		{"--output", "-v"},
+		{"-v", "--output", "--verbose"},
+	} {
+		failCmd(t, "[OPTIONS]", init, args)
+	}
+
+	okCmd(t, "[OPTIONS]", init, []string{"--output=--verbose"})
+	require.Equal(t, "--verbose", *output, "a value starting with a dash should be accepted using the = form")
+	require.False(t, *verbose)
+
+	okCmd(t, "[OPTIONS]", init, []string{"-o", "out", "--verbose"})
+	require.Equal(t, "out", *output)
+	require.True(t, *verbose)
+}
+
+func TestSpecLongOptValueWithEquals(t *testing.T) {
+	var output *string
+	init := func(c *Cmd) {
+		output = c.StringOpt("o output", "", "")
+	}
+
+	okCmd(t, "[-o]", init, []string{"--output=a=b"})
+	require.Equal(t, "a=b", *output)
+
+	okCmd(t, "[-o]", init, []string{"-o=a=b"})
+	require.Equal(t, "a=b", *output)
+}
+
 func TestSpecStrsOpt(t *testing.T) {
 	var f *[]string
 	init := func(c *Cmd) {
@@ -448,6 +537,67 @@ func TestSpecOptionalArg(t *testing.T) {
 
 }
 
+func TestSpecOptionalArgBeforeRequiredArg(t *testing.T) {
+	var src, dst *string
+	init := func(c *Cmd) {
+		src = c.StringArg("SRC", "", "")
+		dst = c.StringArg("DST", "", "")
+	}
+	spec := "[SRC] DST"
+
+	okCmd(t, spec, init, []string{"x"})
+	require.Equal(t, "", *src)
+	require.Equal(t, "x", *dst)
+
+	okCmd(t, spec, init, []string{"x", "y"})
+	require.Equal(t, "x", *src)
+	require.Equal(t, "y", *dst)
+
+	failCmd(t, spec, init, []string{})
+	failCmd(t, spec, init, []string{"x", "y", "z"})
+}
+
+func TestSpecOptionalArgAfterRequiredArg(t *testing.T) {
+	var src, dst *string
+	init := func(c *Cmd) {
+		src = c.StringArg("SRC", "", "")
+		dst = c.StringArg("DST", "", "")
+	}
+	spec := "SRC [DST]"
+
+	okCmd(t, spec, init, []string{"x"})
+	require.Equal(t, "x", *src)
+	require.Equal(t, "", *dst)
+
+	okCmd(t, spec, init, []string{"x", "y"})
+	require.Equal(t, "x", *src)
+	require.Equal(t, "y", *dst)
+
+	failCmd(t, spec, init, []string{})
+	failCmd(t, spec, init, []string{"x", "y", "z"})
+}
+
+func TestSpecOptionalArgsFilledFromTheLeft(t *testing.T) {
+	var a, b, c *string
+	init := func(cmd *Cmd) {
+		a = cmd.StringArg("A", "", "")
+		b = cmd.StringArg("B", "", "")
+		c = cmd.StringArg("C", "", "")
+	}
+	spec := "[A] [B] C"
+
+	okCmd(t, spec, init, []string{"x"})
+	require.Equal(t, []string{"", "", "x"}, []string{*a, *b, *c})
+
+	okCmd(t, spec, init, []string{"x", "y"})
+	require.Equal(t, []string{"x", "", "y"}, []string{*a, *b, *c})
+
+	okCmd(t, spec, init, []string{"x", "y", "z"})
+	require.Equal(t, []string{"x", "y", "z"}, []string{*a, *b, *c})
+
+	failCmd(t, spec, init, []string{"x", "y", "z", "t"})
+}
+
 func TestSpecOptionChoice(t *testing.T) {
 	var f, g *bool
 	init := func(c *Cmd) {
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"bufio"
+	"strings"
+)
+
+/*
+ArgStream iterates over the values of a streamed argument (see StreamArg) as they arrive, without buffering them all in a slice.
+It is used like a bufio.Scanner:
+
+	for files.Next() {
+		process(files.Value())
+	}
+	if err := files.Err(); err != nil {
+		...
+	}
+*/
+type ArgStream struct {
+	values  *[]string
+	pos     int
+	scanner *bufio.Scanner
+	value   string
+}
+
+/*
+StreamArg defines a string slice argument on the command c named `name`, with a description of `desc` which will be used in help messages,
+whose values are consumed through the returned stream instead of a slice, e.g. to process thousands of items:
+
+	cmd.Spec = "[FILES...]"
+	files := cmd.StreamArg("FILES", "the files to process")
+
+	$ app process a.txt b.txt
+	$ find . -name '*.txt' | app process
+
+If the argument is not set in the call arguments, its values are read from the standard input, one per non blank line, as the stream is iterated.
+The argument should thus be made optional in the command spec.
+*/
+func (c *Cmd) StreamArg(name string, desc string) *ArgStream {
+	res := &ArgStream{values: c.StringsArg(name, nil, desc)}
+	c.streams = append(c.streams, res)
+	return res
+}
+
+// start rewinds the stream after the call arguments got parsed
+func (s *ArgStream) start() {
+	s.pos = 0
+	s.scanner = nil
+	s.value = ""
+	if len(*s.values) == 0 {
+		s.scanner = bufio.NewScanner(stdIn)
+	}
+}
+
+/*
+Next advances the stream to its next value, which is then available through Value.
+It returns false when the stream is exhausted or reading the standard input failed, see Err
+*/
+func (s *ArgStream) Next() bool {
+	if s.scanner == nil {
+		if s.pos >= len(*s.values) {
+			return false
+		}
+		s.value = (*s.values)[s.pos]
+		s.pos++
+		return true
+	}
+
+	for s.scanner.Scan() {
+		if line := strings.TrimSpace(s.scanner.Text()); len(line) > 0 {
+			s.value = line
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Value returns the current value of the stream, as set by the last call to Next
+*/
+func (s *ArgStream) Value() string {
+	return s.value
+}
+
+/*
+Err returns the error which occurred while reading the standard input, if any
+*/
+func (s *ArgStream) Err() error {
+	if s.scanner == nil {
+		return nil
+	}
+	return s.scanner.Err()
+}
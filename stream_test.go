@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamArg(t *testing.T) {
+	defer suppressOutput()()
+
+	var (
+		files *ArgStream
+		got   []string
+	)
+	app := App("app", "")
+	app.ErrorHandling = flag.ContinueOnError
+	app.Spec = "[FILES...]"
+	files = app.StreamArg("FILES", "")
+	app.Action = func() {
+		got = nil
+		for files.Next() {
+			got = append(got, files.Value())
+		}
+		require.Nil(t, files.Err())
+	}
+
+	require.Nil(t, app.Run([]string{"app", "a", "b"}))
+	require.Equal(t, []string{"a", "b"}, got)
+
+	var input strings.Builder
+	var expected []string
+	for i := 0; i < 10000; i++ {
+		fmt.Fprintf(&input, "file-%d\n", i)
+		if i%100 == 0 {
+			input.WriteString("  \n")
+		}
+		expected = append(expected, fmt.Sprintf("file-%d", i))
+	}
+	defer provideInput(strings.NewReader(input.String()))()
+
+	app.Reset()
+	require.Nil(t, app.Run([]string{"app"}))
+	require.Equal(t, expected, got)
+}
+
+func TestStreamArgIncremental(t *testing.T) {
+	defer suppressOutput()()
+
+	lines := make(chan string)
+	defer provideInput(&chanReader{lines: lines})()
+
+	app := App("app", "")
+	app.Spec = "[FILES...]"
+	files := app.StreamArg("FILES", "")
+	app.Action = func() {
+		go func() {
+			lines <- "a\n"
+			lines <- "b\n"
+			close(lines)
+		}()
+		require.True(t, files.Next())
+		require.Equal(t, "a", files.Value())
+		require.True(t, files.Next())
+		require.Equal(t, "b", files.Value())
+		require.False(t, files.Next())
+	}
+
+	require.Nil(t, app.Run([]string{"app"}))
+}
+
+// chanReader is a reader returning the lines sent to it one at a time, as a slow producer would
+type chanReader struct {
+	lines chan string
+}
+
+func (r *chanReader) Read(p []byte) (int, error) {
+	line, ok := <-r.lines
+	if !ok {
+		return 0, io.EOF
+	}
+	return copy(p, line), nil
+}
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+/*
+Summary turns on or off the report of the inputs which were ignored or passed through when parsing the call arguments, instead of failing the run:
+the unknown options dropped with the Lenient profile (see Profile) or collected by Passthrough,
+and the environment variables whose value is invalid for their option or argument.
+
+Each of them is reported as a warning (see OnWarning) once the call arguments of a command got parsed, e.g.:
+
+	Warning: ignored unknown option --colour
+	Warning: passed through --memory 2g
+	Warning: ignored environment variable APP_COUNT: invalid value "ten" for option --count
+*/
+func (cli *Cli) Summary(enabled bool) {
+	cli.summary = enabled
+}
+
+// reportIgnored warns about the inputs which were ignored or passed through by the parse of the call arguments, if the summary is enabled
+func (c *Cmd) reportIgnored() {
+	if !c.summary {
+		return
+	}
+	for _, arg := range c.ignored {
+		c.warn(fmt.Sprintf("ignored unknown option %s", arg))
+	}
+	if c.passthrough != nil && len(*c.passthrough) > 0 {
+		c.warn(fmt.Sprintf("passed through %s", strings.Join(*c.passthrough, " ")))
+	}
+	for _, opt := range c.options {
+		if opt.envInvert {
+			continue
+		}
+		conv := opt.envConv
+		if opt.units != nil {
+			conv = func(v string) (reflect.Value, error) {
+				s, err := opt.units.convert(v)
+				return reflect.ValueOf(s), err
+			}
+		}
+		for _, ev := range invalidEnvVars(opt.envVar, conv) {
+			c.warn(fmt.Sprintf("ignored environment variable %s: invalid value %q for option %s", ev, opt.maskString(os.Getenv(ev)), opt.displayName()))
+		}
+	}
+	for _, arg := range c.args {
+		t := arg.value.Elem().Type()
+		for _, ev := range invalidEnvVars(arg.envVar, func(v string) (reflect.Value, error) { return vconv(v, t) }) {
+			c.warn(fmt.Sprintf("ignored environment variable %s: invalid value %q for argument %s", ev, os.Getenv(ev), arg.name))
+		}
+	}
+}
+
+// invalidEnvVars returns the env vars in envVars which are set to a value that conv fails to convert
+func invalidEnvVars(envVars string, conv func(string) (reflect.Value, error)) []string {
+	res := []string{}
+	for _, ev := range envVarNames(envVars) {
+		v := os.Getenv(ev)
+		if len(v) == 0 {
+			continue
+		}
+		if _, err := conv(v); err != nil {
+			res = append(res, ev)
+		}
+	}
+	return res
+}
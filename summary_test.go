@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummary(t *testing.T) {
+	defer suppressOutput()()
+
+	os.Setenv("APP_TEST_COUNT", "ten")
+	os.Setenv("APP_TEST_COUNT2", "3")
+	os.Setenv("APP_TEST_LEVEL", "high")
+	os.Setenv("APP_TEST_SIZE", "big")
+	defer func() {
+		for _, ev := range []string{"APP_TEST_COUNT", "APP_TEST_COUNT2", "APP_TEST_LEVEL", "APP_TEST_SIZE"} {
+			os.Unsetenv(ev)
+		}
+	}()
+
+	var (
+		warnings []string
+		summary  = true
+	)
+	init := func(app *Cli) {
+		warnings = nil
+		app.Profile(Lenient)
+		app.Summary(summary)
+		app.OnWarning(func(msg string) { warnings = append(warnings, msg) })
+		app.Int(IntOpt{Name: "count", EnvVar: "APP_TEST_COUNT APP_TEST_COUNT2"})
+		app.Int(IntOpt{Name: "level", EnvVar: "APP_TEST_LEVEL"})
+		app.Command("run", "", func(cmd *Cmd) {
+			cmd.Spec = "[OPTIONS] [SIZE]"
+			cmd.BoolOpt("v", false, "")
+			cmd.Int(IntArg{Name: "SIZE", EnvVar: "APP_TEST_SIZE"})
+			cmd.Passthrough()
+			cmd.Action = func() {}
+		})
+	}
+
+	require.Nil(t, runApp(init, "--colour", "auto", "-x", "run", "-v", "--memory", "2g", "--rm"))
+	require.Equal(t, []string{
+		"ignored unknown option --colour auto",
+		"ignored unknown option -x",
+		`ignored environment variable APP_TEST_COUNT: invalid value "ten" for option --count`,
+		`ignored environment variable APP_TEST_LEVEL: invalid value "high" for option --level`,
+		"passed through --memory 2g --rm",
+		`ignored environment variable APP_TEST_SIZE: invalid value "big" for argument SIZE`,
+	}, warnings)
+
+	summary = false
+	require.Nil(t, runApp(init, "--colour", "auto", "run", "--rm"))
+	require.Empty(t, warnings, "no summary should be reported unless enabled")
+}
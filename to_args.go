@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+/*
+ToArgs returns the call arguments reproducing the current values of the command's options which differ from their initial values,
+e.g. to save them as a preset:
+
+	app.Action = func() {
+		savePreset(app.ToArgs())
+	}
+
+The options are returned in their declaration order, using their longest name, e.g. `--output out.txt`.
+A bool option is returned without a value when it is true, and a counter option is repeated, e.g. `-v -v`.
+The values starting with a dash, and the values of the options accepting an optional value, use the `--name=value` form.
+The values of a slice option which were appended to its initial value are returned as one option per value.
+
+The values of the secret options are masked, and are hence not reproduced.
+
+The arguments are not returned.
+*/
+func (c *Cmd) ToArgs() []string {
+	res := []string{}
+	for _, opt := range c.options {
+		if opt == c.jsonFlags || opt == c.configFile || opt == c.showConfig {
+			continue
+		}
+		res = append(res, opt.toArgs()...)
+	}
+	return res
+}
+
+// toArgs returns the call arguments setting the option to its current value, if it differs from its initial value
+func (o *opt) toArgs() []string {
+	current := o.value.Elem()
+	initial := reflect.ValueOf(o.defaultValue)
+	if reflect.DeepEqual(current.Interface(), initial.Interface()) {
+		return nil
+	}
+	name := o.displayName()
+
+	switch {
+	case o.isBool():
+		if current.Bool() {
+			return []string{name}
+		}
+		return []string{name + "=false"}
+	case o.isCounter():
+		res := []string{}
+		for i := initial.Int(); i+int64(o.step) <= current.Int(); i += int64(o.step) {
+			res = append(res, name)
+		}
+		return res
+	case o.isMulti():
+		res := []string{}
+		for i := appendedFrom(current, initial); i < current.Len(); i++ {
+			res = append(res, optArgs(name, fmt.Sprintf("%v", current.Index(i).Interface()), false)...)
+		}
+		return res
+	default:
+		return optArgs(name, fmt.Sprintf("%v", o.shownValue()), o.hasOptionalValue())
+	}
+}
+
+// appendedFrom returns the index of the first value of the slice current which was appended to the slice initial,
+// or 0 if current does not start with the values of initial
+func appendedFrom(current, initial reflect.Value) int {
+	if initial.Len() > current.Len() {
+		return 0
+	}
+	for i := 0; i < initial.Len(); i++ {
+		if !reflect.DeepEqual(current.Index(i).Interface(), initial.Index(i).Interface()) {
+			return 0
+		}
+	}
+	return initial.Len()
+}
+
+func optArgs(name, value string, inline bool) []string {
+	if inline || strings.HasPrefix(value, "-") {
+		return []string{name + "=" + value}
+	}
+	return []string{name, value}
+}
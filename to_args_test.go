@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestToArgs(t *testing.T) {
+	mkCmd := func() *Cmd {
+		cmd := &Cmd{name: "test", optionsIdx: map[string]*opt{}, argsIdx: map[string]*arg{}}
+		cmd.ErrorHandling = flag.ContinueOnError
+		cmd.BoolOpt("f force", false, "")
+		cmd.BoolOpt("color", true, "")
+		cmd.StringOpt("o output", "out", "")
+		cmd.IntOpt("n", 1, "")
+		cmd.DurationOpt("timeout", time.Minute, "")
+		cmd.StringsOpt("t tag", []string{"default"}, "")
+		cmd.IntCounter(IntCounterOpt{Name: "v", Value: 0})
+		cmd.String(StringOpt{Name: "log", Value: "", OptionalValue: "stderr"})
+		cmd.StringArg("SRC", "", "")
+		cmd.Spec = "[OPTIONS] [SRC]"
+		require.Nil(t, cmd.doInit())
+		return cmd
+	}
+	parse := func(args []string) *Cmd {
+		cmd := mkCmd()
+		require.Nil(t, cmd.fsm.parse(args), "args %v", args)
+		require.Nil(t, cmd.resolve())
+		return cmd
+	}
+	values := func(cmd *Cmd) []interface{} {
+		res := []interface{}{}
+		for _, opt := range cmd.options {
+			res = append(res, opt.get())
+		}
+		return res
+	}
+
+	cases := []struct {
+		args     []string
+		expected []string
+	}{
+		{[]string{}, []string{}},
+		{[]string{"src"}, []string{}},
+		{[]string{"-o", "out", "-n", "1"}, []string{}},
+		{[]string{"-f", "--color=false"}, []string{"--force", "--color=false"}},
+		{[]string{"-o", "x y", "-n=-3", "src"}, []string{"--output", "x y", "-n=-3"}},
+		{[]string{"--timeout", "90s", "-t", "a", "--tag", "b"}, []string{"--timeout", "1m30s", "--tag", "a", "--tag", "b"}},
+		{[]string{"-vvv", "--log"}, []string{"-v", "-v", "-v", "--log=stderr"}},
+		{[]string{"--log=file.log", "-fv"}, []string{"--force", "-v", "--log=file.log"}},
+	}
+
+	for _, cas := range cases {
+		cmd := parse(cas.args)
+		args := cmd.ToArgs()
+		require.Equal(t, cas.expected, args, "args %v", cas.args)
+		require.Equal(t, values(cmd), values(parse(args)), "args %v should be reproduced by %v", cas.args, args)
+	}
+}
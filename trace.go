@@ -0,0 +1,89 @@
+package cli
+
+import "strings"
+
+/*
+Source identifies where the value of an option or an argument comes from
+*/
+type Source string
+
+const (
+	// SourceDefault is the source of the values which were left to their declared initial value
+	SourceDefault Source = "default"
+	// SourceEnv is the source of the values which were initialized from an environment variable
+	SourceEnv Source = "env"
+	// SourceCLI is the source of the values which were set from the call arguments
+	SourceCLI Source = "cli"
+	// SourceConfig is the source of the values which were set from a config file (see ConfigFile)
+	SourceConfig Source = "config"
+	// SourceJSON is the source of the values which were set from a JSON object passed in the call arguments (see FlagsFromJSON)
+	SourceJSON Source = "json"
+	// SourceQuery is the source of the values which were set from a query string (see SetFromQuery)
+	SourceQuery Source = "query"
+	// SourceExternal is the source of the values which were read from a ValueSource (see ValueSources)
+	SourceExternal Source = "external"
+	// SourceImplied is the source of the values which were set because another option implies them (see Implies)
+	SourceImplied Source = "implied"
+)
+
+func sourceFor(envVar string) Source {
+	if len(envVar) > 0 {
+		return SourceEnv
+	}
+	return SourceDefault
+}
+
+/*
+Trace describes how the values of a command options and arguments were resolved.
+It can be serialized to JSON, e.g. to be logged or compared between runs
+*/
+type Trace struct {
+	// The command path, e.g. `app cmd sub`
+	Command string `json:"command"`
+	// The command options, in their declaration order
+	Options []ValueTrace `json:"options"`
+	// The command arguments, in their declaration order
+	Args []ValueTrace `json:"args"`
+}
+
+/*
+ValueTrace describes the value of an option or an argument and where it comes from
+*/
+type ValueTrace struct {
+	// The option names (with the dashes) or the argument name
+	Name string `json:"name"`
+	// The option or argument current value
+	Value interface{} `json:"value"`
+	// Where the value comes from
+	Source Source `json:"source"`
+	// The environment variable the value was read from, if Source is SourceEnv
+	EnvVar string `json:"envVar,omitempty"`
+}
+
+/*
+Trace returns the current values of the command's options and arguments together with their sources.
+It is meant to be called after the call arguments got parsed, e.g. in the command Action
+*/
+func (c *Cmd) Trace() Trace {
+	res := Trace{
+		Command: strings.Join(append(c.parents, c.name), " "),
+		Options: []ValueTrace{},
+		Args:    []ValueTrace{},
+	}
+
+	for _, opt := range c.options {
+		res.Options = append(res.Options, newValueTrace(strings.Join(opt.names, " "), opt.shownValue(), opt.source, opt.sourceEnvVar))
+	}
+	for _, arg := range c.args {
+		res.Args = append(res.Args, newValueTrace(arg.name, arg.get(), arg.source, arg.sourceEnvVar))
+	}
+	return res
+}
+
+func newValueTrace(name string, value interface{}, source Source, envVar string) ValueTrace {
+	res := ValueTrace{Name: name, Value: value, Source: source}
+	if source == SourceEnv {
+		res.EnvVar = envVar
+	}
+	return res
+}
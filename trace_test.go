@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrace(t *testing.T) {
+	defer exitShouldNotCalled(t)()
+	defer suppressOutput()()
+
+	os.Setenv("TRACE_OUTPUT", "")
+	os.Setenv("TRACE_LEVEL", "7")
+	os.Setenv("TRACE_USER", "env-user")
+
+	app := App("app", "")
+	var trace Trace
+	app.Command("run", "", func(cmd *Cmd) {
+		cmd.String(StringOpt{Name: "o output", Value: "out", EnvVar: "TRACE_OUTPUT"})
+		cmd.Int(IntOpt{Name: "l level", Value: 1, EnvVar: "TRACE_LEVEL"})
+		cmd.String(StringOpt{Name: "u user", Value: "root", EnvVar: "TRACE_OUTPUT TRACE_USER"})
+		cmd.Strings(StringsArg{Name: "FILES", Value: nil})
+		cmd.Spec = "[OPTIONS] FILES..."
+
+		cmd.Action = func() {
+			trace = cmd.Trace()
+		}
+	})
+
+	app.Run([]string{"app", "run", "-u", "cli-user", "a", "b"})
+
+	require.Equal(t, Trace{
+		Command: "app run",
+		Options: []ValueTrace{
+			{Name: "-o --output", Value: "out", Source: SourceDefault},
+			{Name: "-l --level", Value: 7, Source: SourceEnv, EnvVar: "TRACE_LEVEL"},
+			{Name: "-u --user", Value: "cli-user", Source: SourceCLI},
+		},
+		Args: []ValueTrace{
+			{Name: "FILES", Value: []string{"a", "b"}, Source: SourceCLI},
+		},
+	}, trace)
+
+	js, err := json.Marshal(trace)
+	require.Nil(t, err)
+	require.Equal(t, `{"command":"app run","options":[`+
+		`{"name":"-o --output","value":"out","source":"default"},`+
+		`{"name":"-l --level","value":7,"source":"env","envVar":"TRACE_LEVEL"},`+
+		`{"name":"-u --user","value":"cli-user","source":"cli"}],`+
+		`"args":[{"name":"FILES","value":["a","b"],"source":"cli"}]}`, string(js))
+}
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+Unit describes a unit accepted by a UnitOpt, a value expressed in this unit being converted to the base unit as `value*Factor + Offset`, e.g.:
+
+	map[string]cli.Unit{
+		"km": {Factor: 1000},              // to meters
+		"C":  {Factor: 1, Offset: 273.15}, // to Kelvin
+	}
+*/
+type Unit struct {
+	// The multiplier converting a value to the base unit
+	Factor float64
+	// The amount added to a value once multiplied, e.g. for the temperatures
+	Offset float64
+}
+
+// unitConverter converts the values suffixed with a unit to the base unit
+type unitConverter struct {
+	base     string
+	units    map[string]Unit
+	suffixes []string
+}
+
+func newUnitConverter(base string, units map[string]Unit) *unitConverter {
+	res := &unitConverter{base: base, units: map[string]Unit{}}
+	for suffix, unit := range units {
+		res.units[suffix] = unit
+	}
+	if len(base) > 0 {
+		res.units[base] = Unit{Factor: 1}
+	}
+	for suffix := range res.units {
+		res.suffixes = append(res.suffixes, suffix)
+	}
+	// the longest suffixes first, so that e.g. `km` is not matched as `m`
+	sort.Sort(suffixesByLength(res.suffixes))
+	return res
+}
+
+type suffixesByLength []string
+
+func (s suffixesByLength) Len() int      { return len(s) }
+func (s suffixesByLength) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s suffixesByLength) Less(i, j int) bool {
+	if len(s[i]) != len(s[j]) {
+		return len(s[i]) > len(s[j])
+	}
+	return s[i] < s[j]
+}
+
+// convert returns the value of s in the base unit, s being a number optionally followed by one of the units suffixes
+func (u *unitConverter) convert(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	for _, suffix := range u.suffixes {
+		if !strings.HasSuffix(s, suffix) {
+			continue
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(s, suffix)), 64)
+		if err != nil {
+			continue
+		}
+		unit := u.units[suffix]
+		return strconv.FormatFloat(f*unit.Factor+unit.Offset, 'g', -1, 64), nil
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return s, nil
+	}
+	return s, fmt.Errorf("invalid quantity %q, expected a number followed by one of the units %s", s, strings.Join(u.sortedSuffixes(), ", "))
+}
+
+func (u *unitConverter) sortedSuffixes() []string {
+	res := append([]string{}, u.suffixes...)
+	sort.Strings(res)
+	return res
+}
+
+// format shows the value in the base unit, e.g. `5000m`
+func (u *unitConverter) format(v interface{}) string {
+	return fmt.Sprintf("%v%s", v, u.base)
+}
+
+// vinit initializes into from the first env var in envVars with a valid quantity, or else with defaultValue.
+// It returns the name of the env var which was used, if any
+func (u *unitConverter) vinit(into reflect.Value, envVars string, defaultValue interface{}) string {
+	for _, ev := range envVarNames(envVars) {
+		v := os.Getenv(ev)
+		if len(v) == 0 {
+			continue
+		}
+		if conv, err := u.convert(v); err == nil {
+			if f, err := strconv.ParseFloat(conv, 64); err == nil {
+				into.Elem().SetFloat(f)
+				return ev
+			}
+		}
+	}
+	into.Elem().Set(reflect.ValueOf(defaultValue))
+	return ""
+}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"math"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnitOpt(t *testing.T) {
+	defer suppressOutput()()
+
+	var distance, temp *float64
+	init := func(app *Cli) {
+		distance = app.Unit(UnitOpt{
+			Name:  "d distance",
+			Value: 100,
+			Base:  "m",
+			Units: map[string]Unit{"km": {Factor: 1000}, "cm": {Factor: 0.01}, "mi": {Factor: 1609.344}},
+		})
+		temp = app.Unit(UnitOpt{
+			Name:   "temp",
+			EnvVar: "APP_TEST_TEMP",
+			Base:   "K",
+			Units:  map[string]Unit{"C": {Factor: 1, Offset: 273.15}, "F": {Factor: 5.0 / 9, Offset: 273.15 - 32*5.0/9}},
+		})
+	}
+
+	cases := []struct {
+		args     []string
+		distance float64
+		temp     float64
+	}{
+		{[]string{"app"}, 100, 0},
+		{[]string{"app", "-d", "5km"}, 5000, 0},
+		{[]string{"app", "--distance=250cm"}, 2.5, 0},
+		{[]string{"app", "--distance", "12m"}, 12, 0},
+		{[]string{"app", "--distance", "12"}, 12, 0},
+		{[]string{"app", "--distance", " 1.5 km"}, 1500, 0},
+		{[]string{"app", "--distance", "2mi"}, 3218.688, 0},
+		{[]string{"app", "--temp", "20C"}, 100, 293.15},
+		{[]string{"app", "--temp=-5.5C"}, 100, 267.65},
+		{[]string{"app", "--temp", "212F"}, 100, 373.15},
+		{[]string{"app", "--temp", "300K"}, 100, 300},
+	}
+	for _, cas := range cases {
+		app := testApp(init)
+		require.Nil(t, app.Run(cas.args), "%v", cas.args)
+		require.True(t, math.Abs(cas.distance-*distance) < 1e-9, "%v: expected distance %v, got %v", cas.args, cas.distance, *distance)
+		require.True(t, math.Abs(cas.temp-*temp) < 1e-9, "%v: expected temp %v, got %v", cas.args, cas.temp, *temp)
+	}
+
+	for _, bad := range []string{"5", "x", "5kg", "km", "5C0"} {
+		err := runApp(init, "--temp", bad)
+		if bad == "5" {
+			require.Nil(t, err)
+			continue
+		}
+		require.NotNil(t, err, "%s should have been rejected", bad)
+		require.True(t, strings.Contains(err.Error(), `expected a number followed by one of the units C, F, K`), "unexpected error %s", err)
+	}
+
+	os.Setenv("APP_TEST_TEMP", "10C")
+	defer os.Unsetenv("APP_TEST_TEMP")
+	app := testApp(init)
+	require.True(t, math.Abs(283.15-*temp) < 1e-9, "expected temp 283.15, got %v", *temp)
+	require.Equal(t, SourceEnv, app.optionsIdx["--temp"].source)
+}
+
+func TestUnitOptHelp(t *testing.T) {
+	var stdErr string
+	defer captureAndRestoreOutput(nil, &stdErr)()
+	defer exitShouldBeCalledWith(t, 2, new(bool))()
+
+	app := App("app", "")
+	app.Unit(UnitOpt{Name: "d distance", Value: 1500, Base: "m", Units: map[string]Unit{"km": {Factor: 1000}}, Desc: "Distance"})
+	app.Run([]string{"app", "-h"})
+
+	require.Equal(t, `
+Usage: app [OPTIONS]
+
+
+Options:
+  -d, --distance=1500m   Distance
+`, stdErr)
+}
@@ -0,0 +1,286 @@
+package cli
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// envVarNameRe matches the portable environment variable names
+var envVarNameRe = regexp.MustCompile(`^[A-Z_][A-Z0-9_]*$`)
+
+/*
+ValidateExamples checks that the examples of the command and of all its sub commands are accepted, e.g. in a test to keep the help messages accurate:
+the example values of the options (see the Example field of StringOpt for instance), and the example call arguments of the commands (see Examples):
+
+	func TestExamples(t *testing.T) {
+		if err := app.ValidateExamples(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+The examples are parsed in a dry run: the options and arguments values are left untouched, the actions are not run,
+and neither are the Transforms functions, nor the OnSet callbacks of the options.
+The first invalid example is returned as an error, together with the option or the command it belongs to
+*/
+func (c *Cmd) ValidateExamples() error {
+	if err := c.doInit(); err != nil {
+		return err
+	}
+	path := strings.Join(append(c.parents, c.name), " ")
+	for _, opt := range c.options {
+		if len(opt.example) == 0 {
+			continue
+		}
+		if err := opt.validate(opt.example); err != nil {
+			return fmt.Errorf("invalid example %q of option %s in command %s: %v", opt.example, opt.displayName(), path, err)
+		}
+	}
+	for _, example := range c.Examples {
+		if err := c.validateExample(example); err != nil {
+			return fmt.Errorf("invalid example %q of command %s: %v", example, path, err)
+		}
+	}
+	for _, sub := range c.commands {
+		sub.parents = append(append([]string{}, c.parents...), c.name)
+		if err := sub.ValidateExamples(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateExample parses the example call arguments line in a dry run, following the sub commands it selects
+func (c *Cmd) validateExample(line string) error {
+	args, err := shellTokenize(line)
+	if err != nil {
+		return err
+	}
+	return c.validateArgs(args)
+}
+
+// validateArgs checks that args are accepted by the command and by the sub commands they select, leaving the values untouched
+func (c *Cmd) validateArgs(args []string) error {
+	if err := c.doInit(); err != nil {
+		return err
+	}
+	args, err := c.expandAliases(args)
+	if err != nil {
+		return err
+	}
+	n := c.getOptsAndArgs(args)
+	pc, ok, err := c.fsm.accept(args[:n])
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return c.fsm.usageError(args[:n], *pc.exhausted, *pc.missing)
+	}
+	for _, opt := range c.options {
+		for _, v := range pc.opts[opt] {
+			if err := opt.validate(v); err != nil {
+				return err
+			}
+		}
+	}
+	for _, arg := range c.args {
+		for _, v := range pc.args[arg] {
+			if err := arg.validate(v); err != nil {
+				return err
+			}
+		}
+	}
+	if n == len(args) {
+		return nil
+	}
+	return c.commandNamed(args[n]).validateArgs(args[n+1:])
+}
+
+// validate checks that the option accepts s as a value in the call arguments, without running its transforms nor its callback,
+// and leaving its value untouched
+func (o *opt) validate(s string) error {
+	choices := o.choices
+	defer func() {
+		o.choices = choices
+	}()
+	for _, part := range o.splitValue(s) {
+		v, err := o.convert(part, false)
+		if err != nil {
+			return err
+		}
+		if !o.isCounter() {
+			if err := vset(reflect.New(o.value.Elem().Type()), v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validate checks that the argument accepts s as a value in the call arguments, leaving its value untouched
+func (a *arg) validate(s string) error {
+	value := reflect.ValueOf(a.get())
+	defer a.value.Elem().Set(value)
+	return a.set(s)
+}
+
+/*
+ValidationError lists the problems found by Validate, one per line
+*/
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return strings.Join(e.Problems, "\n")
+}
+
+/*
+Validate checks the declarations of the app and of all its commands, e.g. in a test to catch the mistakes before they reach the users:
+
+	func TestApp(t *testing.T) {
+		if err := app.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+It reports the invalid specs (e.g. referencing an undeclared option or argument), the options and arguments declared but not used in the spec,
+the option names and argument names declared twice, the invalid declarations panicking when a command is initialized
+(e.g. Implies or ExactlyOne referencing an undeclared option), the invalid examples (see ValidateExamples),
+and the EnvVar names which are not portable environment variable names, i.e. not matching `[A-Z_][A-Z0-9_]*`, e.g. `my-app.token`.
+
+All the problems found are returned in a *ValidationError, or nil if there are none
+*/
+func (cli *Cli) Validate() error {
+	problems := []string{}
+	skip := map[*opt]bool{}
+	if cli.version != nil {
+		skip[cli.version.option] = true
+	}
+	cli.Cmd.validateTree(&problems, skip)
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// validateTree adds the problems of the declarations of the command and of its sub commands to problems,
+// the options in skip being not required to be used in the spec
+func (c *Cmd) validateTree(problems *[]string, skip map[*opt]bool) {
+	path := strings.Join(append(c.parents, c.name), " ")
+	report := func(format string, args ...interface{}) {
+		*problems = append(*problems, fmt.Sprintf("command %s: %s", path, fmt.Sprintf(format, args...)))
+	}
+
+	if err := c.doInitSafely(); err != nil {
+		report("%v", err)
+		if !c.declared {
+			return
+		}
+	}
+
+	for _, opt := range c.options {
+		for _, name := range opt.names {
+			if c.optionsIdx[name] != opt {
+				report("option name %s is declared more than once", name)
+			}
+		}
+	}
+	for _, arg := range c.args {
+		if c.argsIdx[arg.name] != arg {
+			report("argument %s is declared more than once", arg.name)
+		}
+	}
+
+	if used, err := c.specParams(); err == nil {
+		for _, opt := range c.options {
+			if !used[opt] && !skip[opt] {
+				report("option %s is not used in the spec %q", opt.displayName(), c.Spec)
+			}
+		}
+		for _, arg := range c.args {
+			if !used[arg] {
+				report("argument %s is not used in the spec %q", arg.name, c.Spec)
+			}
+		}
+	}
+
+	for _, opt := range c.options {
+		if len(opt.example) == 0 {
+			continue
+		}
+		if err := opt.validate(opt.example); err != nil {
+			report("invalid example %q of option %s: %v", opt.example, opt.displayName(), err)
+		}
+	}
+	if c.declared {
+		for _, example := range c.Examples {
+			if err := c.validateExample(example); err != nil {
+				report("invalid example %q: %v", example, err)
+			}
+		}
+	}
+
+	for _, opt := range c.options {
+		for _, ev := range envVarNames(opt.envVar) {
+			if !envVarNameRe.MatchString(ev) {
+				report("malformed environment variable name %q of option %s", ev, opt.displayName())
+			}
+		}
+	}
+	for _, arg := range c.args {
+		for _, ev := range envVarNames(arg.envVar) {
+			if !envVarNameRe.MatchString(ev) {
+				report("malformed environment variable name %q of argument %s", ev, arg.name)
+			}
+		}
+	}
+
+	for _, sub := range c.commands {
+		sub.parents = append(append([]string{}, c.parents...), c.name)
+		sub.validateTree(problems, nil)
+	}
+}
+
+// doInitSafely initializes the command, reporting the panics of its initializer as errors
+func (c *Cmd) doInitSafely() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return c.doInit()
+}
+
+// specParams returns the options and arguments referenced by the command spec, [OPTIONS] referencing all the options
+func (c *Cmd) specParams() (map[interface{}]bool, error) {
+	tokens, err := uTokenize(c.Spec)
+	if err != nil {
+		return nil, err
+	}
+	res := map[interface{}]bool{}
+	for _, token := range tokens {
+		switch token.typ {
+		case utOptions:
+			for _, opt := range c.options {
+				res[opt] = true
+			}
+		case utShortOpt, utLongOpt:
+			if opt, found := c.optionsIdx[token.val]; found {
+				res[opt] = true
+			}
+		case utOptSeq:
+			for _, r := range token.val {
+				if opt, found := c.optionsIdx["-"+string(r)]; found {
+					res[opt] = true
+				}
+			}
+		case utPos:
+			if arg, found := c.argsIdx[token.val]; found {
+				res[arg] = true
+			}
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,190 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateExamples(t *testing.T) {
+	var (
+		workers  *int
+		tags     *[]string
+		src      *string
+		port     = "8080"
+		examples []string
+		calls    int
+		called   bool
+	)
+	init := func(app *Cli) {
+		app.Examples = examples
+		app.Command("serve", "", func(cmd *Cmd) {
+			cmd.Spec = "[OPTIONS] [SRC]"
+			cmd.String(StringOpt{Name: "url", Value: "", Example: "https://api.example.com"})
+			cmd.String(StringOpt{Name: "level", Value: "info", Example: "debug", Transforms: []func(string) (string, error){func(s string) (string, error) {
+				calls++
+				return s, nil
+			}}})
+			workers = cmd.Int(IntOpt{Name: "workers", Value: 4, Example: "1_000", AllowUnderscore: true, OnSet: func(int) {
+				calls++
+			}})
+			tags = cmd.Strings(StringsOpt{Name: "tag", Value: []string{"default"}, Example: "a"})
+			cmd.Int(IntOpt{Name: "port", Value: 80, Example: port})
+			src = cmd.String(StringArg{Name: "SRC", Value: "."})
+			cmd.Examples = []string{"--port 8080 /srv", "--level debug"}
+			cmd.Action = func() {
+				called = true
+			}
+		})
+	}
+
+	examples = []string{"serve --workers 1_000 --tag a --tag 'b c' /srv"}
+	app := testApp(init)
+	require.Nil(t, app.ValidateExamples())
+	require.Equal(t, 4, *workers, "the examples should be validated in a dry run")
+	require.Equal(t, []string{"default"}, *tags, "the examples should be validated in a dry run")
+	require.Equal(t, ".", *src, "the examples should be validated in a dry run")
+	require.Equal(t, SourceDefault, app.commands[0].optionsIdx["--workers"].source)
+	require.Equal(t, 0, calls, "the transforms and the callbacks should not be run in a dry run")
+	require.False(t, called, "the actions should not be run in a dry run")
+	require.Nil(t, app.Validate())
+
+	port = "eighty"
+	app = testApp(init)
+	err := app.ValidateExamples()
+	require.NotNil(t, err)
+	require.Equal(t, `invalid example "eighty" of option --port in command app serve: strconv.ParseInt: parsing "eighty": invalid syntax`, err.Error())
+	port = "8080"
+
+	cases := []struct {
+		example string
+		msg     string
+	}{
+		{"serve --port eighty", `invalid example "serve --port eighty" of command app: strconv.ParseInt: parsing "eighty": invalid syntax`},
+		{"serve --prot 80", `invalid example "serve --prot 80" of command app: incorrect usage`},
+		{"serve a b", `invalid example "serve a b" of command app: unexpected argument: b`},
+		{"serve --workers 1__0", `invalid example "serve --workers 1__0" of command app: invalid value for option --workers: misplaced underscore in 1__0`},
+		{"serve 'a", `invalid example "serve 'a" of command app: unclosed quote '`},
+	}
+	for _, cas := range cases {
+		examples = []string{cas.example}
+		app = testApp(init)
+		err := app.ValidateExamples()
+		require.NotNil(t, err, "example %q should have failed", cas.example)
+		require.Equal(t, cas.msg, err.Error())
+
+		err = app.Validate()
+		require.NotNil(t, err, "example %q should have failed", cas.example)
+		require.Equal(t, "command app: "+strings.Replace(cas.msg, " of command app", "", 1), err.Error())
+	}
+}
+
+func TestHelpExamples(t *testing.T) {
+	var stdErr string
+	defer captureAndRestoreOutput(nil, &stdErr)()
+
+	app := App("app", "")
+	app.Command("cp", "Copy files", func(cmd *Cmd) {
+		cmd.Spec = "[-r] SRC DST"
+		cmd.BoolOpt("r", false, "Recursive")
+		cmd.StringArg("SRC", "", "Source")
+		cmd.StringArg("DST", "", "Destination")
+		cmd.Examples = []string{"-r src dst", "a.txt b.txt"}
+	})
+	require.Nil(t, app.ValidateExamples())
+	app.commands[0].PrintHelp()
+
+	require.Equal(t, `
+Usage: app cp [-r] SRC DST
+
+Copy files
+
+Arguments:
+  SRC="" (string)   Source
+  DST="" (string)   Destination
+
+Options:
+  -r=false     Recursive
+
+Examples:
+  app cp -r src dst
+  app cp a.txt b.txt
+`, stdErr)
+}
+
+func trimLower(s string) (string, error) {
+	return strings.ToLower(strings.TrimSpace(s)), nil
+}
+
+func TestValidate(t *testing.T) {
+	app := App("app", "")
+	app.Version("version", "1.0")
+	app.Spec = "[-v] SRC"
+	app.BoolOpt("v", false, "")
+	app.StringOpt("output", "", "")
+	app.StringArg("SRC", "", "")
+	app.Command("cp", "", func(cmd *Cmd) {
+		cmd.BoolOpt("f force", false, "")
+		cmd.StringOpt("f", "", "")
+		cmd.StringArg("SRC", "", "")
+		cmd.StringArg("SRC", "", "")
+	})
+	app.Command("rm", "", func(cmd *Cmd) {
+		cmd.Spec = "[-x] FILE"
+		cmd.StringArg("FILE", "", "")
+	})
+	app.Command("mv", "", func(cmd *Cmd) {
+		cmd.BoolOpt("verbose", false, "")
+		cmd.Implies("verbose", "missing")
+	})
+	app.Command("remote", "", func(remote *Cmd) {
+		remote.Command("add", "", func(cmd *Cmd) {
+			cmd.Int(IntOpt{Name: "port", Example: "eighty"})
+			cmd.String(StringOpt{Name: "url", Example: "https://example.com"})
+		})
+	})
+
+	err := app.Validate()
+	require.NotNil(t, err)
+	problems := err.(*ValidationError).Problems
+	require.Len(t, problems, 7, "%s", err)
+	require.Equal(t, `command app: option --output is not used in the spec "[-v] SRC"`, problems[0])
+	require.Equal(t, "command app cp: option name -f is declared more than once", problems[1])
+	require.Equal(t, "command app cp: argument SRC is declared more than once", problems[2])
+	require.Equal(t, `command app cp: argument SRC is not used in the spec "[OPTIONS] SRC SRC "`, problems[3])
+	require.True(t, strings.HasPrefix(problems[4], "command app rm: Parse error at position 1:"), "unexpected problem %s", problems[4])
+	require.Equal(t, "command app mv: Undeclared option missing", problems[5])
+	require.Equal(t, `command app remote add: invalid example "eighty" of option --port: strconv.ParseInt: parsing "eighty": invalid syntax`, problems[6])
+	require.Equal(t, strings.Join(problems, "\n"), err.Error())
+
+	app = App("app", "")
+	app.Version("v version", "1.0")
+	app.StringOpt("output", "", "")
+	app.Command("cp", "", func(cmd *Cmd) {
+		cmd.Spec = "[-f] SRC"
+		cmd.BoolOpt("f", false, "")
+		cmd.StringArg("SRC", "", "")
+	})
+	require.Nil(t, app.Validate())
+}
+
+func TestValidateEnvVarNames(t *testing.T) {
+	app := App("app", "")
+	app.String(StringOpt{Name: "token", EnvVar: "APP_TOKEN _TOKEN TOKEN2"})
+	app.Strings(StringsArg{Name: "SRC", EnvVar: "APP_SRC"})
+	require.Nil(t, app.Validate())
+
+	app = App("app", "")
+	app.String(StringOpt{Name: "token", EnvVar: "APP_TOKEN app-token"})
+	app.Command("cp", "", func(cmd *Cmd) {
+		cmd.StringArg("SRC", "", "")
+		cmd.String(StringArg{Name: "DST", EnvVar: "2DST"})
+	})
+	err := app.Validate()
+	require.NotNil(t, err)
+	require.Equal(t, []string{
+		`command app: malformed environment variable name "app-token" of option --token`,
+		`command app cp: malformed environment variable name "2DST" of argument DST`,
+	}, err.(*ValidationError).Problems)
+}
@@ -0,0 +1,28 @@
+//go:build go1.18
+// +build go1.18
+
+package cli
+
+/*
+Value returns the current value of the command's option or argument named name, and false if there is none or if its type is not T.
+Options can be named with or without their dashes, e.g. `count` or `--count`:
+
+	count, ok := cli.Value[int](cmd, "count")
+	files, ok := cli.Value[[]string](cmd, "FILES")
+
+It is meant to be called once the call arguments got parsed, e.g. in an Action.
+The value is read in place, without parsing it again, and the option or argument is found using an index by name,
+so each call takes constant time whatever the number of options, and can be repeated, e.g. in a hot path
+*/
+func Value[T any](c *Cmd, name string) (T, bool) {
+	var zero T
+	v, found := c.valueOf(name)
+	if !found {
+		return zero, false
+	}
+	res, ok := v.(T)
+	if !ok {
+		return zero, false
+	}
+	return res, true
+}
@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+ValueSource provides values for the options of a command from an external store, e.g. the OS keyring or a remote config
+*/
+type ValueSource interface {
+	// Lookup returns the value of the option named name (its longest name without the dashes), and false if the source has none
+	Lookup(name string) (string, bool, error)
+}
+
+// cachedValueSource is implemented by the value sources caching their values, for the cache to be dropped when the app is reset
+type cachedValueSource interface {
+	ValueSource
+	reset()
+}
+
+/*
+ValueSources adds sources to be consulted in order for the values of the command's options which were set neither in the call arguments
+nor by an environment variable (or a config file, see ConfigFile), the first source having a value for an option winning:
+
+	cmd.ValueSources(KeyringSource{Keyring: myKeyring, Service: "app"})
+
+A source failing to lookup a value is reported as a usage error
+*/
+func (c *Cmd) ValueSources(sources ...ValueSource) {
+	c.valueSources = append(c.valueSources, sources...)
+}
+
+func (c *Cmd) applyValueSources() error {
+	if len(c.valueSources) == 0 {
+		return nil
+	}
+	for _, opt := range c.options {
+		if opt.source != SourceDefault || opt == c.jsonFlags || opt == c.configFile {
+			continue
+		}
+		name := strings.TrimLeft(opt.displayName(), "-")
+		for _, source := range c.valueSources {
+			v, found, err := source.Lookup(name)
+			if err != nil {
+				return fmt.Errorf("failed to lookup the value of option %s: %v", opt.displayName(), err)
+			}
+			if !found {
+				continue
+			}
+			if err := opt.checkImmutable(SourceExternal); err != nil {
+				return err
+			}
+			// the values are validated like the call arguments ones, a slice option value being split like an env variable one
+			strs := []string{v}
+			if opt.isMulti() {
+				strs = strings.Split(v, opt.envSep())
+				for i := range strs {
+					strs[i] = strings.TrimSpace(strs[i])
+				}
+			}
+			if err := opt.setValues(strs); err != nil {
+				return fmt.Errorf("invalid value for option %s: %v", opt.displayName(), err)
+			}
+			opt.source = SourceExternal
+			break
+		}
+	}
+	return nil
+}
+
+/*
+Keyring gives access to the secrets stored in the OS keyring or a secret service.
+It is meant to be implemented using a keyring library, keeping this dependency optional
+*/
+type Keyring interface {
+	// Get returns the secret stored for the service and account, and false if there is none
+	Get(service, account string) (string, bool, error)
+}
+
+/*
+NoKeyring is a Keyring which stores no secrets, e.g. to be used on the platforms without a keyring
+*/
+type NoKeyring struct{}
+
+// Get always reports that there is no secret
+func (NoKeyring) Get(service, account string) (string, bool, error) {
+	return "", false, nil
+}
+
+/*
+KeyringSource is a ValueSource reading the options values from a Keyring:
+
+	cmd.ValueSources(KeyringSource{
+		Keyring:  myKeyring,
+		Service:  "app",
+		Accounts: map[string]string{"token": "api-token"},
+	})
+*/
+type KeyringSource struct {
+	// The keyring the secrets are read from
+	Keyring Keyring
+	// The service the secrets are stored under
+	Service string
+	// The accounts the secrets are stored under, by option name (without the dashes).
+	// If nil, every option is looked up using its name as the account
+	Accounts map[string]string
+}
+
+// Lookup reads the secret stored in the keyring for the option
+func (s KeyringSource) Lookup(name string) (string, bool, error) {
+	account := name
+	if s.Accounts != nil {
+		var found bool
+		if account, found = s.Accounts[name]; !found {
+			return "", false, nil
+		}
+	}
+	return s.Keyring.Get(s.Service, account)
+}
@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeKeyring map[string]string
+
+func (k fakeKeyring) Get(service, account string) (string, bool, error) {
+	if service == "broken" {
+		return "", false, errors.New("keyring locked")
+	}
+	v, found := k[service+"/"+account]
+	return v, found, nil
+}
+
+func TestValueSources(t *testing.T) {
+	defer suppressOutput()()
+
+	keyring := fakeKeyring{
+		"app/api-token": "s3cr3t",
+		"app/user":      "keyring-user",
+		"app/retries":   "5",
+		"other/user":    "other-user",
+	}
+
+	var (
+		token   *string
+		user    *string
+		retries *int
+		tags    *[]string
+		sources []ValueSource
+	)
+	init := func(app *Cli) {
+		token = app.String(StringOpt{Name: "t token", Value: "", EnvVar: "VS_TOKEN"})
+		user = app.StringOpt("u user", "root", "")
+		retries = app.IntOpt("retries", 1, "")
+		tags = app.StringsOpt("tag", []string{"x"}, "")
+		app.ValueSources(sources...)
+	}
+
+	sources = []ValueSource{KeyringSource{Keyring: keyring, Service: "app", Accounts: map[string]string{"token": "api-token"}}}
+	app := testApp(init)
+	require.Nil(t, app.Run([]string{"app"}))
+	require.Equal(t, "s3cr3t", *token)
+	require.Equal(t, "root", *user, "only the configured accounts should be looked up")
+	require.Equal(t, SourceExternal, app.optionsIdx["--token"].source)
+
+	sources = []ValueSource{KeyringSource{Keyring: keyring, Service: "app"}, KeyringSource{Keyring: keyring, Service: "other"}}
+	app = testApp(init)
+	require.Nil(t, app.Run([]string{"app"}))
+	require.Equal(t, "keyring-user", *user, "the first source with a value should win")
+	require.Equal(t, 5, *retries)
+	require.Equal(t, []string{"x"}, *tags)
+
+	sources = []ValueSource{KeyringSource{Keyring: keyring, Service: "app"}}
+	app = testApp(init)
+	require.Nil(t, app.Run([]string{"app", "-u", "cli-user"}))
+	require.Equal(t, "cli-user", *user, "the call arguments should take precedence")
+
+	os.Setenv("VS_TOKEN", "env-token")
+	sources = []ValueSource{KeyringSource{Keyring: keyring, Service: "app", Accounts: map[string]string{"token": "api-token"}}}
+	app = testApp(init)
+	require.Nil(t, app.Run([]string{"app"}))
+	os.Setenv("VS_TOKEN", "")
+	require.Equal(t, "env-token", *token, "the env variables should take precedence")
+
+	sources = []ValueSource{KeyringSource{Keyring: NoKeyring{}, Service: "app"}}
+	app = testApp(init)
+	require.Nil(t, app.Run([]string{"app"}))
+	require.Equal(t, "root", *user)
+	require.Equal(t, SourceDefault, app.optionsIdx["--user"].source)
+
+	sources = []ValueSource{KeyringSource{Keyring: keyring, Service: "broken"}}
+	app = testApp(init)
+	err := app.Run([]string{"app"})
+	require.NotNil(t, err)
+	require.Equal(t, "failed to lookup the value of option --token: keyring locked", err.Error())
+
+	sources = []ValueSource{KeyringSource{Keyring: fakeKeyring{"app/retries": "many"}, Service: "app"}}
+	app = testApp(init)
+	err = app.Run([]string{"app"})
+	require.NotNil(t, err)
+	require.Equal(t, `invalid value for option --retries: strconv.ParseInt: parsing "many": invalid syntax`, err.Error())
+
+	sources = []ValueSource{KeyringSource{Keyring: fakeKeyring{"app/tag": "a, b"}, Service: "app"}}
+	app = testApp(init)
+	require.Nil(t, app.Run([]string{"app"}))
+	require.Equal(t, []string{"a", "b"}, *tags, "the slice values should be split like the env variables ones")
+}
+
+func TestValueSourcesValidation(t *testing.T) {
+	defer suppressOutput()()
+
+	max := 5
+	var (
+		level   *string
+		keyring = fakeKeyring{"app/level": " debug "}
+	)
+	init := func(app *Cli) {
+		level = app.String(StringOpt{Name: "level", Value: "info", Choices: []string{"info", "debug"}, Transforms: []func(string) (string, error){trimSpace}})
+		app.Int(IntOpt{Name: "n", Value: 1, Max: &max})
+		app.String(StringOpt{Name: "name", Pattern: "^[a-z]+$"})
+		app.ValueSources(KeyringSource{Keyring: keyring, Service: "app"})
+	}
+
+	require.Nil(t, runApp(init))
+	require.Equal(t, "debug", *level, "the transforms should be applied")
+
+	cases := []struct {
+		keyring fakeKeyring
+		msg     string
+	}{
+		{fakeKeyring{"app/level": "bogus"}, "invalid value for option --level: invalid value bogus for option --level: expected one of [info debug]"},
+		{fakeKeyring{"app/n": "99"}, "invalid value for option -n: invalid value for option -n: value 99 exceeds maximum 5"},
+		{fakeKeyring{"app/name": "123"}, "invalid value for option --name: invalid value 123 for option --name: expected a value matching ^[a-z]+$"},
+	}
+	for _, cas := range cases {
+		keyring = cas.keyring
+		err := runApp(init)
+		require.NotNil(t, err, "keyring %v", cas.keyring)
+		require.Equal(t, cas.msg, err.Error())
+	}
+}
+
+func trimSpace(s string) (string, error) {
+	return strings.TrimSpace(s), nil
+}
@@ -0,0 +1,89 @@
+//go:build go1.18
+// +build go1.18
+
+package cli
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValue(t *testing.T) {
+	defer exitShouldNotCalled(t)()
+	defer suppressOutput()()
+
+	app := App("app", "")
+	app.Spec = "[OPTIONS] FILES..."
+	app.StringOpt("o output", "out", "")
+	app.IntOpt("count", 1, "")
+	app.DurationOpt("timeout", time.Second, "")
+	app.StringsArg("FILES", nil, "")
+
+	var cmd *Cmd
+	app.Action = func() {
+		cmd = app.Cmd
+	}
+	app.Run([]string{"app", "-o", "x", "--count", "3", "a", "b"})
+	require.NotNil(t, cmd)
+
+	output, ok := Value[string](cmd, "output")
+	require.True(t, ok)
+	require.Equal(t, "x", output)
+
+	output, ok = Value[string](cmd, "-o")
+	require.True(t, ok)
+	require.Equal(t, "x", output)
+
+	count, ok := Value[int](cmd, "count")
+	require.True(t, ok)
+	require.Equal(t, 3, count)
+
+	timeout, ok := Value[time.Duration](cmd, "--timeout")
+	require.True(t, ok)
+	require.Equal(t, time.Second, timeout)
+
+	files, ok := Value[[]string](cmd, "FILES")
+	require.True(t, ok)
+	require.Equal(t, []string{"a", "b"}, files)
+
+	badCount, ok := Value[string](cmd, "count")
+	require.False(t, ok, "the type param should match the value type")
+	require.Equal(t, "", badCount)
+
+	badFiles, ok := Value[[]int](cmd, "FILES")
+	require.False(t, ok, "the type param should match the value type")
+	require.Nil(t, badFiles)
+
+	_, ok = Value[string](cmd, "missing")
+	require.False(t, ok)
+}
+
+func BenchmarkValue(b *testing.B) {
+	app := App("app", "")
+	for i := 0; i < 100; i++ {
+		app.IntOpt(fmt.Sprintf("opt%d", i), i, "")
+	}
+	count := app.IntOpt("c count", 3, "")
+	app.StringsArg("FILES", nil, "")
+
+	b.Run("pointer", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = *count
+		}
+	})
+	for _, name := range []string{"--count", "count", "c"} {
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				Value[int](app.Cmd, name)
+			}
+		})
+	}
+	b.Run("FILES", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			Value[[]string](app.Cmd, "FILES")
+		}
+	})
+}